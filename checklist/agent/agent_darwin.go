@@ -0,0 +1,5 @@
+package agent
+
+func detect(p *Platform) Status {
+	return Status{}
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// unitDirs are searched in systemd's precedence order.
+var unitDirs = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/lib/systemd/system",
+	"/lib/systemd/system",
+}
+
+var cgroupDirs = []string{
+	"/sys/fs/cgroup/system.slice",
+	"/sys/fs/cgroup/systemd/system.slice",
+}
+
+func detect(p *Platform) Status {
+	var st Status
+	for _, unit := range findUnits(p.Services) {
+		st.Installed = true
+		st.Services = append(st.Services, unit)
+		if unitEnabled(unit) {
+			st.Enabled = true
+		}
+		if unitRunning(unit) {
+			st.Running = true
+		}
+	}
+	if !st.Running && len(p.Processes) > 0 && processRunning(p.Processes) {
+		st.Installed = true
+		st.Running = true
+	}
+	return st
+}
+
+// findUnits returns the unit file names matching patterns, skipping units
+// masked by a symlink to /dev/null.
+func findUnits(patterns []string) []string {
+	var units []string
+	seen := make(map[string]struct{})
+	for _, dir := range unitDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if _, ok := seen[name]; ok || !matchName(patterns, name) {
+				continue
+			}
+			seen[name] = struct{}{}
+			if target, err := os.Readlink(filepath.Join(dir, name)); err == nil && target == "/dev/null" {
+				continue
+			}
+			units = append(units, name)
+		}
+	}
+	slices.Sort(units)
+	return units
+}
+
+// unitEnabled looks for the unit in a *.wants or *.requires directory, which
+// is what `systemctl enable` creates.
+func unitEnabled(unit string) bool {
+	for _, dir := range unitDirs {
+		for _, suffix := range []string{"*.wants", "*.requires"} {
+			matches, _ := filepath.Glob(filepath.Join(dir, suffix, unit))
+			if len(matches) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unitRunning reports whether the unit's cgroup currently holds processes.
+func unitRunning(unit string) bool {
+	for _, dir := range cgroupDirs {
+		content, err := os.ReadFile(filepath.Join(dir, unit, "cgroup.procs"))
+		if err == nil && strings.TrimSpace(string(content)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func processRunning(patterns []string) bool {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.Trim(e.Name(), "0123456789") != "" {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if matchName(patterns, strings.TrimSpace(string(comm))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+type windowsService struct {
+	Name      string `json:"Name"`
+	State     string `json:"State"`
+	StartMode string `json:"StartMode"`
+}
+
+func detect(p *Platform) Status {
+	var st Status
+	services, err := listServices()
+	if err == nil {
+		for _, svc := range services {
+			if !matchName(p.Services, svc.Name) {
+				continue
+			}
+			st.Installed = true
+			st.Services = append(st.Services, svc.Name)
+			if strings.EqualFold(svc.State, "Running") {
+				st.Running = true
+			}
+			if strings.HasPrefix(strings.ToLower(svc.StartMode), "auto") {
+				st.Enabled = true
+			}
+		}
+	}
+	slices.Sort(st.Services)
+	if !st.Running && len(p.Processes) > 0 {
+		processes, err := listProcesses()
+		if err == nil && slices.ContainsFunc(processes, func(name string) bool {
+			return matchName(p.Processes, name)
+		}) {
+			st.Installed = true
+			st.Running = true
+		}
+	}
+	return st
+}
+
+func listServices() ([]windowsService, error) {
+	cmd := exec.Command("powershell", "-Command",
+		"Get-CimInstance Win32_Service | Select-Object Name, State, StartMode | ConvertTo-Json -Compress")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	output = []byte(strings.TrimSpace(string(output)))
+	var services []windowsService
+	if len(output) > 0 && output[0] == '{' {
+		// ConvertTo-Json emits a bare object when there is a single result.
+		var svc windowsService
+		if err = json.Unmarshal(output, &svc); err != nil {
+			return nil, err
+		}
+		return []windowsService{svc}, nil
+	}
+	if err = json.Unmarshal(output, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+func listProcesses() ([]string, error) {
+	cmd := exec.Command("powershell", "-Command", "Get-Process | Select-Object -ExpandProperty ProcessName")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
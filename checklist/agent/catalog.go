@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// Agent describes how to recognise one endpoint agent on each OS.
+type Agent struct {
+	Name    string    `json:"name"`
+	Linux   *Platform `json:"linux,omitempty"`
+	Windows *Platform `json:"windows,omitempty"`
+	Darwin  *Platform `json:"darwin,omitempty"`
+}
+
+// Platform lists the artefacts an agent leaves on one OS. Service and
+// process names may contain shell-style wildcards such as "AVP*".
+type Platform struct {
+	Services       []string `json:"services,omitempty"`
+	Processes      []string `json:"processes,omitempty"`
+	ConfigPaths    []string `json:"config_paths,omitempty"`
+	VersionCommand []string `json:"version_command,omitempty"`
+}
+
+// Status is what was found for one agent on this host.
+type Status struct {
+	Name      string
+	Installed bool
+	Running   bool
+	Enabled   bool
+	Version   string
+	Services  []string
+}
+
+// DefaultCatalog is used when no catalog file is given.
+var DefaultCatalog = []Agent{
+	{
+		Name: "Kaspersky Endpoint Security",
+		Linux: &Platform{
+			Services:       []string{"kesl.service", "kesl-supervisor.service"},
+			Processes:      []string{"kesl"},
+			ConfigPaths:    []string{"/etc/opt/kaspersky/kesl"},
+			VersionCommand: []string{"/opt/kaspersky/kesl/bin/kesl-control", "--app-info"},
+		},
+		Windows: &Platform{
+			Services:  []string{"AVP*"},
+			Processes: []string{"avp"},
+		},
+	},
+	{
+		Name: "Kaspersky Network Agent",
+		Linux: &Platform{
+			Services:    []string{"klnagent64.service", "klnagent.service"},
+			Processes:   []string{"klnagent"},
+			ConfigPaths: []string{"/etc/opt/kaspersky/klnagent.conf"},
+		},
+		Windows: &Platform{
+			Services:  []string{"klnagent"},
+			Processes: []string{"klnagent"},
+		},
+	},
+	{
+		Name: "SIEM connector",
+		Windows: &Platform{
+			Services: []string{"scsm"},
+		},
+	},
+	{
+		Name: "Wazuh agent",
+		Linux: &Platform{
+			Services:       []string{"wazuh-agent.service"},
+			Processes:      []string{"wazuh-agentd"},
+			ConfigPaths:    []string{"/var/ossec/etc/ossec.conf"},
+			VersionCommand: []string{"/var/ossec/bin/wazuh-control", "info", "-v"},
+		},
+		Windows: &Platform{
+			Services:    []string{"WazuhSvc"},
+			Processes:   []string{"wazuh-agent"},
+			ConfigPaths: []string{`C:\Program Files (x86)\ossec-agent\ossec.conf`},
+		},
+	},
+	{
+		Name: "CrowdStrike Falcon",
+		Linux: &Platform{
+			Services:       []string{"falcon-sensor.service"},
+			Processes:      []string{"falcon-sensor"},
+			ConfigPaths:    []string{"/opt/CrowdStrike/falconctl"},
+			VersionCommand: []string{"/opt/CrowdStrike/falconctl", "-g", "--version"},
+		},
+		Windows: &Platform{
+			Services:  []string{"CSFalconService"},
+			Processes: []string{"CSFalconService"},
+		},
+	},
+	{
+		Name: "Microsoft Defender for Endpoint",
+		Linux: &Platform{
+			Services:       []string{"mdatp.service"},
+			Processes:      []string{"wdavdaemon"},
+			ConfigPaths:    []string{"/etc/opt/microsoft/mdatp"},
+			VersionCommand: []string{"mdatp", "version"},
+		},
+		Windows: &Platform{
+			Services:  []string{"WinDefend", "Sense"},
+			Processes: []string{"MsMpEng"},
+		},
+	},
+}
+
+// LoadCatalog reads a JSON array of agents from path.
+func LoadCatalog(path string) ([]Agent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var catalog []Agent
+	if err = json.Unmarshal(content, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse agent catalog %s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// Collect reports the status of every agent in catalog that has an entry for
+// the running OS.
+func Collect(catalog []Agent) []Status {
+	statuses := make([]Status, 0, len(catalog))
+	for _, a := range catalog {
+		p := a.platform()
+		if p == nil {
+			continue
+		}
+		st := detect(p)
+		st.Name = a.Name
+		if !st.Installed {
+			st.Installed = configExists(p.ConfigPaths)
+		}
+		if st.Installed && len(p.VersionCommand) > 0 {
+			st.Version = version(p.VersionCommand)
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// Format renders statuses in the checklist text layout.
+func Format(statuses []Status) string {
+	var result string
+	for _, st := range statuses {
+		result += fmt.Sprintf("****%s****\n", st.Name)
+		result += fmt.Sprintf("installed: %t\n", st.Installed)
+		result += fmt.Sprintf("running: %t\n", st.Running)
+		result += fmt.Sprintf("enabled: %t\n", st.Enabled)
+		if st.Version != "" {
+			result += fmt.Sprintf("version: %s\n", st.Version)
+		}
+		if len(st.Services) > 0 {
+			result += fmt.Sprintf("services: %s\n", strings.Join(st.Services, ", "))
+		}
+	}
+	return result
+}
+
+func (a Agent) platform() *Platform {
+	switch runtime.GOOS {
+	case "linux":
+		return a.Linux
+	case "windows":
+		return a.Windows
+	case "darwin":
+		return a.Darwin
+	}
+	return nil
+}
+
+func configExists(paths []string) bool {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func version(command []string) string {
+	output, err := exec.Command(command[0], command[1:]...).Output()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// matchName reports whether name matches any of the patterns, ignoring case.
+func matchName(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
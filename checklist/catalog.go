@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+
+	"checklist/catalog"
+	"checklist/platform"
+
+	"github.com/spf13/cobra"
+)
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Look up controls and their script on each distribution",
+}
+
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every control in the catalog",
+	Args:  cobra.NoArgs,
+	RunE:  runCatalogList,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var catalogShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a control by its canonical ID or any distribution's script ID",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCatalogShow,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	catalogCmd.AddCommand(catalogListCmd, catalogShowCmd)
+	rootCmd.AddCommand(catalogCmd)
+}
+
+func runCatalogList(cmd *cobra.Command, args []string) error {
+	c, err := catalog.Load()
+	if err != nil {
+		return err
+	}
+	fmt.Print(c.List())
+	return nil
+}
+
+func runCatalogShow(cmd *cobra.Command, args []string) error {
+	c, err := catalog.Load()
+	if err != nil {
+		return err
+	}
+	ctl, ok := c.Find(args[0])
+	if !ok {
+		return fmt.Errorf("unknown control: %s", args[0])
+	}
+	fmt.Print(ctl.Show())
+	if script, ok := localScript(args[0]); ok {
+		fmt.Printf("local: %s\n", script)
+	}
+	return nil
+}
+
+// localScript resolves a control or any distribution's script ID to the
+// script of the same control in this host's series.
+func localScript(id string) (string, bool) {
+	c, err := catalog.Load()
+	if err != nil {
+		return "", false
+	}
+	ctl, ok := c.Find(id)
+	if !ok {
+		return "", false
+	}
+	series, ok := platform.Detect().Series()
+	if !ok {
+		return "", false
+	}
+	s, ok := ctl.Scripts[series.Distro]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s.sh", series.Distro, s.ID), true
+}
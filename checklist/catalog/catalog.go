@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Distributions are the script directories, in the order they are reported.
+var Distributions = []string{
+	"ubuntu",
+	"debian",
+	"centos",
+	"redhat",
+	"rockey",
+	"suse",
+	"oraclelinux",
+}
+
+var severities = []string{"low", "medium", "high"}
+
+// Script is the check implementing a control on one distribution.
+type Script struct {
+	ID string `yaml:"id"`
+	// Title overrides the control title when the script checks a
+	// distribution-specific variant, such as ufw instead of firewalld.
+	Title string `yaml:"title,omitempty"`
+}
+
+// Control is one canonical check and its script on every distribution.
+type Control struct {
+	ID       string            `yaml:"id"`
+	Title    string            `yaml:"title"`
+	CIS      string            `yaml:"cis"`
+	Severity string            `yaml:"severity"`
+	Scripts  map[string]Script `yaml:"scripts"`
+	// Gaps records why a distribution has no script for the control.
+	Gaps map[string]string `yaml:"gaps,omitempty"`
+}
+
+// Catalog is the full list of controls.
+type Catalog struct {
+	Controls []Control `yaml:"controls"`
+}
+
+//go:embed catalog.yaml
+var embedded []byte
+
+// Load parses the catalog shipped with the binary.
+func Load() (*Catalog, error) {
+	return Parse(embedded)
+}
+
+// Parse reads a catalog from YAML.
+func Parse(content []byte) (*Catalog, error) {
+	var c Catalog
+	if err := yaml.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	return &c, nil
+}
+
+// Find returns the control with the given canonical ID or whose script on
+// any distribution has that ID.
+func (c *Catalog) Find(id string) (*Control, bool) {
+	for i := range c.Controls {
+		if c.Controls[i].ID == id {
+			return &c.Controls[i], true
+		}
+	}
+	for i := range c.Controls {
+		for _, s := range c.Controls[i].Scripts {
+			if s.ID == id {
+				return &c.Controls[i], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Missing lists the distributions that have neither a script nor a recorded
+// gap for the control.
+func (ctl Control) Missing() []string {
+	var missing []string
+	for _, d := range Distributions {
+		_, hasScript := ctl.Scripts[d]
+		_, hasGap := ctl.Gaps[d]
+		if !hasScript && !hasGap {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// Validate reports every inconsistency in the catalog: missing fields,
+// unknown distributions or severities, duplicate IDs and distributions
+// without a script or a recorded gap.
+func (c *Catalog) Validate() []string {
+	var problems []string
+	controls := make(map[string]struct{})
+	scripts := make(map[string]string)
+	for _, ctl := range c.Controls {
+		if ctl.ID == "" || ctl.Title == "" || ctl.CIS == "" {
+			problems = append(problems, fmt.Sprintf("%q: id, title and cis are required", ctl.ID))
+		}
+		if _, ok := controls[ctl.ID]; ok {
+			problems = append(problems, fmt.Sprintf("%s: duplicate control", ctl.ID))
+		}
+		controls[ctl.ID] = struct{}{}
+		if !slices.Contains(severities, ctl.Severity) {
+			problems = append(problems, fmt.Sprintf("%s: unknown severity %q", ctl.ID, ctl.Severity))
+		}
+		for d, s := range ctl.Scripts {
+			if !slices.Contains(Distributions, d) {
+				problems = append(problems, fmt.Sprintf("%s: unknown distribution %q", ctl.ID, d))
+			}
+			if _, ok := ctl.Gaps[d]; ok {
+				problems = append(problems, fmt.Sprintf("%s: %s has both a script and a gap", ctl.ID, d))
+			}
+			key := d + "/" + s.ID
+			if other, ok := scripts[key]; ok {
+				problems = append(problems, fmt.Sprintf("%s: %s.sh is already mapped to %s", ctl.ID, key, other))
+			}
+			scripts[key] = ctl.ID
+		}
+		for _, d := range ctl.Missing() {
+			problems = append(problems, fmt.Sprintf("%s: no script for %s", ctl.ID, d))
+		}
+	}
+	slices.Sort(problems)
+	return problems
+}
+
+// Show renders one control in the checklist text layout.
+func (ctl Control) Show() string {
+	var result string
+	result += fmt.Sprintf("****%s****\n", ctl.ID)
+	result += fmt.Sprintf("title: %s\n", ctl.Title)
+	result += fmt.Sprintf("cis: %s\n", ctl.CIS)
+	result += fmt.Sprintf("severity: %s\n", ctl.Severity)
+	for _, d := range Distributions {
+		if s, ok := ctl.Scripts[d]; ok {
+			result += fmt.Sprintf("+%s/%s.sh", d, s.ID)
+			if s.Title != "" {
+				result += fmt.Sprintf(" (%s)", s.Title)
+			}
+			result += "\n"
+			continue
+		}
+		reason := ctl.Gaps[d]
+		if reason == "" {
+			reason = "not implemented"
+		}
+		result += fmt.Sprintf("-%s: %s\n", d, reason)
+	}
+	return result
+}
+
+// List renders one line per control.
+func (c *Catalog) List() string {
+	var b strings.Builder
+	for _, ctl := range c.Controls {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\n", ctl.ID, ctl.CIS, ctl.Severity, ctl.Title)
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptRoot is the repository root holding one directory per distribution.
+var scriptRoot = filepath.Join("..", "..")
+
+func load(t *testing.T) *Catalog {
+	t.Helper()
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCatalogIsConsistent(t *testing.T) {
+	for _, problem := range load(t).Validate() {
+		t.Error(problem)
+	}
+}
+
+func TestMappedScriptsExist(t *testing.T) {
+	for _, ctl := range load(t).Controls {
+		for d, s := range ctl.Scripts {
+			path := filepath.Join(scriptRoot, d, s.ID+".sh")
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("%s: %v", ctl.ID, err)
+			}
+		}
+	}
+}
+
+func TestEveryScriptIsCatalogued(t *testing.T) {
+	c := load(t)
+	for _, d := range Distributions {
+		entries, err := os.ReadDir(filepath.Join(scriptRoot, d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			id, ok := strings.CutSuffix(e.Name(), ".sh")
+			if !ok || strings.Trim(id, "0123456789") != "" {
+				// Helpers such as rename.sh and check.sh are not controls.
+				continue
+			}
+			ctl, found := c.Find(id)
+			if !found || ctl.Scripts[d].ID != id {
+				t.Errorf("%s/%s is not in the catalog", d, e.Name())
+			}
+		}
+	}
+}
+
+func TestValidateFlagsMissingDistribution(t *testing.T) {
+	c, err := Parse([]byte(`
+controls:
+  - id: disable-cramfs
+    title: Ensure mounting of cramfs filesystems is disabled
+    cis: "1.1.1.1"
+    severity: low
+    scripts:
+      ubuntu: {id: "1001"}
+      debian: {id: "11001"}
+      centos: {id: "5001"}
+      redhat: {id: "2001"}
+      rockey: {id: "3001"}
+      suse: {id: "200"}
+    gaps:
+      centos: duplicated
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := c.Validate()
+	want := []string{
+		"disable-cramfs: centos has both a script and a gap",
+		"disable-cramfs: no script for oraclelinux",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate() = %q, want %q", problems, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	c := load(t)
+	for _, id := range []string{"disable-cramfs", "1001", "11001", "5001", "200", "100"} {
+		ctl, ok := c.Find(id)
+		if !ok || ctl.ID != "disable-cramfs" {
+			t.Errorf("Find(%q) = %v, %t", id, ctl, ok)
+		}
+	}
+	if _, ok := c.Find("9999"); ok {
+		t.Error("Find(\"9999\") found a control")
+	}
+}
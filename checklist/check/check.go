@@ -0,0 +1,39 @@
+package check
+
+import "fmt"
+
+// Result is the outcome of one check.
+type Result struct {
+	ID       string
+	Title    string
+	Findings []string
+}
+
+// Pass reports whether the check found nothing wrong.
+func (r Result) Pass() bool {
+	return len(r.Findings) == 0
+}
+
+// Format renders results under a "****header****" line: each check marked
+// + or - with its findings below it, then the number passed and failed.
+func Format(header string, results []Result) string {
+	var passed int
+	result := fmt.Sprintf("****%s****\n", header)
+	for _, r := range results {
+		mark := "-"
+		if r.Pass() {
+			mark = "+"
+			passed++
+		}
+		result += fmt.Sprintf("%s%s %s\n", mark, r.ID, r.Title)
+		for _, f := range r.Findings {
+			result += fmt.Sprintf("  %s\n", f)
+		}
+	}
+	result += fmt.Sprintf("passed: %d, failed: %d\n", passed, len(results)-passed)
+	return result
+}
+
+// WellKnownCommunities are SNMP community strings devices ship with or
+// that are guessed first.
+var WellKnownCommunities = []string{"public", "private", "admin", "monitor", "security"}
@@ -0,0 +1,499 @@
+package cisco
+
+import (
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	Permit = "permit"
+	Deny   = "deny"
+)
+
+// broadBits is the shortest prefix, per address family, that is not
+// considered overly broad for a management ACL.
+var broadBits = map[int]int{
+	32:  16,
+	128: 48,
+}
+
+// portNames are the named ports that matter for management planes.
+var portNames = map[string]int{
+	"ftp":    21,
+	"ssh":    22,
+	"telnet": 23,
+	"www":    80,
+	"http":   80,
+	"snmp":   161,
+	"https":  443,
+}
+
+// Address is one source or destination match. Wildcard is set only for
+// masks that are not contiguous and so cannot be written as a prefix.
+type Address struct {
+	Prefix   netip.Prefix
+	Wildcard netip.Addr
+}
+
+// Any reports whether the address matches everything in its family.
+func (a Address) Any() bool {
+	return !a.Wildcard.IsValid() && a.Prefix.Bits() == 0
+}
+
+// Broad reports whether the address covers more hosts than a management
+// ACL should admit.
+func (a Address) Broad() bool {
+	if a.Wildcard.IsValid() {
+		ones := 0
+		for _, b := range a.Wildcard.AsSlice() {
+			ones += bits.OnesCount8(b)
+		}
+		return a.Prefix.Addr().BitLen()-ones < broadBits[a.Prefix.Addr().BitLen()]
+	}
+	return a.Prefix.Bits() < broadBits[a.Prefix.Addr().BitLen()]
+}
+
+// Contains reports whether every address matched by other is matched by a.
+// Non-contiguous wildcards are only known to be contained by a prefix that
+// covers their whole base network.
+func (a Address) Contains(other Address) bool {
+	if a.Wildcard.IsValid() || a.Prefix.Addr().BitLen() != other.Prefix.Addr().BitLen() {
+		return false
+	}
+	return a.Prefix.Bits() <= other.Prefix.Bits() && a.Prefix.Contains(other.Prefix.Addr())
+}
+
+func (a Address) String() string {
+	if a.Wildcard.IsValid() {
+		return fmt.Sprintf("%s wildcard %s", a.Prefix.Addr(), a.Wildcard)
+	}
+	if a.Any() {
+		if a.Prefix.Addr().Is6() {
+			return "any6"
+		}
+		return "any"
+	}
+	return a.Prefix.String()
+}
+
+// Entry is one access control entry. An entry whose source is an object
+// group has one Sources element per group member.
+type Entry struct {
+	Line     int
+	Sequence string
+	Action   string
+	Protocol string
+	Sources  []Address
+	PortOp   string
+	Ports    []int
+	Text     string
+}
+
+// ACL is a numbered or named access list.
+type ACL struct {
+	Name    string
+	Kind    string
+	Line    int
+	Entries []Entry
+}
+
+// ObjectGroups maps an object group name to its addresses.
+type ObjectGroups map[string][]Address
+
+// ParseACLs collects every IPv4 and IPv6 access list in config.
+func ParseACLs(config *Config) (map[string]*ACL, error) {
+	groups, err := parseObjectGroups(config)
+	if err != nil {
+		return nil, err
+	}
+	acls := make(map[string]*ACL)
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 2 {
+			continue
+		}
+		switch {
+		case fields[0] == "access-list" && len(fields) > 2:
+			// Numbered ACLs have one global line per entry.
+			acl := acls[fields[1]]
+			if acl == nil {
+				acl = &ACL{Name: fields[1], Kind: numberedKind(fields[1]), Line: l.Number}
+				acls[fields[1]] = acl
+			}
+			if err := acl.addEntry(l, fields[2:], groups); err != nil {
+				return nil, err
+			}
+		case (fields[0] == "ip" || fields[0] == "ipv4" || fields[0] == "ipv6") && fields[1] == "access-list" && len(fields) > 2:
+			acl := &ACL{Name: fields[len(fields)-1], Kind: "extended", Line: l.Number}
+			if fields[0] == "ipv6" {
+				acl.Kind = "ipv6"
+			} else if fields[2] == "standard" {
+				acl.Kind = "standard"
+			}
+			for _, child := range children(config, l) {
+				if err := acl.addEntry(child, child.Fields(), groups); err != nil {
+					return nil, err
+				}
+			}
+			key := acl.Name
+			if fields[0] == "ipv6" {
+				key = "ipv6 " + acl.Name
+			}
+			acls[key] = acl
+		}
+	}
+	return acls, nil
+}
+
+func numberedKind(name string) string {
+	n, _ := strconv.Atoi(name)
+	if (n >= 1 && n <= 99) || (n >= 1300 && n <= 1999) {
+		return "standard"
+	}
+	return "extended"
+}
+
+// children returns the lines entered in the mode opened by parent.
+func children(config *Config, parent *Line) []*Line {
+	var lines []*Line
+	for _, l := range config.Lines {
+		if l.Parent == parent {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (acl *ACL) addEntry(l *Line, fields []string, groups ObjectGroups) error {
+	e := Entry{Line: l.Number, Text: l.Text}
+	if len(fields) > 0 && fields[0] == "sequence" && len(fields) > 1 {
+		e.Sequence, fields = fields[1], fields[2:]
+	} else if len(fields) > 0 {
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			e.Sequence, fields = fields[0], fields[1:]
+		}
+	}
+	if len(fields) == 0 || (fields[0] != Permit && fields[0] != Deny) {
+		// Remarks, statistics and other options.
+		return nil
+	}
+	e.Action, fields = fields[0], fields[1:]
+	v6 := acl.Kind == "ipv6"
+	if acl.Kind != "standard" {
+		if len(fields) == 0 {
+			return fmt.Errorf("line %d: missing protocol", l.Number)
+		}
+		e.Protocol, fields = fields[0], fields[1:]
+	}
+
+	sources, rest, err := parseAddress(fields, v6, groups, acl.Kind == "standard")
+	if err != nil {
+		return fmt.Errorf("line %d: %w", l.Number, err)
+	}
+	e.Sources = sources
+	if acl.Kind != "standard" {
+		_, _, rest = parsePorts(rest)
+		if _, after, err := parseAddress(rest, v6, groups, false); err == nil {
+			e.PortOp, e.Ports, _ = parsePorts(after)
+		}
+	}
+	acl.Entries = append(acl.Entries, e)
+	return nil
+}
+
+// parseAddress reads one address match from the front of fields and returns
+// the remaining fields. A standard ACL may give a bare host address.
+func parseAddress(fields []string, v6 bool, groups ObjectGroups, standard bool) ([]Address, []string, error) {
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("missing address")
+	}
+	switch fields[0] {
+	case "any", "any4", "any6":
+		bitLen := 32
+		if v6 || fields[0] == "any6" {
+			bitLen = 128
+		}
+		addr := netip.IPv4Unspecified()
+		if bitLen == 128 {
+			addr = netip.IPv6Unspecified()
+		}
+		return []Address{{Prefix: netip.PrefixFrom(addr, 0)}}, fields[1:], nil
+	case "host":
+		if len(fields) < 2 {
+			return nil, nil, fmt.Errorf("missing host address")
+		}
+		addr, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return []Address{{Prefix: netip.PrefixFrom(addr, addr.BitLen())}}, fields[2:], nil
+	case "object-group", "addrgroup", "net-group":
+		if len(fields) < 2 {
+			return nil, nil, fmt.Errorf("missing object group name")
+		}
+		members, ok := groups[fields[1]]
+		if !ok {
+			return nil, nil, fmt.Errorf("undefined object group %s", fields[1])
+		}
+		return members, fields[2:], nil
+	}
+	if strings.Contains(fields[0], "/") {
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return []Address{{Prefix: prefix.Masked()}}, fields[1:], nil
+	}
+	addr, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid address %q", fields[0])
+	}
+	if len(fields) > 1 {
+		if wildcard, err := netip.ParseAddr(fields[1]); err == nil && wildcard.Is4() == addr.Is4() {
+			return []Address{fromWildcard(addr, wildcard)}, fields[2:], nil
+		}
+	}
+	if !standard {
+		return nil, nil, fmt.Errorf("address %s needs a wildcard", fields[0])
+	}
+	return []Address{{Prefix: netip.PrefixFrom(addr, addr.BitLen())}}, fields[1:], nil
+}
+
+// fromWildcard converts "address wildcard" into a prefix when the wildcard
+// bits are contiguous.
+func fromWildcard(addr, wildcard netip.Addr) Address {
+	w := wildcard.AsSlice()
+	a := addr.AsSlice()
+	host := 0
+	contiguous := true
+	for i := range w {
+		a[i] &^= w[i]
+		for bit := 7; bit >= 0; bit-- {
+			if w[i]&(1<<bit) != 0 {
+				host++
+			} else if host > 0 {
+				// A zero after a one: the mask has a hole.
+				contiguous = false
+			}
+		}
+	}
+	base, _ := netip.AddrFromSlice(a)
+	if !contiguous {
+		return Address{Prefix: netip.PrefixFrom(base, base.BitLen()), Wildcard: wildcard}
+	}
+	return Address{Prefix: netip.PrefixFrom(base, base.BitLen()-host)}
+}
+
+// fromMask converts "address netmask", as used in object groups.
+func fromMask(addr, mask netip.Addr) Address {
+	m := mask.AsSlice()
+	inverted := make([]byte, len(m))
+	for i := range m {
+		inverted[i] = ^m[i]
+	}
+	wildcard, _ := netip.AddrFromSlice(inverted)
+	return fromWildcard(addr, wildcard)
+}
+
+// parsePorts reads an optional port match from the front of fields.
+func parsePorts(fields []string) (string, []int, []string) {
+	if len(fields) < 2 {
+		return "", nil, fields
+	}
+	op := fields[0]
+	var count int
+	switch op {
+	case "eq", "neq":
+		for count = 1; count < len(fields); count++ {
+			if _, ok := portNumber(fields[count]); !ok {
+				break
+			}
+		}
+		count--
+	case "gt", "lt":
+		count = 1
+	case "range":
+		count = 2
+	default:
+		return "", nil, fields
+	}
+	var ports []int
+	for _, f := range fields[1 : 1+min(count, len(fields)-1)] {
+		if p, ok := portNumber(f); ok {
+			ports = append(ports, p)
+		}
+	}
+	return op, ports, fields[1+min(count, len(fields)-1):]
+}
+
+func portNumber(field string) (int, bool) {
+	if p, ok := portNames[field]; ok {
+		return p, true
+	}
+	p, err := strconv.Atoi(field)
+	return p, err == nil
+}
+
+// MatchesPort reports whether the entry's destination port match admits port.
+func (e Entry) MatchesPort(port int) bool {
+	switch e.PortOp {
+	case "":
+		return true
+	case "eq":
+		for _, p := range e.Ports {
+			if p == port {
+				return true
+			}
+		}
+		return false
+	case "neq":
+		for _, p := range e.Ports {
+			if p == port {
+				return false
+			}
+		}
+		return true
+	case "gt":
+		return len(e.Ports) == 1 && port > e.Ports[0]
+	case "lt":
+		return len(e.Ports) == 1 && port < e.Ports[0]
+	case "range":
+		return len(e.Ports) == 2 && port >= e.Ports[0] && port <= e.Ports[1]
+	}
+	return false
+}
+
+// MatchesProtocol reports whether the entry applies to traffic of protocol.
+func (e Entry) MatchesProtocol(protocol string) bool {
+	switch e.Protocol {
+	case "", "ip", "ipv4", "ipv6", protocol:
+		return true
+	}
+	return false
+}
+
+// parseObjectGroups reads IOS-XE and IOS-XR "object-group network" and
+// NX-OS "object-group ip address" definitions.
+func parseObjectGroups(config *Config) (ObjectGroups, error) {
+	groups := make(ObjectGroups)
+	nested := make(map[string][]string)
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 3 || fields[0] != "object-group" {
+			continue
+		}
+		switch {
+		case fields[1] == "network", fields[1] == "ip" && fields[2] == "address", fields[1] == "ipv6" && fields[2] == "address":
+		default:
+			continue
+		}
+		name := fields[len(fields)-1]
+		v6 := fields[1] == "ipv6" || (len(fields) > 3 && fields[2] == "ipv6")
+		groups[name] = nil
+		for _, child := range children(config, l) {
+			cf := child.Fields()
+			if len(cf) > 0 {
+				if _, err := strconv.Atoi(cf[0]); err == nil {
+					cf = cf[1:]
+				}
+			}
+			if len(cf) == 0 || cf[0] == "description" {
+				continue
+			}
+			if cf[0] == "group-object" && len(cf) > 1 {
+				nested[name] = append(nested[name], cf[1])
+				continue
+			}
+			if cf[0] == "range" && len(cf) > 2 {
+				members, err := rangeAddresses(cf[1], cf[2])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", child.Number, err)
+				}
+				groups[name] = append(groups[name], members...)
+				continue
+			}
+			if len(cf) > 1 && !strings.Contains(cf[0], "/") && cf[0] != "host" {
+				// Object groups use a netmask, not a wildcard.
+				addr, err1 := netip.ParseAddr(cf[0])
+				mask, err2 := netip.ParseAddr(cf[1])
+				if err1 == nil && err2 == nil {
+					groups[name] = append(groups[name], fromMask(addr, mask))
+					continue
+				}
+			}
+			members, _, err := parseAddress(cf, v6, groups, true)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", child.Number, err)
+			}
+			groups[name] = append(groups[name], members...)
+		}
+	}
+	// Expand from the direct members only, so a group is not added again
+	// through one expanded earlier.
+	expanded := make(ObjectGroups)
+	for name := range nested {
+		expanded[name] = expandGroup(name, nested, groups, map[string]bool{})
+	}
+	for name, members := range expanded {
+		groups[name] = append(groups[name], members...)
+	}
+	return groups, nil
+}
+
+func expandGroup(name string, nested map[string][]string, groups ObjectGroups, seen map[string]bool) []Address {
+	var members []Address
+	for _, child := range nested[name] {
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		members = append(members, groups[child]...)
+		members = append(members, expandGroup(child, nested, groups, seen)...)
+	}
+	return members
+}
+
+// rangeAddresses covers the inclusive range first-last with prefixes.
+func rangeAddresses(first, last string) ([]Address, error) {
+	start, err := netip.ParseAddr(first)
+	if err != nil {
+		return nil, err
+	}
+	end, err := netip.ParseAddr(last)
+	if err != nil {
+		return nil, err
+	}
+	if start.BitLen() != end.BitLen() || end.Less(start) {
+		return nil, fmt.Errorf("invalid range %s %s", first, last)
+	}
+	var members []Address
+	for {
+		bitsLeft := start.BitLen()
+		for bitsLeft > 0 {
+			p := netip.PrefixFrom(start, bitsLeft-1).Masked()
+			if p.Addr() != start || lastAddr(p).Compare(end) > 0 {
+				break
+			}
+			bitsLeft--
+		}
+		p := netip.PrefixFrom(start, bitsLeft)
+		members = append(members, Address{Prefix: p})
+		next := lastAddr(p).Next()
+		if !next.IsValid() || end.Less(next) {
+			return members, nil
+		}
+		start = next
+	}
+}
+
+func lastAddr(p netip.Prefix) netip.Addr {
+	a := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(a)*8; i++ {
+		a[i/8] |= 1 << (7 - i%8)
+	}
+	last, _ := netip.AddrFromSlice(a)
+	return last
+}
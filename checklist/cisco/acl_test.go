@@ -0,0 +1,193 @@
+package cisco
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// parse reads a configuration given as text.
+func parse(t *testing.T, text, osName string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.cfg")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := ParseConfig(path, osName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestParseAddress(t *testing.T) {
+	for _, tc := range []struct {
+		fields   []string
+		standard bool
+		want     string
+		broad    bool
+	}{
+		{[]string{"any"}, false, "any", true},
+		{[]string{"any6"}, false, "any6", true},
+		{[]string{"host", "10.1.1.1"}, false, "10.1.1.1/32", false},
+		{[]string{"10.1.1.1"}, true, "10.1.1.1/32", false},
+		{[]string{"10.0.0.0", "0.0.0.255"}, false, "10.0.0.0/24", false},
+		// Host bits under the wildcard are cleared.
+		{[]string{"10.1.2.3", "0.0.255.255"}, false, "10.1.0.0/16", false},
+		{[]string{"10.0.0.0", "0.255.255.255"}, false, "10.0.0.0/8", true},
+		// A non-contiguous wildcard is as broad as the hosts it matches.
+		{[]string{"10.0.0.0", "0.255.0.255"}, false, "10.0.0.0 wildcard 0.255.0.255", false},
+		{[]string{"10.0.0.0", "0.255.255.254"}, false, "10.0.0.0 wildcard 0.255.255.254", true},
+		{[]string{"10.0.1.0", "0.0.254.255"}, false, "10.0.1.0 wildcard 0.0.254.255", false},
+		{[]string{"192.168.0.0/16"}, false, "192.168.0.0/16", false},
+		{[]string{"172.16.1.9/12"}, false, "172.16.0.0/12", true},
+		{[]string{"2001:db8::/32"}, false, "2001:db8::/32", true},
+		{[]string{"2001:db8:1::/48"}, false, "2001:db8:1::/48", false},
+	} {
+		addrs, _, err := parseAddress(tc.fields, false, nil, tc.standard)
+		if err != nil {
+			t.Errorf("%q: %v", tc.fields, err)
+			continue
+		}
+		if len(addrs) != 1 || addrs[0].String() != tc.want {
+			t.Errorf("%q: got %v, want %s", tc.fields, addrs, tc.want)
+			continue
+		}
+		if got := addrs[0].Broad(); got != tc.broad {
+			t.Errorf("%q: broad %t, want %t", tc.fields, got, tc.broad)
+		}
+	}
+	for _, fields := range [][]string{
+		{"10.0.0.0"},
+		{"host"},
+		{"object-group", "MISSING"},
+		{"not-an-address", "0.0.0.255"},
+	} {
+		if _, _, err := parseAddress(fields, false, ObjectGroups{}, false); err == nil {
+			t.Errorf("%q: no error", fields)
+		}
+	}
+}
+
+func TestAddressContains(t *testing.T) {
+	prefix := func(s string) Address { return Address{Prefix: netip.MustParsePrefix(s)} }
+	wildcard := fromWildcard(netip.MustParseAddr("10.0.0.0"), netip.MustParseAddr("0.255.0.255"))
+	for _, tc := range []struct {
+		a, b Address
+		want bool
+	}{
+		{prefix("10.0.0.0/8"), prefix("10.1.0.0/16"), true},
+		{prefix("10.1.0.0/16"), prefix("10.0.0.0/8"), false},
+		{prefix("10.0.0.0/8"), prefix("192.168.0.0/16"), false},
+		{prefix("0.0.0.0/0"), prefix("2001:db8::/32"), false},
+		{prefix("10.0.0.0/8"), wildcard, true},
+		{wildcard, prefix("10.1.0.1/32"), false},
+	} {
+		if got := tc.a.Contains(tc.b); got != tc.want {
+			t.Errorf("%s contains %s: got %t, want %t", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestObjectGroups(t *testing.T) {
+	config := parse(t, `object-group network MGMT
+ description management hosts
+ host 10.10.0.5
+ 10.10.1.0 255.255.255.0
+ range 10.10.2.0 10.10.2.15
+object-group network ALL-MGMT
+ group-object MGMT
+ 10.20.0.0 255.255.0.0
+object-group network LOOP
+ group-object LOOP
+ group-object ALL-MGMT
+ip access-list extended VTY
+ 10 permit tcp object-group ALL-MGMT any eq 22
+ 20 deny ip any any log
+`, IOSXE)
+	groups, err := parseObjectGroups(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs := func(addrs []Address) []string {
+		var s []string
+		for _, a := range addrs {
+			s = append(s, a.String())
+		}
+		return s
+	}
+	mgmt := []string{"10.10.0.5/32", "10.10.1.0/24", "10.10.2.0/28"}
+	if got := strs(groups["MGMT"]); !reflect.DeepEqual(got, mgmt) {
+		t.Errorf("MGMT: got %q, want %q", got, mgmt)
+	}
+	all := append([]string{"10.20.0.0/16"}, mgmt...)
+	if got := strs(groups["ALL-MGMT"]); !reflect.DeepEqual(got, all) {
+		t.Errorf("ALL-MGMT: got %q, want %q", got, all)
+	}
+	// A group that includes itself is expanded once.
+	if got := strs(groups["LOOP"]); !reflect.DeepEqual(got, all) {
+		t.Errorf("LOOP: got %q, want %q", got, all)
+	}
+
+	acls, err := ParseACLs(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strs(acls["VTY"].Entries[0].Sources); !reflect.DeepEqual(got, all) {
+		t.Errorf("entry sources: got %q, want %q", got, all)
+	}
+}
+
+func TestNXOSObjectGroup(t *testing.T) {
+	config := parse(t, `object-group ip address MGMT
+  10 10.10.0.0/24
+  20 host 10.10.1.1
+ip access-list VTY
+  10 permit tcp addrgroup MGMT any eq 22
+`, NXOS)
+	acls, err := ParseACLs(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range acls["VTY"].Permitted("tcp", 22) {
+		got = append(got, r.Address.String())
+	}
+	if want := []string{"10.10.0.0/24", "10.10.1.1/32"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPermitted(t *testing.T) {
+	config := parse(t, `access-list 10 permit 10.10.0.0 0.0.0.255
+access-list 10 deny any
+ip access-list extended VTY
+ 10 deny ip 10.1.0.0 0.0.255.255 any
+ 20 permit tcp 10.1.5.0 0.0.0.255 any eq 22
+ 30 permit tcp 10.20.0.0 0.0.0.255 any eq telnet
+ 40 permit udp any any eq snmp
+ 50 permit tcp 10.30.0.0 0.0.0.255 any range 20 30
+ 60 permit tcp 10.40.0.0 0.0.0.255 any neq 22
+ 70 permit ip 192.168.0.0 0.0.255.255 any
+ 80 permit tcp 10.50.0.0 0.0.0.255 eq 22 any
+`, IOSXE)
+	acls, err := ParseACLs(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := acls["10"].Kind; got != "standard" {
+		t.Errorf("10: kind %s", got)
+	}
+	var got []string
+	for _, r := range acls["VTY"].Permitted("tcp", 22) {
+		got = append(got, r.Sequence+" "+r.Address.String())
+	}
+	// 20 is shadowed by 10; 30, 40 and 60 match another port or protocol;
+	// 80 matches a source port, so any destination port.
+	want := []string{"50 10.30.0.0/24", "70 192.168.0.0/16", "80 10.50.0.0/24"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,890 @@
+package cisco
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"checklist/check"
+)
+
+// DefaultSIEM are the syslog collectors the 7013, 9013 and 10013 scripts
+// expect, as host or host:port.
+var DefaultSIEM = map[string][]string{
+	IOSXE: {"192.168.89.10:1514", "192.168.100.104:514"},
+	IOSXR: {"192.168.1.100:514", "192.168.100.104:514"},
+	NXOS:  {"192.168.89.104:1514", "192.168.89.104:514", "192.168.89.105:1514", "10.1.1.100:514", "172.16.10.50:514"},
+}
+
+// Options are the site values some checks compare against. An empty SIEM
+// uses DefaultSIEM for the configuration's OS.
+type Options struct {
+	SIEM []string
+}
+
+// Check is one iosxe/70xx.sh, iosxr/90xx.sh or nxos/100xx.sh control. run
+// returns what is wrong, nothing when the check passes.
+type Check struct {
+	ID    string
+	Title string
+	run   func(config *Config, opts Options) []string
+}
+
+var Checks = map[string][]Check{
+	IOSXE: {
+		{"7001", "Host name is not the factory default", checkHostName("Router", "Switch")},
+		{"7002", "Login banner is configured", checkBanner("login")},
+		{"7003", "Console and VTY sessions time out after 5 idle minutes", checkExecTimeout("line con", "line vty")},
+		{"7004", "VTY access is restricted to management sources", checkVTYACL},
+		{"7005", "Unnecessary services are disabled", checkServicesXE},
+		{"7007", "Console and auxiliary lines require authentication", checkLineAuthXE},
+		{"7008", "VTY logins use RADIUS or TACACS+", checkRemoteAuthXE},
+		{"7009", "Time zone and NTP are configured", checkTime},
+		{"7010", "SNMP is read-only and restricted to known clients", checkSNMP},
+		{"7011", "SSH version 2 is the only VTY transport", checkSSHXE},
+		{"7012", "Enable and user passwords are hashed", checkPasswordsXE},
+		{"7013", "Syslog is sent to the SIEM", checkSyslog},
+		{"7014", "Login attempts are limited", checkGlobal("aaa authentication attempts login")},
+		{"7015", "Configuration is archived automatically", checkArchive},
+	},
+	IOSXR: {
+		{"9001", "Host name is not the factory default", checkHostName("router", "switch", "ios", "iosxr")},
+		{"9002", "Login banner is configured", checkBanner("login")},
+		{"9003", "Console and VTY sessions time out after 5 idle minutes", checkExecTimeout("line console", "line default")},
+		{"9004", "VTY access is restricted to management sources", checkVTYACL},
+		{"9005", "Unnecessary services are disabled", checkServicesXR},
+		{"9007", "Console and line templates require authentication", checkLineAuthXR},
+		{"9008", "Logins use RADIUS or TACACS+", checkRemoteAuthXR},
+		{"9009", "Time zone and NTP are configured", checkTimeXR},
+		{"9010", "SNMP is read-only and restricted to known clients", checkSNMP},
+		{"9011", "SSH version 2 is the only line transport", checkSSHXR},
+		{"9012", "Passwords are hashed and the password policy is enforced", checkPasswordsXR},
+		{"9013", "Syslog is sent to the SIEM", checkSyslog},
+		{"9014", "A password policy locks out repeated login failures", checkLockoutXR},
+		{"9015", "Configuration is archived automatically", checkGlobal("configuration commit auto-save filename")},
+	},
+	NXOS: {
+		{"10001", "Host name is not the factory default", checkHostName("router", "switch")},
+		{"10002", "MOTD banner is configured", checkBanner("motd")},
+		{"10003", "Console and VTY sessions time out after 5 idle minutes", checkExecTimeout("line console", "line vty")},
+		{"10004", "VTY access is restricted to management sources", checkVTYACL},
+		{"10005", "Unnecessary features are disabled", checkServicesNX},
+		{"10007", "AAA login never falls back to no authentication", checkNoAuthNone},
+		{"10008", "Default and console logins use RADIUS or TACACS+", checkRemoteAuthNX},
+		{"10009", "Time zone and NTP are configured", checkTime},
+		{"10010", "SNMP is read-only and restricted to known clients", checkSNMP},
+		{"10011", "SSH is enabled", checkSSHNX},
+		{"10012", "Passwords are hashed and the passphrase policy is enforced", checkPasswordsNX},
+		{"10013", "Syslog is sent to the SIEM", checkSyslog},
+		{"10014", "Rejected logins are rate limited", checkGlobal("aaa authentication rejected")},
+		{"10015", "Configuration is backed up by a scheduled job", checkScheduler},
+	},
+}
+
+func GetChecks(paths []string, osName string, siem []string) (string, error) {
+	var result string
+	for _, path := range paths {
+		config, err := ParseConfig(path, osName)
+		if err != nil {
+			return "", err
+		}
+		result += check.Format(fmt.Sprintf("%s (%s)", config.Path, config.OS), Audit(config, Options{SIEM: siem}))
+	}
+	return result, nil
+}
+
+// Audit runs the checks for the configuration's OS.
+func Audit(config *Config, opts Options) []check.Result {
+	if len(opts.SIEM) == 0 {
+		opts.SIEM = DefaultSIEM[config.OS]
+	}
+	var results []check.Result
+	for _, c := range Checks[config.OS] {
+		results = append(results, check.Result{ID: c.ID, Title: c.Title, Findings: c.run(config, opts)})
+	}
+	return results
+}
+
+// under returns the lines entered in the mode opened by parent, nil for
+// global configuration, whose words start with prefix.
+func under(config *Config, parent *Line, prefix string) []*Line {
+	var lines []*Line
+	for _, l := range config.Lines {
+		if l.Parent == parent && startsWith(l, prefix) {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func global(config *Config, prefix string) []*Line {
+	return under(config, nil, prefix)
+}
+
+func startsWith(l *Line, prefix string) bool {
+	text := strings.Join(l.Fields(), " ")
+	return prefix == "" || text == prefix || strings.HasPrefix(text, prefix+" ")
+}
+
+// checkGlobal passes when a global line starts with prefix.
+func checkGlobal(prefix string) func(*Config, Options) []string {
+	return func(config *Config, opts Options) []string {
+		if len(global(config, prefix)) == 0 {
+			return []string{prefix + " is not set"}
+		}
+		return nil
+	}
+}
+
+func checkHostName(defaults ...string) func(*Config, Options) []string {
+	return func(config *Config, opts Options) []string {
+		lines := global(config, "hostname")
+		if len(lines) == 0 || len(lines[0].Fields()) < 2 {
+			return []string{"hostname is not set"}
+		}
+		name := lines[0].Fields()[1]
+		for _, d := range defaults {
+			if strings.EqualFold(name, d) {
+				return []string{"hostname is the factory default " + name}
+			}
+		}
+		return nil
+	}
+}
+
+func checkBanner(kind string) func(*Config, Options) []string {
+	return checkGlobal("banner " + kind)
+}
+
+// checkExecTimeout requires exec-timeout on the lines starting with each
+// of required and checks its value wherever it is set. IOS and IOS-XR
+// take minutes and seconds, NX-OS minutes only; 0 never times out.
+func checkExecTimeout(required ...string) func(*Config, Options) []string {
+	return func(config *Config, opts Options) []string {
+		var findings []string
+		for _, l := range global(config, "line") {
+			timeouts := under(config, l, "exec-timeout")
+			if len(timeouts) == 0 {
+				for _, r := range required {
+					if startsWith(l, r) {
+						findings = append(findings, l.Text+": exec-timeout is not set")
+						break
+					}
+				}
+				continue
+			}
+			fields := timeouts[0].Fields()
+			var seconds int
+			for i, unit := range []int{60, 1} {
+				if len(fields) > i+1 {
+					n, _ := strconv.Atoi(fields[i+1])
+					seconds += n * unit
+				}
+			}
+			if seconds <= 0 || seconds > 300 {
+				findings = append(findings, fmt.Sprintf("%s: %s, want 5 minutes or less", l.Text, timeouts[0].Text))
+			}
+		}
+		return findings
+	}
+}
+
+// checkVTYACL reports what the management ACL analysis finds wrong with
+// every way into the VTY lines.
+func checkVTYACL(config *Config, opts Options) []string {
+	accesses, err := AnalyzeManagementACLs(config)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var findings []string
+	for _, a := range accesses {
+		if a.Plane != PlaneVTY {
+			continue
+		}
+		for _, f := range a.Findings {
+			findings = append(findings, fmt.Sprintf("line %d %s: %s", a.Line, a.Where, f))
+		}
+	}
+	return findings
+}
+
+// Services IOS-XE enables with a global line, and the lines that must be
+// present to turn others off.
+var (
+	unusedServicesXE = []string{
+		"service tcp-small-servers",
+		"service udp-small-servers",
+		"ip finger",
+		"ip bootp server",
+		"service config",
+		"service dhcp",
+	}
+	disabledServicesXE = []string{
+		"ip dhcp bootp ignore",
+		"no ip http server",
+		"no ip http secure-server",
+	}
+)
+
+func checkServicesXE(config *Config, opts Options) []string {
+	var findings []string
+	for _, s := range unusedServicesXE {
+		for _, l := range global(config, s) {
+			findings = append(findings, fmt.Sprintf("%s is enabled on line %d", s, l.Number))
+		}
+	}
+	for _, s := range disabledServicesXE {
+		if len(global(config, s)) == 0 {
+			findings = append(findings, s+" is not set")
+		}
+	}
+	return findings
+}
+
+func checkServicesXR(config *Config, opts Options) []string {
+	var findings []string
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		var enabled bool
+		switch {
+		case l.Parent == nil && len(fields) > 2 && fields[0] == "service":
+			enabled = fields[2] == "tcp-small-servers" || fields[2] == "udp-small-servers"
+		case l.Parent == nil && (fields[0] == "tftp" || fields[0] == "telnet"):
+			enabled = contains(fields, "server")
+		case l.Parent == nil && len(fields) > 1 && fields[0] == "dhcp":
+			enabled = fields[1] == "ipv4" || fields[1] == "ipv6"
+		case l.Parent == nil:
+			enabled = l.Text == "cdp"
+		case len(fields) > 1 && fields[0] == "allow" && l.Within("control-plane"):
+			// Management plane protection opening an insecure protocol.
+			enabled = strings.EqualFold(fields[1], "http") || strings.EqualFold(fields[1], "telnet")
+		}
+		if enabled {
+			findings = append(findings, fmt.Sprintf("%s is enabled on line %d", strings.Join(fields, " "), l.Number))
+		}
+	}
+	return findings
+}
+
+var unusedFeaturesNX = []string{"telnet", "dhcp", "nxapi", "nxsdk", "netconf", "restconf", "scp-server"}
+
+func checkServicesNX(config *Config, opts Options) []string {
+	var findings []string
+	for _, f := range unusedFeaturesNX {
+		for _, l := range global(config, "feature "+f) {
+			if l.Text == "feature "+f {
+				findings = append(findings, fmt.Sprintf("feature %s is enabled on line %d", f, l.Number))
+			}
+		}
+	}
+	for _, s := range []string{"no cdp enable", "no ip source-route"} {
+		if len(global(config, s)) == 0 {
+			findings = append(findings, s+" is not set")
+		}
+	}
+	return findings
+}
+
+// loginLists maps each aaa authentication login method list to its
+// methods. A "group" method is recorded as "group NAME" for each server
+// group it lists, as IOS repeats the keyword and NX-OS does not.
+func loginLists(config *Config) map[string][]string {
+	lists := make(map[string][]string)
+	for _, l := range global(config, "aaa authentication login") {
+		fields := l.Fields()
+		if len(fields) < 5 {
+			continue
+		}
+		var (
+			methods []string
+			inGroup bool
+		)
+		for _, f := range fields[4:] {
+			switch {
+			case f == "group":
+				inGroup = true
+			case inGroup && f != "local" && f != "none" && f != "enable" && f != "line":
+				methods = append(methods, "group "+f)
+			default:
+				inGroup = false
+				methods = append(methods, f)
+			}
+		}
+		lists[fields[3]] = methods
+	}
+	return lists
+}
+
+// remoteGroup reports whether a server group sends logins to RADIUS or
+// TACACS+ servers.
+func remoteGroup(config *Config, name string) bool {
+	switch name {
+	case "radius":
+		return len(global(config, "radius-server host"))+len(global(config, "radius server")) > 0
+	case "tacacs+":
+		return len(global(config, "tacacs-server host"))+len(global(config, "tacacs server")) > 0
+	}
+	return len(global(config, "aaa group server radius "+name))+len(global(config, "aaa group server tacacs+ "+name)) > 0
+}
+
+// checkLoginList reports a method list that is missing or uses no RADIUS
+// or TACACS+ group.
+func checkLoginList(config *Config, lists map[string][]string, name, where string) string {
+	methods, ok := lists[name]
+	if !ok {
+		return fmt.Sprintf("%s uses login list %s, which is not defined", where, name)
+	}
+	for _, m := range methods {
+		if group, ok := strings.CutPrefix(m, "group "); ok && remoteGroup(config, group) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s uses login list %s, which has no RADIUS or TACACS+ group", where, name)
+}
+
+// loginList returns the method list a line uses, "default" when unset.
+func loginList(config *Config, l *Line) string {
+	if auth := under(config, l, "login authentication"); len(auth) > 0 && len(auth[0].Fields()) > 2 {
+		return auth[0].Fields()[2]
+	}
+	return "default"
+}
+
+func checkLineAuthXE(config *Config, opts Options) []string {
+	if len(global(config, "aaa new-model")) > 0 {
+		if methods, ok := loginLists(config)["default"]; ok && !contains(methods, "none") {
+			return nil
+		}
+	}
+	var findings []string
+	for _, l := range append(global(config, "line con"), global(config, "line aux")...) {
+		if len(under(config, l, "password"))+len(under(config, l, "login local"))+len(under(config, l, "login authentication")) == 0 {
+			findings = append(findings, l.Text+": no password or login method")
+		}
+	}
+	return findings
+}
+
+func checkRemoteAuthXE(config *Config, opts Options) []string {
+	if len(global(config, "aaa new-model")) == 0 {
+		return []string{"aaa new-model is not set"}
+	}
+	var findings []string
+	lists := loginLists(config)
+	for _, l := range global(config, "line vty") {
+		if f := checkLoginList(config, lists, loginList(config, l), l.Text); f != "" {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func checkLineAuthXR(config *Config, opts Options) []string {
+	lines := append(global(config, "line console"), global(config, "line default")...)
+	lines = append(lines, global(config, "line template")...)
+	if len(lines) == 0 {
+		return []string{"no line console, default or template is configured"}
+	}
+	var findings []string
+	for _, l := range lines {
+		if len(under(config, l, "secret"))+len(under(config, l, "login authentication")) == 0 {
+			findings = append(findings, l.Text+": no secret or login authentication")
+		}
+	}
+	return findings
+}
+
+func checkRemoteAuthXR(config *Config, opts Options) []string {
+	var findings []string
+	lists := loginLists(config)
+	lines := append(global(config, "line console"), global(config, "line default")...)
+	templates := make(map[string]*Line)
+	for _, l := range global(config, "line template") {
+		templates[l.Fields()[len(l.Fields())-1]] = l
+	}
+	for _, pool := range global(config, "vty-pool") {
+		fields := pool.Fields()
+		for i := range fields[:len(fields)-1] {
+			if fields[i] == "line-template" && templates[fields[i+1]] != nil {
+				lines = append(lines, templates[fields[i+1]])
+				delete(templates, fields[i+1])
+			}
+		}
+	}
+	for _, l := range lines {
+		if f := checkLoginList(config, lists, loginList(config, l), l.Text); f != "" {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func checkRemoteAuthNX(config *Config, opts Options) []string {
+	var findings []string
+	lists := loginLists(config)
+	for _, name := range []string{"default", "console"} {
+		methods, ok := lists[name]
+		if !ok {
+			findings = append(findings, "aaa authentication login "+name+" is not set")
+			continue
+		}
+		var groups int
+		for _, m := range methods {
+			group, ok := strings.CutPrefix(m, "group ")
+			if !ok {
+				continue
+			}
+			groups++
+			if !remoteGroup(config, group) {
+				findings = append(findings, fmt.Sprintf("aaa authentication login %s uses group %s, which has no RADIUS or TACACS+ servers", name, group))
+			}
+		}
+		if groups == 0 {
+			findings = append(findings, "aaa authentication login "+name+" uses no server group")
+		}
+	}
+	return findings
+}
+
+func checkNoAuthNone(config *Config, opts Options) []string {
+	var findings []string
+	for name, methods := range loginLists(config) {
+		if contains(methods, "none") {
+			findings = append(findings, "aaa authentication login "+name+" falls back to none")
+		}
+	}
+	return findings
+}
+
+// checkTime wants NTP and a clock timezone of UTC+7.
+func checkTime(config *Config, opts Options) []string {
+	var findings []string
+	tz := global(config, "clock timezone")
+	if len(tz) == 0 {
+		findings = append(findings, "clock timezone is not set")
+	} else if fields := tz[0].Fields(); len(fields) < 4 || fields[3] != "7" || (len(fields) > 4 && fields[4] != "0") {
+		findings = append(findings, tz[0].Text+", want UTC+7")
+	}
+	if len(global(config, "ntp server")) == 0 {
+		findings = append(findings, "no ntp server is configured")
+	}
+	return findings
+}
+
+func checkTimeXR(config *Config, opts Options) []string {
+	var findings []string
+	tz := global(config, "clock timezone")
+	if len(tz) == 0 {
+		findings = append(findings, "clock timezone is not set")
+	} else if fields := tz[0].Fields(); fields[len(fields)-1] != "Asia/Saigon" && fields[len(fields)-1] != "Asia/Ho_Chi_Minh" {
+		findings = append(findings, tz[0].Text+", want Asia/Saigon")
+	}
+	ntp := global(config, "ntp")
+	var servers, sources int
+	for _, l := range ntp {
+		servers += len(under(config, l, "server"))
+		sources += len(under(config, l, "source"))
+	}
+	if servers == 0 {
+		findings = append(findings, "no ntp server is configured")
+	}
+	if sources == 0 {
+		findings = append(findings, "ntp source is not set")
+	}
+	return findings
+}
+
+// checkSNMP passes when SNMP is off. Otherwise communities must be
+// read-only with a narrow ACL, SNMPv3 users must use SHA and AES, and
+// traps must go to a host.
+func checkSNMP(config *Config, opts Options) []string {
+	var (
+		findings []string
+		hosts    int
+		v3Hosts  = make(map[string]bool)
+		names    []string
+		users    = make(map[string]struct{ sha, aes bool })
+	)
+	snmp := global(config, "snmp-server")
+	if len(snmp) == 0 {
+		// The scripts require the restricted community or v3 user and
+		// trap host, so a device without SNMP fails too.
+		return []string{"SNMP is not configured"}
+	}
+	for _, l := range global(config, "snmp-server host") {
+		fields := l.Fields()
+		if i := index(fields, "priv"); contains(fields, "3") && i > 0 && i+1 < len(fields) {
+			v3Hosts[fields[i+1]] = true
+		}
+	}
+	for _, l := range snmp {
+		fields := l.Fields()
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[1] {
+		case "community":
+			// The community is a secret, so only its line is shown.
+			where := fmt.Sprintf("community on line %d", l.Number)
+			for _, known := range check.WellKnownCommunities {
+				if strings.EqualFold(fields[2], known) {
+					findings = append(findings, fmt.Sprintf("%s uses the well-known string %q", where, known))
+				}
+			}
+			if contains(fields[3:], "RW") || contains(fields[3:], "rw") || contains(fields[3:], "network-admin") {
+				findings = append(findings, where+" has read-write access")
+			}
+		case "host":
+			hosts++
+		case "vrf":
+			hosts += len(under(config, l, "host"))
+		case "user":
+			// NX-OS keeps an SNMP user for every local account, so only
+			// the users traps are sent as are checked there.
+			if config.OS == NXOS && !v3Hosts[fields[2]] {
+				continue
+			}
+			if _, ok := users[fields[2]]; !ok {
+				names = append(names, fields[2])
+			}
+			// NX-OS may set a user's role on a line of its own.
+			u := users[fields[2]]
+			if i := index(fields, "auth"); i > 0 && i+1 < len(fields) && strings.HasPrefix(fields[i+1], "sha") {
+				u.sha = true
+			}
+			if i := index(fields, "priv"); i > 0 && i+1 < len(fields) && strings.HasPrefix(fields[i+1], "aes") {
+				u.aes = true
+			}
+			users[fields[2]] = u
+		}
+	}
+	for _, name := range names {
+		if !users[name].sha {
+			findings = append(findings, "snmp-server user "+name+" does not authenticate with SHA")
+		}
+		if !users[name].aes {
+			findings = append(findings, "snmp-server user "+name+" does not encrypt with AES")
+		}
+	}
+	accesses, err := AnalyzeManagementACLs(config)
+	if err != nil {
+		return append(findings, err.Error())
+	}
+	for _, a := range accesses {
+		if a.Plane != PlaneSNMP {
+			continue
+		}
+		for _, f := range a.Findings {
+			findings = append(findings, fmt.Sprintf("%s on line %d: %s", a.Where, a.Line, f))
+		}
+	}
+	if config.OS == NXOS && len(global(config, "snmp-server globalEnforcePriv")) == 0 {
+		findings = append(findings, "snmp-server globalEnforcePriv is not set")
+	}
+	if hosts == 0 {
+		findings = append(findings, "no snmp-server host is configured")
+	}
+	return findings
+}
+
+func checkSSHXE(config *Config, opts Options) []string {
+	var findings []string
+	if len(global(config, "ip ssh version 2")) == 0 {
+		findings = append(findings, "ip ssh version 2 is not set")
+	}
+	for _, l := range global(config, "line vty") {
+		findings = append(findings, transportSSH(config, l)...)
+	}
+	return findings
+}
+
+func checkSSHXR(config *Config, opts Options) []string {
+	var findings []string
+	if len(global(config, "ssh server v2")) == 0 {
+		findings = append(findings, "ssh server v2 is not set")
+	}
+	for _, l := range global(config, "ssh server v1") {
+		findings = append(findings, fmt.Sprintf("ssh server v1 is enabled on line %d", l.Number))
+	}
+	lines := append(global(config, "line default"), global(config, "line template")...)
+	if len(lines) == 0 {
+		findings = append(findings, "no line default or template is configured")
+	}
+	for _, l := range lines {
+		findings = append(findings, transportSSH(config, l)...)
+	}
+	return findings
+}
+
+// transportSSH reports a line that accepts anything but SSH. A line that
+// accepts nothing is fine.
+func transportSSH(config *Config, l *Line) []string {
+	transport := under(config, l, "transport input")
+	if len(transport) == 0 {
+		return []string{l.Text + ": transport input is not set"}
+	}
+	if methods := transport[0].Fields()[2:]; len(methods) != 1 || (methods[0] != "ssh" && methods[0] != "none") {
+		return []string{fmt.Sprintf("%s: %s, want ssh only", l.Text, transport[0].Text)}
+	}
+	return nil
+}
+
+func checkSSHNX(config *Config, opts Options) []string {
+	if len(global(config, "no feature ssh")) > 0 {
+		return []string{"no feature ssh is set"}
+	}
+	return nil
+}
+
+func checkPasswordsXE(config *Config, opts Options) []string {
+	var findings []string
+	for _, s := range []string{"enable secret", "service password-encryption"} {
+		if len(global(config, s)) == 0 {
+			findings = append(findings, s+" is not set")
+		}
+	}
+	var secrets int
+	for _, l := range global(config, "username") {
+		fields := l.Fields()
+		switch {
+		case contains(fields, "secret"):
+			secrets++
+		case contains(fields, "password"):
+			findings = append(findings, fmt.Sprintf("username %s has a password instead of a secret", fields[1]))
+		}
+	}
+	if secrets == 0 {
+		findings = append(findings, "no username has a secret")
+	}
+	return findings
+}
+
+// passwordPolicies returns the settings of each IOS-XR aaa password-policy,
+// keyed by the setting's first word.
+func passwordPolicies(config *Config) map[string]map[string]string {
+	policies := make(map[string]map[string]string)
+	for _, l := range global(config, "aaa password-policy") {
+		fields := l.Fields()
+		if len(fields) != 3 {
+			continue
+		}
+		settings := make(map[string]string)
+		for _, c := range under(config, l, "") {
+			f := c.Fields()
+			settings[f[0]] = f[len(f)-1]
+		}
+		policies[fields[2]] = settings
+	}
+	return policies
+}
+
+// usersWithoutPolicy lists the IOS-XR usernames not bound to one of the
+// policies.
+func usersWithoutPolicy(config *Config, policies []string) []string {
+	var users []string
+	for _, l := range global(config, "username") {
+		if len(l.Fields()) < 2 {
+			continue
+		}
+		var bound bool
+		for _, p := range under(config, l, "policy") {
+			bound = bound || contains(policies, p.Fields()[len(p.Fields())-1])
+		}
+		if !bound {
+			users = append(users, l.Fields()[1])
+		}
+	}
+	return users
+}
+
+// checkPolicy finds the IOS-XR password policies whose settings all pass
+// ok and reports the users bound to none of them.
+func checkPolicy(config *Config, what string, ok func(settings map[string]string) bool) []string {
+	var good []string
+	for name, settings := range passwordPolicies(config) {
+		if ok(settings) {
+			good = append(good, name)
+		}
+	}
+	if len(good) == 0 {
+		return []string{"no aaa password-policy " + what}
+	}
+	var findings []string
+	for _, user := range usersWithoutPolicy(config, good) {
+		findings = append(findings, fmt.Sprintf("username %s does not use a policy that %s", user, what))
+	}
+	return findings
+}
+
+func checkPasswordsXR(config *Config, opts Options) []string {
+	var findings []string
+	users := global(config, "username")
+	if len(users) == 0 {
+		findings = append(findings, "no username is configured")
+	}
+	for _, l := range users {
+		if len(l.Fields()) < 2 {
+			continue
+		}
+		if len(under(config, l, "secret")) == 0 {
+			findings = append(findings, fmt.Sprintf("username %s has no secret", l.Fields()[1]))
+		}
+	}
+	for _, l := range config.Lines {
+		if startsWith(l, "password 7") {
+			findings = append(findings, fmt.Sprintf("type 7 password on line %d", l.Number))
+		}
+	}
+	findings = append(findings, checkPolicy(config, "requires 10 characters from every class", func(s map[string]string) bool {
+		for _, class := range []string{"numeric", "lower-case", "upper-case", "special-char"} {
+			if n, err := strconv.Atoi(s[class]); err != nil || n < 1 {
+				return false
+			}
+		}
+		n, err := strconv.Atoi(s["min-length"])
+		return err == nil && n >= 10
+	})...)
+	if len(global(config, "password6 encryption aes")) == 0 {
+		findings = append(findings, "password6 encryption aes is not set")
+	}
+	return findings
+}
+
+func checkLockoutXR(config *Config, opts Options) []string {
+	return checkPolicy(config, "sets lockout-time and authen-max-attempts", func(s map[string]string) bool {
+		return s["lockout-time"] != "" && s["authen-max-attempts"] != ""
+	})
+}
+
+func checkPasswordsNX(config *Config, opts Options) []string {
+	var (
+		findings  []string
+		passwords int
+	)
+	for _, l := range global(config, "username") {
+		fields := l.Fields()
+		i := index(fields, "password")
+		if i < 0 {
+			continue
+		}
+		passwords++
+		if i+2 >= len(fields) || fields[i+1] != "5" || !strings.HasPrefix(fields[i+2], "$") {
+			findings = append(findings, fmt.Sprintf("username %s password is not a type 5 hash", fields[1]))
+		}
+	}
+	if passwords == 0 {
+		findings = append(findings, "no username has a password")
+	}
+	if len(global(config, "no password strength-check")) > 0 {
+		findings = append(findings, "password strength-check is disabled")
+	}
+	length := global(config, "userpassphrase min-length")
+	if len(length) == 0 {
+		findings = append(findings, "userpassphrase min-length is not set")
+	} else if fields := length[0].Fields(); len(fields) < 3 {
+		findings = append(findings, "userpassphrase min-length has no value")
+	} else if n, err := strconv.Atoi(fields[2]); err != nil || n < 10 {
+		findings = append(findings, length[0].Text+", want 10 or more")
+	}
+	return findings
+}
+
+// checkSyslog wants a logging host that is one of opts.SIEM. IOS-XE writes
+// "logging host A transport udp port P", IOS-XR "logging A port P" and
+// NX-OS "logging server A port P"; the port defaults to 514.
+func checkSyslog(config *Config, opts Options) []string {
+	for _, l := range global(config, "logging") {
+		fields := l.Fields()
+		if len(fields) < 2 {
+			continue
+		}
+		host := fields[1]
+		if host == "host" || host == "server" {
+			if len(fields) < 3 {
+				continue
+			}
+			host = fields[2]
+		} else if _, err := netip.ParseAddr(host); err != nil {
+			continue
+		}
+		port := "514"
+		if i := index(fields, "port"); i > 0 && i+1 < len(fields) && fields[i+1] != "default" {
+			port = fields[i+1]
+		}
+		for _, siem := range opts.SIEM {
+			addr, siemPort, ok := strings.Cut(siem, ":")
+			if !ok {
+				siemPort = "514"
+			}
+			if addr == host && siemPort == port {
+				return nil
+			}
+		}
+	}
+	return []string{fmt.Sprintf("no logging host sends to %s", strings.Join(opts.SIEM, " or "))}
+}
+
+func checkArchive(config *Config, opts Options) []string {
+	archive := global(config, "archive")
+	if len(archive) == 0 {
+		return []string{"archive is not configured"}
+	}
+	var findings []string
+	for _, s := range []string{"path", "write-memory", "time-period"} {
+		if len(under(config, archive[0], s)) == 0 {
+			findings = append(findings, "archive "+s+" is not set")
+		}
+	}
+	return findings
+}
+
+var backupSchemes = []string{"tftp:", "ftp:", "http:", "https:", "scp:", "sftp:", "usb"}
+
+// checkScheduler wants a scheduled job that copies the running
+// configuration off the switch.
+func checkScheduler(config *Config, opts Options) []string {
+	if len(global(config, "feature scheduler")) == 0 {
+		return []string{"feature scheduler is not enabled"}
+	}
+	jobs := make(map[string]bool)
+	for _, l := range global(config, "scheduler job name") {
+		if len(l.Fields()) < 4 {
+			continue
+		}
+		for _, c := range under(config, l, "copy running-config") {
+			for _, scheme := range backupSchemes {
+				if fields := c.Fields(); len(fields) > 2 && strings.HasPrefix(fields[2], scheme) {
+					jobs[l.Fields()[3]] = true
+				}
+			}
+		}
+	}
+	if len(jobs) == 0 {
+		return []string{"no scheduler job copies running-config to a remote server"}
+	}
+	for _, l := range global(config, "scheduler schedule name") {
+		if len(under(config, l, "time")) == 0 {
+			continue
+		}
+		for _, job := range under(config, l, "job name") {
+			if fields := job.Fields(); len(fields) > 2 && jobs[fields[2]] {
+				return nil
+			}
+		}
+	}
+	return []string{"no scheduler schedule runs a backup job"}
+}
+
+func contains(list []string, s string) bool {
+	return index(list, s) >= 0
+}
+
+func index(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
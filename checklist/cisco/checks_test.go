@@ -0,0 +1,86 @@
+package cisco
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func failing(t *testing.T, path, osName string) []string {
+	t.Helper()
+	config, err := ParseConfig(path, osName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range Audit(config, Options{}) {
+		if !r.Pass() {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids
+}
+
+func TestSampleConfigs(t *testing.T) {
+	for _, tc := range []struct {
+		path, os string
+		want     []string
+	}{
+		{"../../iosxe/iosxe.cfg", IOSXE, []string{"7003", "7004", "7005", "7008", "7012", "7014", "7015"}},
+		{"../../iosxe/cat8k.cfg", IOSXE, []string{"7002", "7003", "7004", "7008", "7009", "7010", "7013", "7015"}},
+		{"../../iosxr/asr9k.cfg", IOSXR, []string{"9004", "9005", "9010"}},
+		{"../../nxos/n9k.cfg", NXOS, []string{"10004"}},
+	} {
+		if got := failing(t, tc.path, tc.os); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: failing %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestOSDetected(t *testing.T) {
+	for path, want := range map[string]string{
+		"../../iosxe/cat8k.cfg": IOSXE,
+		"../../iosxr/asr9k.cfg": IOSXR,
+		"../../nxos/n9k.cfg":    NXOS,
+	} {
+		config, err := ParseConfig(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.OS != want {
+			t.Errorf("%s: detected %s, want %s", path, config.OS, want)
+		}
+	}
+}
+
+// TestBareLines runs every check on configurations whose lines stop after
+// the keyword. They are wrong but must not panic.
+func TestBareLines(t *testing.T) {
+	const text = `hostname
+username
+line default
+ exec-timeout
+ transport input
+userpassphrase min-length
+scheduler job name
+ copy running-config
+scheduler schedule name s
+ time daily 1:00
+ job name
+logging
+snmp-server community
+ntp server
+`
+	path := filepath.Join(t.TempDir(), "bare.cfg")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, osName := range []string{IOSXE, IOSXR, NXOS} {
+		config, err := ParseConfig(path, osName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Audit(config, Options{})
+	}
+}
@@ -0,0 +1,113 @@
+package cisco
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	IOSXE = "iosxe"
+	IOSXR = "iosxr"
+	NXOS  = "nxos"
+)
+
+// Line is one configuration line. Parent is the closest less indented line
+// above it, which is the mode the line was entered in.
+type Line struct {
+	Number int
+	Indent int
+	Text   string
+	Parent *Line
+}
+
+// Fields splits the line into its words.
+func (l *Line) Fields() []string {
+	return strings.Fields(l.Text)
+}
+
+// Within reports whether the line or one of its parents starts with prefix.
+func (l *Line) Within(prefix string) bool {
+	for p := l; p != nil; p = p.Parent {
+		if strings.HasPrefix(p.Text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a parsed running or startup configuration.
+type Config struct {
+	Path  string
+	OS    string
+	Lines []*Line
+}
+
+// ParseConfig reads a configuration file. When osName is empty the OS is
+// detected from the banner lines the device writes at the top.
+func ParseConfig(path, osName string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := &Config{Path: path, OS: osName}
+	var (
+		stack  []*Line
+		lineNo int
+	)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
+		text := strings.TrimLeft(raw, " \t")
+		if text == "" || strings.HasPrefix(text, "!") {
+			if config.OS == "" {
+				config.OS = detectOS(text)
+			}
+			if text != "" && len(raw) == len(text) {
+				// A "!" in the first column closes every open mode.
+				stack = stack[:0]
+			}
+			continue
+		}
+		line := &Line{Number: lineNo, Indent: len(raw) - len(text), Text: text}
+		for len(stack) > 0 && stack[len(stack)-1].Indent >= line.Indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			line.Parent = stack[len(stack)-1]
+		}
+		stack = append(stack, line)
+		config.Lines = append(config.Lines, line)
+		if config.OS == "" {
+			config.OS = detectOS(text)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if config.OS == "" {
+		config.OS = IOSXE
+	}
+	switch config.OS {
+	case IOSXE, IOSXR, NXOS:
+	default:
+		return nil, fmt.Errorf("unknown Cisco OS %q, want %s, %s or %s", config.OS, IOSXE, IOSXR, NXOS)
+	}
+	return config, nil
+}
+
+func detectOS(text string) string {
+	switch {
+	case strings.HasPrefix(text, "!! IOS XR Configuration"):
+		return IOSXR
+	case strings.HasPrefix(text, "!Command: show running-config"),
+		strings.HasPrefix(text, "!Running configuration last done"):
+		return NXOS
+	}
+	return ""
+}
@@ -0,0 +1,406 @@
+package cisco
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Management planes whose reachability is checked.
+const (
+	PlaneVTY  = "vty"
+	PlaneSNMP = "snmp"
+	PlaneHTTP = "http"
+)
+
+var planes = []string{PlaneVTY, PlaneSNMP, PlaneHTTP}
+
+// Range is a source range an ACL lets through, with the entry permitting it.
+type Range struct {
+	Address  Address
+	ACL      string
+	Line     int
+	Sequence string
+}
+
+// Access is one way into a management plane and the source ranges that
+// can reach it.
+type Access struct {
+	Plane    string
+	Line     int
+	Where    string
+	ACLs     []string
+	Disabled bool
+	Ranges   []Range
+	Findings []string
+}
+
+// Exposed reports whether the access has any finding.
+func (a Access) Exposed() bool {
+	return len(a.Findings) > 0
+}
+
+// service is the traffic a plane accepts, used to skip ACL entries that
+// match another protocol or port.
+type service struct {
+	protocol string
+	port     int
+}
+
+var planeServices = map[string]service{
+	PlaneVTY:  {"tcp", 22},
+	PlaneSNMP: {"udp", 161},
+	PlaneHTTP: {"tcp", 443},
+}
+
+func GetManagementACLs(paths []string, osName string) (string, error) {
+	var result string
+	for _, path := range paths {
+		config, err := ParseConfig(path, osName)
+		if err != nil {
+			return "", err
+		}
+		accesses, err := AnalyzeManagementACLs(config)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		var exposed int
+		result += fmt.Sprintf("****%s (%s)****\n", config.Path, config.OS)
+		for _, plane := range planes {
+			result += fmt.Sprintf("----%s----\n", plane)
+			for _, a := range accesses {
+				if a.Plane != plane {
+					continue
+				}
+				mark := "+"
+				if a.Exposed() {
+					mark = "-"
+					exposed++
+				}
+				result += fmt.Sprintf("%sline %d %s", mark, a.Line, a.Where)
+				switch {
+				case a.Disabled:
+					result += ": disabled"
+				case len(a.ACLs) > 0:
+					result += ": " + strings.Join(a.ACLs, ", ")
+				}
+				result += "\n"
+				for _, r := range a.Ranges {
+					result += fmt.Sprintf("  %s (%s line %d", r.Address, r.ACL, r.Line)
+					if r.Sequence != "" {
+						result += " seq " + r.Sequence
+					}
+					result += ")\n"
+				}
+				for _, f := range a.Findings {
+					result += fmt.Sprintf("  %s\n", f)
+				}
+			}
+		}
+		result += fmt.Sprintf("access points: %d, exposed: %d\n", len(accesses), exposed)
+	}
+	return result, nil
+}
+
+// AnalyzeManagementACLs finds every VTY, SNMP and HTTP access point in
+// config and the source ranges its ACLs permit.
+func AnalyzeManagementACLs(config *Config) ([]Access, error) {
+	acls, err := ParseACLs(config)
+	if err != nil {
+		return nil, err
+	}
+	var accesses []Access
+	accesses = append(accesses, vtyAccess(config, acls)...)
+	accesses = append(accesses, snmpAccess(config)...)
+	accesses = append(accesses, httpAccess(config)...)
+	for i := range accesses {
+		accesses[i].evaluate(acls)
+	}
+	return accesses, nil
+}
+
+// evaluate resolves the referenced ACLs into permitted ranges and records
+// findings. Ranges already set, as from an IOS-XR management-plane peer
+// list, are only checked.
+func (a *Access) evaluate(acls map[string]*ACL) {
+	if a.Disabled {
+		return
+	}
+	svc := planeServices[a.Plane]
+	for _, name := range a.ACLs {
+		acl, ok := acls[name]
+		if !ok {
+			a.Findings = append(a.Findings, fmt.Sprintf("ACL %s is not defined, all sources permitted", name))
+			continue
+		}
+		a.Ranges = append(a.Ranges, acl.Permitted(svc.protocol, svc.port)...)
+	}
+	if len(a.ACLs) == 0 && len(a.Ranges) == 0 {
+		a.Findings = append(a.Findings, "no ACL, all sources permitted")
+	}
+	for _, r := range a.Ranges {
+		switch {
+		case r.Address.Any():
+			a.Findings = append(a.Findings, fmt.Sprintf("%s line %d permits any source", r.ACL, r.Line))
+		case r.Address.Broad():
+			a.Findings = append(a.Findings, fmt.Sprintf("%s line %d permits broad range %s", r.ACL, r.Line, r.Address))
+		}
+	}
+}
+
+// Permitted walks the ACL in order and returns the source ranges of the
+// permit entries that apply to protocol and port and are not wholly
+// covered by an earlier deny.
+func (acl *ACL) Permitted(protocol string, port int) []Range {
+	var (
+		denied []Address
+		ranges []Range
+	)
+	for _, e := range acl.Entries {
+		if !e.MatchesProtocol(protocol) || !e.MatchesPort(port) {
+			continue
+		}
+		for _, src := range e.Sources {
+			if e.Action == Deny {
+				denied = append(denied, src)
+				continue
+			}
+			shadowed := false
+			for _, d := range denied {
+				if d.Contains(src) {
+					shadowed = true
+					break
+				}
+			}
+			if !shadowed {
+				ranges = append(ranges, Range{Address: src, ACL: acl.Name, Line: e.Line, Sequence: e.Sequence})
+			}
+		}
+	}
+	return ranges
+}
+
+func vtyAccess(config *Config, acls map[string]*ACL) []Access {
+	var accesses []Access
+	templates := make(map[string][]string)
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 2 {
+			continue
+		}
+		switch {
+		case fields[0] == "line" && fields[1] == "vty":
+			a := Access{Plane: PlaneVTY, Line: l.Number, Where: l.Text}
+			for _, child := range children(config, l) {
+				cf := child.Fields()
+				switch {
+				case len(cf) > 1 && cf[0] == "access-class" && (len(cf) < 3 || cf[2] == "in"):
+					a.ACLs = append(a.ACLs, cf[1])
+				case len(cf) > 2 && cf[0] == "ipv6" && cf[1] == "access-class" && (len(cf) < 4 || cf[3] == "in"):
+					a.ACLs = append(a.ACLs, "ipv6 "+cf[2])
+				case len(cf) == 3 && cf[0] == "transport" && cf[1] == "input" && cf[2] == "none":
+					a.Disabled = true
+				}
+			}
+			accesses = append(accesses, a)
+		case fields[0] == "line" && (fields[1] == "default" || fields[1] == "template"):
+			// IOS-XR line templates are applied to VTYs by vty-pool.
+			name := fields[len(fields)-1]
+			templates[name] = []string{}
+			for _, child := range children(config, l) {
+				cf := child.Fields()
+				if len(cf) != 3 || cf[0] != "access-class" || cf[1] != "ingress" {
+					continue
+				}
+				// The ingress access-class filters both families.
+				_, v4 := acls[cf[2]]
+				_, v6 := acls["ipv6 "+cf[2]]
+				if v4 || !v6 {
+					templates[name] = append(templates[name], cf[2])
+				}
+				if v6 {
+					templates[name] = append(templates[name], "ipv6 "+cf[2])
+				}
+			}
+		}
+	}
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 2 {
+			continue
+		}
+		switch {
+		case fields[0] == "vty-pool":
+			template := "default"
+			for i := range fields[:len(fields)-1] {
+				if fields[i] == "line-template" {
+					template = fields[i+1]
+				}
+			}
+			accesses = append(accesses, Access{Plane: PlaneVTY, Line: l.Number, Where: l.Text, ACLs: templates[template]})
+		case fields[0] == "ssh" && fields[1] == "server":
+			for i := range fields[:len(fields)-1] {
+				if fields[i] == "access-list" {
+					name := fields[i+1]
+					if fields[i-1] == "ipv6" {
+						name = "ipv6 " + name
+					}
+					accesses = append(accesses, Access{Plane: PlaneVTY, Line: l.Number, Where: l.Text, ACLs: []string{name}})
+				}
+			}
+		}
+	}
+	return append(accesses, managementPlane(config)...)
+}
+
+// managementPlane reads IOS-XR management plane protection, where each
+// "allow <protocol> peer" lists the addresses that may connect.
+func managementPlane(config *Config) []Access {
+	var accesses []Access
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if len(fields) < 2 || fields[0] != "allow" || !l.Within("control-plane") {
+			continue
+		}
+		var plane string
+		switch strings.ToLower(fields[1]) {
+		case "ssh", "telnet":
+			plane = PlaneVTY
+		case "snmp":
+			plane = PlaneSNMP
+		case "http", "https":
+			plane = PlaneHTTP
+		default:
+			continue
+		}
+		a := Access{Plane: plane, Line: l.Number, Where: "management-plane " + l.Text}
+		if l.Parent != nil {
+			a.Where = fmt.Sprintf("management-plane %s %s", l.Parent.Text, l.Text)
+		}
+		for _, child := range children(config, l) {
+			cf := child.Fields()
+			if len(cf) < 3 || cf[0] != "address" {
+				continue
+			}
+			var addrs []Address
+			if strings.Contains(cf[2], "/") {
+				if p, err := netip.ParsePrefix(cf[2]); err == nil {
+					addrs = []Address{{Prefix: p.Masked()}}
+				}
+			} else if len(cf) > 3 {
+				addrs, _ = rangeAddresses(cf[2], cf[3])
+			} else if addr, err := netip.ParseAddr(cf[2]); err == nil {
+				addrs = []Address{{Prefix: netip.PrefixFrom(addr, addr.BitLen())}}
+			}
+			for _, addr := range addrs {
+				a.Ranges = append(a.Ranges, Range{Address: addr, ACL: "peer", Line: child.Number})
+			}
+		}
+		accesses = append(accesses, a)
+	}
+	return accesses
+}
+
+// snmpAccess reads community and group ACLs. NX-OS sets the ACL of a
+// community on its own line, so lines for the same community are merged.
+func snmpAccess(config *Config) []Access {
+	var accesses []Access
+	communities := make(map[string]int)
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 3 || fields[0] != "snmp-server" {
+			continue
+		}
+		var (
+			where string
+			args  []string
+			key   string
+		)
+		switch fields[1] {
+		case "community":
+			// The community is a secret, so only its line is shown.
+			where, args, key = "community", fields[3:], fields[2]
+		case "group":
+			if len(fields) < 4 {
+				continue
+			}
+			where, args = "group "+fields[2], fields[3:]
+		default:
+			continue
+		}
+		acls := snmpACLs(args)
+		if i, ok := communities[key]; ok && key != "" {
+			accesses[i].ACLs = append(accesses[i].ACLs, acls...)
+			continue
+		}
+		if key != "" {
+			communities[key] = len(accesses)
+		}
+		accesses = append(accesses, Access{Plane: PlaneSNMP, Line: l.Number, Where: where, ACLs: acls})
+	}
+	return accesses
+}
+
+func snmpACLs(args []string) []string {
+	var acls []string
+	for i := 0; i < len(args); i++ {
+		next := ""
+		if i+1 < len(args) {
+			next = args[i+1]
+		}
+		switch args[i] {
+		case "view", "group", "context", "notify", "read", "write", "match":
+			i++
+		case "v1", "v2c", "v3", "auth", "noauth", "priv", "RO", "RW", "ro", "rw", "SDROwner", "SystemOwner":
+		case "access":
+			// IOS-XE groups: access [ipv6 <acl>] <acl>
+		case "ipv6", "IPv6", "use-ipv6acl":
+			if next != "" {
+				acls = append(acls, "ipv6 "+next)
+			}
+			i++
+		case "IPv4", "use-ipv4acl", "use-acl":
+			if next != "" {
+				acls = append(acls, next)
+			}
+			i++
+		default:
+			acls = append(acls, args[i])
+		}
+	}
+	return acls
+}
+
+// httpAccess reads the IOS-XE HTTP server and NX-OS NX-API.
+func httpAccess(config *Config) []Access {
+	var (
+		enabled bool
+		line    int
+		where   string
+		acls    []string
+	)
+	for _, l := range config.Lines {
+		fields := l.Fields()
+		if l.Parent != nil || len(fields) < 2 {
+			continue
+		}
+		switch {
+		case l.Text == "ip http server", l.Text == "ip http secure-server", l.Text == "feature nxapi", fields[0] == "http" && fields[1] == "server":
+			enabled, line, where = true, l.Number, l.Text
+		case l.Text == "no ip http server", l.Text == "no ip http secure-server":
+			if !enabled {
+				line, where = l.Number, strings.TrimPrefix(l.Text, "no ")
+			}
+		case len(fields) > 3 && fields[0] == "ip" && fields[1] == "http" && fields[2] == "access-class":
+			name := fields[len(fields)-1]
+			if fields[3] == "ipv6" {
+				name = "ipv6 " + name
+			}
+			acls = append(acls, name)
+		}
+	}
+	if line == 0 {
+		return nil
+	}
+	return []Access{{Plane: PlaneHTTP, Line: line, Where: where, ACLs: acls, Disabled: !enabled}}
+}
@@ -0,0 +1,103 @@
+package cisco
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManagementACLs(t *testing.T) {
+	for _, tc := range []struct {
+		name, os, text string
+		plane          string
+		want           []string
+	}{
+		{"vty any", IOSXE, `access-list 10 permit any
+line vty 0 4
+ access-class 10 in
+`, PlaneVTY, []string{"10 line 1 permits any source"}},
+		{"vty broad", IOSXE, `ip access-list standard VTY
+ permit 10.0.0.0 0.255.255.255
+line vty 0 4
+ access-class VTY in
+`, PlaneVTY, []string{"VTY line 2 permits broad range 10.0.0.0/8"}},
+		{"vty restricted", IOSXE, `access-list 10 permit 10.10.0.0 0.0.0.255
+access-list 10 deny any log
+line vty 0 4
+ access-class 10 in
+`, PlaneVTY, nil},
+		{"vty ipv6 any", IOSXE, `ipv6 access-list VTY6
+ permit ipv6 any any
+line vty 0 4
+ ipv6 access-class VTY6 in
+`, PlaneVTY, []string{"VTY6 line 2 permits any source"}},
+		{"vty no acl", IOSXE, `line vty 0 4
+ transport input ssh
+`, PlaneVTY, []string{"no ACL, all sources permitted"}},
+		{"vty undefined acl", IOSXE, `line vty 0 4
+ access-class MISSING in
+`, PlaneVTY, []string{"ACL MISSING is not defined, all sources permitted"}},
+		{"vty disabled", IOSXE, `line vty 0 4
+ transport input none
+`, PlaneVTY, nil},
+		{"ssh server any", IOSXR, `ipv4 access-list SSH
+ 10 permit tcp any any eq 22
+ssh server vrf default ipv4 access-list SSH
+`, PlaneVTY, []string{"SSH line 2 permits any source"}},
+		{"management-plane any", IOSXR, `control-plane
+ management-plane
+  inband
+   interface all
+    allow SSH peer
+     address ipv4 0.0.0.0/0
+`, PlaneVTY, []string{"peer line 6 permits any source"}},
+		{"snmp any", IOSXE, `access-list 20 permit any
+snmp-server community s3cret RO 20
+`, PlaneSNMP, []string{"20 line 1 permits any source"}},
+		{"snmp broad", NXOS, `ip access-list SNMP
+  10 permit udp 172.16.0.0/12 any eq snmp
+snmp-server community s3cret group network-operator
+snmp-server community s3cret use-acl SNMP
+`, PlaneSNMP, []string{"SNMP line 2 permits broad range 172.16.0.0/12"}},
+		{"snmp no acl", IOSXR, `snmp-server community s3cret RO
+`, PlaneSNMP, []string{"no ACL, all sources permitted"}},
+		{"snmp other port", IOSXE, `ip access-list extended SNMP
+ permit tcp any any eq 22
+ permit udp 10.10.0.0 0.0.0.255 any eq snmp
+snmp-server community s3cret RO SNMP
+`, PlaneSNMP, nil},
+		{"http any", IOSXE, `access-list 30 permit any
+ip http secure-server
+ip http access-class 30
+`, PlaneHTTP, []string{"30 line 1 permits any source"}},
+		{"http broad", IOSXE, `ip access-list standard WEB
+ permit 10.0.0.0 0.3.255.255
+ip http server
+ip http access-class ipv4 WEB
+`, PlaneHTTP, []string{"WEB line 2 permits broad range 10.0.0.0/14"}},
+		{"http disabled", IOSXE, `no ip http server
+`, PlaneHTTP, nil},
+		{"nxapi no acl", NXOS, `feature nxapi
+`, PlaneHTTP, []string{"no ACL, all sources permitted"}},
+	} {
+		accesses, err := AnalyzeManagementACLs(parse(t, tc.text, tc.os))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var got []string
+		found := false
+		for _, a := range accesses {
+			if a.Plane == tc.plane {
+				found = true
+				got = append(got, a.Findings...)
+			}
+		}
+		if !found {
+			t.Errorf("%s: no %s access found", tc.name, tc.plane)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package cisco
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Kinds of secret, named after the command that sets them.
+const (
+	KindEnable        = "enable"
+	KindUsername      = "username"
+	KindLine          = "line"
+	KindSNMPCommunity = "snmp-community"
+	KindSNMPUser      = "snmp-user"
+	KindTACACS        = "tacacs"
+	KindRADIUS        = "radius"
+	KindKeyChain      = "key-chain"
+	KindOther         = "other"
+)
+
+// Protection levels, from weakest to strongest.
+const (
+	Plaintext  = "plaintext"
+	Reversible = "reversible"
+	WeakHash   = "weak hash"
+	StrongHash = "strong hash"
+	Unknown    = "unknown"
+)
+
+// Secret is one password, secret or key found in a configuration. The
+// value itself is never kept; Redacted is the line with it masked.
+type Secret struct {
+	Line       int
+	Kind       string
+	Owner      string
+	Type       string
+	Algorithm  string
+	Protection string
+	Redacted   string
+	Findings   []string
+}
+
+// Weak reports whether the secret has any finding.
+func (s Secret) Weak() bool {
+	return len(s.Findings) > 0
+}
+
+// Wordlist is a set of passwords considered weak.
+type Wordlist map[string]struct{}
+
+//go:embed weak_passwords.txt
+var defaultWordlist []byte
+
+// DefaultWordlist returns the built-in list of common device passwords.
+func DefaultWordlist() Wordlist {
+	w := make(Wordlist)
+	w.add(defaultWordlist)
+	return w
+}
+
+// LoadWordlist adds the passwords in path, one per line, to w.
+func (w Wordlist) LoadWordlist(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	w.add(content)
+	return nil
+}
+
+func (w Wordlist) add(content []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if word := strings.TrimRight(scanner.Text(), "\r"); word != "" {
+			w[word] = struct{}{}
+		}
+	}
+}
+
+// Contains reports whether password is in the list, ignoring case.
+func (w Wordlist) Contains(password string) bool {
+	if _, ok := w[password]; ok {
+		return true
+	}
+	_, ok := w[strings.ToLower(password)]
+	return ok
+}
+
+// found is a secret value located on a line before it is classified.
+type found struct {
+	kind      string
+	owner     string
+	typ       string
+	value     string
+	index     int
+	localized bool
+	auth      string
+}
+
+func GetSecrets(paths []string, osName, wordlistPath string) (string, error) {
+	weak := DefaultWordlist()
+	if wordlistPath != "" {
+		if err := weak.LoadWordlist(wordlistPath); err != nil {
+			return "", err
+		}
+	}
+	var result string
+	for _, path := range paths {
+		config, err := ParseConfig(path, osName)
+		if err != nil {
+			return "", err
+		}
+		secrets := AnalyzeSecrets(config, weak)
+		var weakCount int
+		result += fmt.Sprintf("****%s (%s)****\n", config.Path, config.OS)
+		for _, s := range secrets {
+			mark := "+"
+			if s.Weak() {
+				mark = "-"
+				weakCount++
+			}
+			result += fmt.Sprintf("%sline %d %s: %s [type %s, %s, %s]", mark, s.Line, s.Kind, s.Redacted, s.Type, s.Algorithm, s.Protection)
+			if len(s.Findings) > 0 {
+				result += ": " + strings.Join(s.Findings, "; ")
+			}
+			result += "\n"
+		}
+		result += fmt.Sprintf("secrets: %d, weak: %d\n", len(secrets), weakCount)
+	}
+	return result, nil
+}
+
+// AnalyzeSecrets classifies every secret in config and checks the ones
+// that can be recovered against weak.
+func AnalyzeSecrets(config *Config, weak Wordlist) []Secret {
+	var secrets []Secret
+	for _, l := range config.Lines {
+		values := secretsIn(config, l)
+		if len(values) == 0 {
+			continue
+		}
+		fields := l.Fields()
+		for _, v := range values {
+			fields[v.index] = "<redacted>"
+		}
+		redacted := strings.Join(fields, " ")
+		for _, v := range values {
+			s := classify(v, config.OS, weak)
+			s.Line = l.Number
+			s.Redacted = redacted
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+func secretsIn(config *Config, l *Line) []found {
+	fields := l.Fields()
+	if len(fields) < 2 || fields[0] == "no" {
+		return nil
+	}
+	if fields[0] == "snmp-server" || l.Within("snmp-server") {
+		return snmpSecrets(fields)
+	}
+	// Global commands that only name an encryption scheme.
+	if fields[1] == "encryption" || fields[1] == "config-key" {
+		return nil
+	}
+
+	kind, owner := kindOf(config, l)
+	var values []found
+	for i := 0; i < len(fields)-1; i++ {
+		switch fields[i] {
+		case "secret", "password":
+		case "key":
+			if kind != KindTACACS && kind != KindRADIUS {
+				continue
+			}
+		case "key-string":
+		default:
+			continue
+		}
+		typ, index := "0", i+1
+		if isType(fields[index]) && index+1 < len(fields) {
+			typ, index = fields[index], index+1
+		}
+		values = append(values, found{kind: kind, owner: owner, typ: typ, value: unquote(fields[index]), index: index})
+		i = index
+	}
+	return values
+}
+
+func kindOf(config *Config, l *Line) (string, string) {
+	fields := l.Fields()
+	switch fields[0] {
+	case "enable":
+		return KindEnable, ""
+	case "username":
+		return KindUsername, fields[1]
+	}
+	for p := l.Parent; p != nil; p = p.Parent {
+		pf := p.Fields()
+		switch {
+		case pf[0] == "username" && len(pf) > 1:
+			return KindUsername, pf[1]
+		case pf[0] == "line":
+			return KindLine, p.Text
+		case pf[0] == "key" && len(pf) > 1 && pf[1] == "chain":
+			return KindKeyChain, p.Text
+		}
+	}
+	for p := l; p != nil; p = p.Parent {
+		switch {
+		case strings.Contains(p.Text, "tacacs"):
+			return KindTACACS, hostOf(config, l)
+		case strings.Contains(p.Text, "radius"):
+			return KindRADIUS, hostOf(config, l)
+		}
+	}
+	return KindOther, ""
+}
+
+// hostOf returns the server a TACACS+ or RADIUS key belongs to. IOS-XE
+// names a server with "tacacs server NAME" and gives its address on a line
+// of its own in that mode; the name stands in when there is none.
+func hostOf(config *Config, l *Line) string {
+	for p := l; p != nil; p = p.Parent {
+		fields := p.Fields()
+		if len(fields) == 3 && (fields[0] == "tacacs" || fields[0] == "radius") && fields[1] == "server" {
+			for _, child := range children(config, p) {
+				if cf := child.Fields(); len(cf) > 2 && cf[0] == "address" {
+					return cf[2]
+				}
+			}
+			return fields[2]
+		}
+		for i, f := range fields[:len(fields)-1] {
+			if f == "host" || f == "server-private" {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// snmpSecrets finds community strings and SNMPv3 user keys.
+func snmpSecrets(fields []string) []found {
+	// Indexes refer to the full line, which may start with snmp-server.
+	shift := 0
+	if fields[0] == "snmp-server" {
+		fields, shift = fields[1:], 1
+	}
+	offset := func(i int) int {
+		return i + shift
+	}
+	var values []found
+	switch {
+	case fields[0] == "community" && len(fields) > 1:
+		values = append(values, found{kind: KindSNMPCommunity, typ: "0", value: unquote(fields[1]), index: offset(1)})
+	case fields[0] == "host":
+		for i := 0; i+2 < len(fields); i++ {
+			if fields[i] == "version" && (fields[i+1] == "1" || fields[i+1] == "2c") {
+				values = append(values, found{kind: KindSNMPCommunity, owner: fields[1], typ: "0", value: unquote(fields[i+2]), index: offset(i + 2)})
+				break
+			}
+		}
+	case fields[0] == "user" && len(fields) > 1:
+		localized := false
+		for _, f := range fields {
+			if strings.HasPrefix(strings.ToLower(f), "localized") {
+				localized = true
+			}
+		}
+		for i := 0; i+2 < len(fields); i++ {
+			if fields[i] != "auth" && fields[i] != "priv" {
+				continue
+			}
+			alg, j := fields[i+1], i+2
+			if _, err := strconv.Atoi(fields[j]); err == nil && fields[i] == "priv" && j+1 < len(fields) {
+				// The AES key length, as in "priv aes 128".
+				j++
+			}
+			typ := "0"
+			if j < len(fields) && fields[j] == "encrypted" {
+				typ, j = "7", j+1
+			}
+			if j >= len(fields) {
+				break
+			}
+			values = append(values, found{kind: KindSNMPUser, owner: fields[1], typ: typ, value: fields[j], index: offset(j), localized: localized, auth: fields[i] + " " + alg})
+			i = j
+		}
+	}
+	return values
+}
+
+func classify(v found, osName string, weak Wordlist) Secret {
+	s := Secret{Kind: v.kind, Owner: v.owner, Type: v.typ}
+	switch {
+	case v.localized:
+		s.Type = "localized"
+		s.Algorithm, s.Protection = v.auth, StrongHash
+		if strings.HasSuffix(v.auth, " md5") || strings.HasSuffix(v.auth, " des") {
+			s.Protection = WeakHash
+			s.Findings = append(s.Findings, fmt.Sprintf("SNMPv3 %s is weak, use sha and aes", v.auth))
+		}
+		return s
+	case strings.HasPrefix(v.value, "$1$"):
+		s.Algorithm, s.Protection = "md5-crypt", WeakHash
+	case strings.HasPrefix(v.value, "$5$"):
+		s.Algorithm, s.Protection = "sha256-crypt", StrongHash
+	case strings.HasPrefix(v.value, "$6$"):
+		s.Algorithm, s.Protection = "sha512-crypt", StrongHash
+	case strings.HasPrefix(v.value, "$8$"):
+		s.Algorithm, s.Protection = "pbkdf2-sha256", StrongHash
+	case strings.HasPrefix(v.value, "$9$"):
+		s.Algorithm, s.Protection = "scrypt", StrongHash
+	case v.typ == "0":
+		s.Algorithm, s.Protection = "none", Plaintext
+	case v.typ == "7":
+		s.Algorithm, s.Protection = "vigenere", Reversible
+	case v.typ == "6":
+		s.Algorithm, s.Protection = "aes", Reversible
+	case v.typ == "4":
+		s.Algorithm, s.Protection = "sha256", WeakHash
+	case v.typ == "5":
+		s.Algorithm, s.Protection = "md5-crypt", WeakHash
+	default:
+		s.Algorithm, s.Protection = "unknown", Unknown
+	}
+
+	switch s.Protection {
+	case Plaintext:
+		s.Findings = append(s.Findings, "stored in plaintext")
+		s.Findings = append(s.Findings, passwordFindings(v.value, weak)...)
+	case Reversible:
+		if v.typ != "7" {
+			break
+		}
+		s.Findings = append(s.Findings, "type 7 is trivially reversible")
+		if plain, err := DecodeType7(v.value); err == nil {
+			s.Findings = append(s.Findings, passwordFindings(plain, weak)...)
+		} else if osName != NXOS {
+			// NX-OS uses its own type 7 scheme for some keys.
+			s.Findings = append(s.Findings, "not a valid type 7 string")
+		}
+	case WeakHash:
+		if s.Algorithm == "sha256" {
+			s.Findings = append(s.Findings, "type 4 is unsalted and broken, use type 8 or 9")
+		} else {
+			s.Findings = append(s.Findings, "MD5 is weaker than type 8 or 9")
+		}
+	}
+	return s
+}
+
+// passwordFindings tests a recovered password without revealing it.
+func passwordFindings(password string, weak Wordlist) []string {
+	var findings []string
+	if weak.Contains(password) {
+		findings = append(findings, "in the weak-password wordlist")
+	}
+	if len(password) < 8 {
+		findings = append(findings, fmt.Sprintf("only %d characters long", len(password)))
+	}
+	return findings
+}
+
+// type7Key is the fixed key Cisco type 7 XORs passwords with.
+const type7Key = "dsfd;kfoA,.iyewrkldJKDHSUBsgvca69834ncxv9873254k;fg87"
+
+// DecodeType7 reverses a Cisco type 7 string: a two digit decimal offset
+// into type7Key followed by the XORed bytes in hex.
+func DecodeType7(encoded string) (string, error) {
+	if len(encoded) < 4 || len(encoded)%2 != 0 {
+		return "", fmt.Errorf("invalid type 7 length %d", len(encoded))
+	}
+	seed, err := strconv.Atoi(encoded[:2])
+	if err != nil || seed >= len(type7Key) {
+		return "", fmt.Errorf("invalid type 7 offset %q", encoded[:2])
+	}
+	plain := make([]byte, 0, len(encoded)/2-1)
+	for i := 2; i < len(encoded); i += 2 {
+		b, err := strconv.ParseUint(encoded[i:i+2], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid type 7 byte %q", encoded[i:i+2])
+		}
+		c := byte(b) ^ type7Key[(seed+i/2-1)%len(type7Key)]
+		if c < 0x20 || c > 0x7e {
+			return "", fmt.Errorf("type 7 string does not decode to printable text")
+		}
+		plain = append(plain, c)
+	}
+	return string(plain), nil
+}
+
+func isType(field string) bool {
+	if len(field) == 0 || len(field) > 2 {
+		return false
+	}
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,134 @@
+package cisco
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeType7(t *testing.T) {
+	for encoded, want := range map[string]string{
+		"0822455D0A16":       "cisco",
+		"070C285F4D06":       "cisco",
+		"02050D480809":       "cisco",
+		"104D000A061843595F": "cisco123",
+		"121A0C0411045D5679": "cisco123",
+	} {
+		got, err := DecodeType7(encoded)
+		if err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", encoded, got, err, want)
+		}
+	}
+	for _, encoded := range []string{"", "08", "0822455D0A1", "9922455D0A16", "08ZZ455D0A16", "0800FF"} {
+		if got, err := DecodeType7(encoded); err == nil {
+			t.Errorf("%q: decoded to %q, want an error", encoded, got)
+		}
+	}
+}
+
+// secretsConfig holds one secret of each storage type. cisco is the
+// plaintext of every recoverable one.
+const secretsConfig = `hostname r1
+enable secret 5 $1$mERr$hx5rVt7rPNoS4wqbXKX7m0
+username md5 secret 5 $1$mERr$hx5rVt7rPNoS4wqbXKX7m0
+username pbkdf2 secret 8 $8$dsYGNam3K1SIJO$7nv/35M/qr6t.dC5xqn8RDYp/3VYdIGHszJlqvNbNkw
+username scrypt secret 9 $9$nhEmQVczB7dqsO$X.HsgL6x1il0RxkOSSvyQYwucySCt7qFm4v7pqCxkKM
+username sha4 secret 4 tnhtc92DXBhelxjYk8LWJrPV36S2i4ntXrpb4RFmfqY
+username vigenere password 7 0822455D0A16
+username plain password 0 cisco
+username long password 0 Corr3ct-Horse-Battery
+tacacs server ISE1
+ address ipv4 10.0.0.5
+ key 7 0822455D0A16
+tacacs server ISE2
+ key 0 cisco
+tacacs-server host 10.0.0.6 key 7 0822455D0A16
+radius-server host 10.0.0.7 auth-port 1812 key 0 cisco
+snmp-server community cisco RO 10
+snmp-server user mon grp v3 auth md5 0x1234567890abcdef localizedkey
+`
+
+func analyze(t *testing.T) (*Config, []Secret) {
+	t.Helper()
+	config := parse(t, secretsConfig, IOSXE)
+	return config, AnalyzeSecrets(config, DefaultWordlist())
+}
+
+func TestAnalyzeSecrets(t *testing.T) {
+	_, secrets := analyze(t)
+	type summary struct {
+		Line                  int
+		Kind, Owner, Type     string
+		Algorithm, Protection string
+		Findings              string
+	}
+	var got []summary
+	for _, s := range secrets {
+		got = append(got, summary{s.Line, s.Kind, s.Owner, s.Type, s.Algorithm, s.Protection, strings.Join(s.Findings, "; ")})
+	}
+	want := []summary{
+		{2, KindEnable, "", "5", "md5-crypt", WeakHash, "MD5 is weaker than type 8 or 9"},
+		{3, KindUsername, "md5", "5", "md5-crypt", WeakHash, "MD5 is weaker than type 8 or 9"},
+		{4, KindUsername, "pbkdf2", "8", "pbkdf2-sha256", StrongHash, ""},
+		{5, KindUsername, "scrypt", "9", "scrypt", StrongHash, ""},
+		{6, KindUsername, "sha4", "4", "sha256", WeakHash, "type 4 is unsalted and broken, use type 8 or 9"},
+		{7, KindUsername, "vigenere", "7", "vigenere", Reversible, "type 7 is trivially reversible; in the weak-password wordlist; only 5 characters long"},
+		{8, KindUsername, "plain", "0", "none", Plaintext, "stored in plaintext; in the weak-password wordlist; only 5 characters long"},
+		{9, KindUsername, "long", "0", "none", Plaintext, "stored in plaintext"},
+		{12, KindTACACS, "10.0.0.5", "7", "vigenere", Reversible, "type 7 is trivially reversible; in the weak-password wordlist; only 5 characters long"},
+		{14, KindTACACS, "ISE2", "0", "none", Plaintext, "stored in plaintext; in the weak-password wordlist; only 5 characters long"},
+		{15, KindTACACS, "10.0.0.6", "7", "vigenere", Reversible, "type 7 is trivially reversible; in the weak-password wordlist; only 5 characters long"},
+		{16, KindRADIUS, "10.0.0.7", "0", "none", Plaintext, "stored in plaintext; in the weak-password wordlist; only 5 characters long"},
+		{17, KindSNMPCommunity, "", "0", "none", Plaintext, "stored in plaintext; in the weak-password wordlist; only 5 characters long"},
+		{18, KindSNMPUser, "mon", "localized", "auth md5", WeakHash, "SNMPv3 auth md5 is weak, use sha and aes"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%+v\nwant:\n%+v", got, want)
+	}
+}
+
+// TestSecretsRedacted checks that no secret, recovered or stored, appears
+// in the report.
+func TestSecretsRedacted(t *testing.T) {
+	config, _ := analyze(t)
+	result, err := GetSecrets([]string{config.Path}, IOSXE, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{
+		"cisco", "Corr3ct-Horse-Battery", "0822455D0A16", "$1$", "$8$", "$9$",
+		"tnhtc92DXBhelxjYk8LWJrPV36S2i4ntXrpb4RFmfqY", "0x1234567890abcdef",
+	} {
+		if strings.Contains(result, secret) {
+			t.Errorf("report shows %q:\n%s", secret, result)
+		}
+	}
+	if !strings.Contains(result, "secrets: 14, weak: 12\n") {
+		t.Errorf("unexpected totals:\n%s", result)
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("Winter2024\r\n\nacme-router\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w := DefaultWordlist()
+	if err := w.LoadWordlist(path); err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range map[string]bool{
+		"cisco":       true,
+		"CISCO":       true,
+		"Winter2024":  true,
+		"acme-router": true,
+		"":            false,
+		"x7!Qv#p2LmR": false,
+	} {
+		if got := w.Contains(word); got != want {
+			t.Errorf("%q: got %t, want %t", word, got, want)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package configdiff
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"checklist/check"
+	"checklist/cisco"
+	"checklist/junos"
+)
+
+// node is one statement. Cisco statements nest by mode; JunOS statements
+// are whole set commands and so all sit at the top.
+type node struct {
+	text     string
+	line     *cisco.Line
+	index    int
+	children []*node
+}
+
+// side is one of the two configurations: its statements and a way to audit
+// it without one of them.
+type side struct {
+	os    string
+	root  *node
+	audit func(skip *node) []check.Result
+}
+
+// Change is a statement found in only one configuration, or at another
+// place among its neighbours. Checks holds the checks whose findings the
+// statement changes; statements inside an added or removed section are
+// covered by the section's own Change.
+type Change struct {
+	Added  bool
+	Checks []string
+
+	stmt *node
+}
+
+// Entry is a statement of the merged configuration: unchanged, with
+// changes below it, or a Change itself.
+type Entry struct {
+	Text     string
+	Change   *Change
+	Children []*Entry
+
+	stmt *node
+}
+
+// Delta is a check whose findings differ between the configurations.
+type Delta struct {
+	ID      string
+	Title   string
+	Before  []string
+	After   []string
+	Added   []string
+	Removed []string
+}
+
+// Diff compares an old and a new configuration of the same device.
+type Diff struct {
+	Old     string
+	New     string
+	OS      string
+	Entries []*Entry
+	Changes int
+	Deltas  []Delta
+}
+
+// Compare diffs two Cisco or JunOS configurations. osName is iosxe, iosxr,
+// nxos or junos; when empty, JunOS is told apart by its syntax and the
+// Cisco OS is detected from the banner lines. An empty siem uses the
+// default collectors of the OS.
+func Compare(oldPath, newPath, osName string, siem []string) (*Diff, error) {
+	if osName == "" {
+		oldVendor, err := detect(oldPath)
+		if err != nil {
+			return nil, err
+		}
+		newVendor, err := detect(newPath)
+		if err != nil {
+			return nil, err
+		}
+		if oldVendor != newVendor {
+			return nil, fmt.Errorf("%s is a %s configuration but %s is a %s one", oldPath, oldVendor, newPath, newVendor)
+		}
+		osName = oldVendor
+	}
+	load := ciscoSide
+	if osName == "junos" {
+		load = junosSide
+	} else if osName == "cisco" {
+		osName = ""
+	}
+	before, err := load(oldPath, osName, siem)
+	if err != nil {
+		return nil, err
+	}
+	after, err := load(newPath, osName, siem)
+	if err != nil {
+		return nil, err
+	}
+	if before.os != after.os {
+		return nil, fmt.Errorf("%s is %s but %s is %s", oldPath, before.os, newPath, after.os)
+	}
+
+	d := &Diff{Old: oldPath, New: newPath, OS: before.os}
+	oldResults, newResults := before.audit(nil), after.audit(nil)
+	d.Entries = merge(before.root.children, after.root.children)
+	walk(d.Entries, func(c *Change) {
+		d.Changes++
+		s, results := before, oldResults
+		if c.Added {
+			s, results = after, newResults
+		}
+		c.Checks = affected(results, s.audit(c.stmt))
+	})
+	for i, r := range oldResults {
+		n := newResults[i]
+		if slices.Equal(normalize(r.Findings), normalize(n.Findings)) {
+			continue
+		}
+		delta := Delta{ID: r.ID, Title: r.Title, Before: r.Findings, After: n.Findings}
+		delta.Removed = missing(r.Findings, n.Findings)
+		delta.Added = missing(n.Findings, r.Findings)
+		d.Deltas = append(d.Deltas, delta)
+	}
+	return d, nil
+}
+
+// GetDiff prints the changed statements, each added or removed section or
+// statement followed by the checks it affects, then the checks whose
+// outcome changed.
+func GetDiff(oldPath, newPath, osName string, siem []string) (string, error) {
+	d, err := Compare(oldPath, newPath, osName, siem)
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("****%s -> %s (%s)****\n", d.Old, d.New, d.OS)
+	result += format(d.Entries, 0)
+	result += "****checks****\n"
+	var regressed, fixed int
+	for _, delta := range d.Deltas {
+		var mark, status string
+		switch {
+		case len(delta.Before) == 0:
+			mark, status = "-", "passed before, fails now"
+			regressed++
+		case len(delta.After) == 0:
+			mark, status = "+", "failed before, passes now"
+			fixed++
+		default:
+			mark, status = "~", "still fails, findings changed"
+		}
+		result += fmt.Sprintf("%s%s %s: %s\n", mark, delta.ID, delta.Title, status)
+		for _, f := range delta.Removed {
+			result += fmt.Sprintf("  -%s\n", f)
+		}
+		for _, f := range delta.Added {
+			result += fmt.Sprintf("  +%s\n", f)
+		}
+	}
+	var relevant int
+	walk(d.Entries, func(c *Change) {
+		if len(c.Checks) > 0 {
+			relevant++
+		}
+	})
+	result += fmt.Sprintf("changes: %d, security relevant: %d, checks regressed: %d, fixed: %d\n", d.Changes, relevant, regressed, fixed)
+	return result, nil
+}
+
+func format(entries []*Entry, depth int) string {
+	var result string
+	indent := strings.Repeat("  ", depth)
+	for _, e := range entries {
+		if e.Change == nil {
+			result += fmt.Sprintf(" %s%s\n", indent, e.Text)
+			result += format(e.Children, depth+1)
+			continue
+		}
+		mark := "-"
+		if e.Change.Added {
+			mark = "+"
+		}
+		result += fmt.Sprintf("%s%s%s", mark, indent, e.Text)
+		if len(e.Change.Checks) > 0 {
+			result += fmt.Sprintf("  [%s]", strings.Join(e.Change.Checks, ", "))
+		}
+		result += "\n"
+		result += formatAll(e.Children, mark, depth+1)
+	}
+	return result
+}
+
+func formatAll(entries []*Entry, mark string, depth int) string {
+	var result string
+	for _, e := range entries {
+		result += fmt.Sprintf("%s%s%s\n", mark, strings.Repeat("  ", depth), e.Text)
+		result += formatAll(e.Children, mark, depth+1)
+	}
+	return result
+}
+
+// merge lines up the statements of two sections along their longest
+// common subsequence, so the order of statements counts: an ACL entry or
+// filter term that moves is removed where it was and added where it is
+// now. Between two matched statements, the removed ones come first and
+// the additions follow what they replace.
+func merge(before, after []*node) []*Entry {
+	var (
+		entries []*Entry
+		i, j    int
+	)
+	flush := func(toOld, toNew int) {
+		for ; i < toOld; i++ {
+			n := before[i]
+			entries = append(entries, &Entry{Text: n.text, Change: &Change{stmt: n}, Children: whole(n.children), stmt: n})
+		}
+		for ; j < toNew; j++ {
+			n := after[j]
+			entries = append(entries, &Entry{Text: n.text, Change: &Change{Added: true, stmt: n}, Children: whole(n.children), stmt: n})
+		}
+	}
+	for _, m := range common(before, after) {
+		flush(m[0], m[1])
+		// Unchanged statements only show when something below them changed.
+		if children := merge(before[i].children, after[j].children); len(children) > 0 {
+			entries = append(entries, &Entry{Text: before[i].text, Children: children, stmt: before[i]})
+		}
+		i, j = i+1, j+1
+	}
+	flush(len(before), len(after))
+	return entries
+}
+
+// common returns the index pairs of the longest common subsequence of the
+// statement texts. The shared head and tail are matched directly, which
+// keeps the table small for the usual edit of a few lines.
+func common(before, after []*node) [][2]int {
+	var head [][2]int
+	for len(head) < len(before) && len(head) < len(after) && before[len(head)].text == after[len(head)].text {
+		head = append(head, [2]int{len(head), len(head)})
+	}
+	var tail int
+	for tail < len(before)-len(head) && tail < len(after)-len(head) &&
+		before[len(before)-1-tail].text == after[len(after)-1-tail].text {
+		tail++
+	}
+	olds, news := before[len(head):len(before)-tail], after[len(head):len(after)-tail]
+
+	// length[a][b] is the LCS length of olds[a:] and news[b:].
+	length := make([][]int32, len(olds)+1)
+	for a := range length {
+		length[a] = make([]int32, len(news)+1)
+	}
+	for a := len(olds) - 1; a >= 0; a-- {
+		for b := len(news) - 1; b >= 0; b-- {
+			if olds[a].text == news[b].text {
+				length[a][b] = length[a+1][b+1] + 1
+			} else {
+				length[a][b] = max(length[a+1][b], length[a][b+1])
+			}
+		}
+	}
+	pairs := head
+	for a, b := 0, 0; a < len(olds) && b < len(news); {
+		switch {
+		case olds[a].text == news[b].text:
+			pairs = append(pairs, [2]int{len(head) + a, len(head) + b})
+			a, b = a+1, b+1
+		case length[a+1][b] >= length[a][b+1]:
+			a++
+		default:
+			b++
+		}
+	}
+	for k := tail; k > 0; k-- {
+		pairs = append(pairs, [2]int{len(before) - k, len(after) - k})
+	}
+	return pairs
+}
+
+// whole lists the statements of an added or removed section.
+func whole(nodes []*node) []*Entry {
+	var entries []*Entry
+	for _, n := range nodes {
+		entries = append(entries, &Entry{Text: n.text, Children: whole(n.children), stmt: n})
+	}
+	return entries
+}
+
+func walk(entries []*Entry, f func(*Change)) {
+	for _, e := range entries {
+		if e.Change != nil {
+			f(e.Change)
+			continue
+		}
+		walk(e.Children, f)
+	}
+}
+
+// affected returns the checks whose findings change when a statement is
+// left out.
+func affected(with, without []check.Result) []string {
+	var ids []string
+	for i, r := range with {
+		if !slices.Equal(normalize(r.Findings), normalize(without[i].Findings)) {
+			ids = append(ids, r.ID)
+		}
+	}
+	return ids
+}
+
+var lineNumber = regexp.MustCompile(`\bline \d+\b`)
+
+// normalize drops line numbers from findings, since unchanged statements
+// move when others are added or removed.
+func normalize(findings []string) []string {
+	var out []string
+	for _, f := range findings {
+		out = append(out, lineNumber.ReplaceAllString(f, "line"))
+	}
+	slices.Sort(out)
+	return out
+}
+
+// missing returns the findings of a that b does not have.
+func missing(a, b []string) []string {
+	have := make(map[string]int)
+	for _, f := range normalize(b) {
+		have[f]++
+	}
+	var out []string
+	for _, f := range a {
+		key := lineNumber.ReplaceAllString(f, "line")
+		if have[key] > 0 {
+			have[key]--
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func ciscoSide(path, osName string, siem []string) (*side, error) {
+	config, err := cisco.ParseConfig(path, osName)
+	if err != nil {
+		return nil, err
+	}
+	root := &node{}
+	nodes := make(map[*cisco.Line]*node)
+	for _, l := range config.Lines {
+		n := &node{text: l.Text, line: l}
+		nodes[l] = n
+		parent := root
+		if l.Parent != nil {
+			parent = nodes[l.Parent]
+		}
+		parent.children = append(parent.children, n)
+	}
+	opts := cisco.Options{SIEM: siem}
+	audit := func(skip *node) []check.Result {
+		c := config
+		if skip != nil {
+			c = &cisco.Config{Path: config.Path, OS: config.OS}
+			for _, l := range config.Lines {
+				if !below(l, skip.line) {
+					c.Lines = append(c.Lines, l)
+				}
+			}
+		}
+		return cisco.Audit(c, opts)
+	}
+	return &side{os: config.OS, root: root, audit: audit}, nil
+}
+
+// below reports whether l is top or in a mode entered from it.
+func below(l, top *cisco.Line) bool {
+	for ; l != nil; l = l.Parent {
+		if l == top {
+			return true
+		}
+	}
+	return false
+}
+
+func junosSide(path, osName string, siem []string) (*side, error) {
+	config, err := junos.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	commands := strings.Split(strings.TrimSuffix(junos.FormatSet(config.Root), "\n"), "\n")
+	root := &node{}
+	for i, c := range commands {
+		root.children = append(root.children, &node{text: c, index: i})
+	}
+	if len(siem) == 0 {
+		siem = junos.DefaultSIEM
+	}
+	opts := junos.Options{SIEM: siem}
+	audit := func(skip *node) []check.Result {
+		var kept []string
+		for i, c := range commands {
+			if skip == nil || i != skip.index {
+				kept = append(kept, c)
+			}
+		}
+		// The commands were written by FormatSet, so they read back.
+		r, _ := junos.ReadSet(strings.NewReader(strings.Join(kept, "\n")))
+		return junos.Audit(&junos.Config{Path: path, Root: r}, opts)
+	}
+	return &side{os: "junos", root: root, audit: audit}, nil
+}
+
+// detect tells a JunOS configuration from a Cisco one.
+func detect(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if junos.Recognize(content) {
+		return "junos", nil
+	}
+	return "cisco", nil
+}
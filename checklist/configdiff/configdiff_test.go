@@ -0,0 +1,116 @@
+package configdiff
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rewrite copies path into a temporary file with old replaced by new.
+func rewrite(t *testing.T, path, old, new string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), old) {
+		t.Fatalf("%s does not contain %q", path, old)
+	}
+	out := filepath.Join(t.TempDir(), filepath.Base(path))
+	if err = os.WriteFile(out, []byte(strings.Replace(string(content), old, new, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func changed(d *Diff) []string {
+	var texts []string
+	walk(d.Entries, func(c *Change) {
+		mark := "-"
+		if c.Added {
+			mark = "+"
+		}
+		texts = append(texts, mark+strings.TrimSpace(c.stmt.text))
+	})
+	return texts
+}
+
+func TestSameConfig(t *testing.T) {
+	for _, path := range []string{"../../iosxe/iosxe.cfg", "../../iosxr/asr9k.cfg", "../../nxos/n9k.cfg", "../../junos/jsw.cfg"} {
+		d, err := Compare(path, path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Changes != 0 || len(d.Deltas) != 0 {
+			t.Errorf("%s: %d changes, %d deltas", path, d.Changes, len(d.Deltas))
+		}
+	}
+}
+
+func TestACLEntryMoved(t *testing.T) {
+	const path = "../../iosxr/asr9k.cfg"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the first two entries of the first IPv4 ACL.
+	lines := strings.Split(string(content), "\n")
+	i := slices.IndexFunc(lines, func(l string) bool { return strings.HasPrefix(l, "ipv4 access-list ") })
+	first, second := lines[i+1], lines[i+2]
+	moved := rewrite(t, path, first+"\n"+second+"\n", second+"\n"+first+"\n")
+
+	d, err := Compare(path, moved, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Either entry may be the one that moved; it is removed and added back.
+	got := changed(d)
+	if len(got) != 2 || got[0][1:] != got[1][1:] || got[0][0] == got[1][0] ||
+		(got[0][1:] != strings.TrimSpace(first) && got[0][1:] != strings.TrimSpace(second)) {
+		t.Errorf("got %q, want one of the swapped entries removed and added", got)
+	}
+}
+
+func TestFilterTermMoved(t *testing.T) {
+	const path = "../../junos/jsw.cfg"
+	const term = "set firewall family inet filter limit-mgmt-access term default_accept then accept\n"
+	const last = "set firewall family inet filter limit-mgmt-access term block_non_manager then discard\n"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := strings.Replace(string(content), term, "", 1)
+	out = strings.Replace(out, last, last+term, 1)
+	moved := filepath.Join(t.TempDir(), "jsw.cfg")
+	if err = os.WriteFile(moved, []byte(out), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Compare(path, moved, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Changes != 2 {
+		t.Errorf("got %d changes, want 2: %q", d.Changes, changed(d))
+	}
+	var fixed []string
+	for _, delta := range d.Deltas {
+		if len(delta.After) == 0 {
+			fixed = append(fixed, delta.ID)
+		}
+	}
+	if !slices.Equal(fixed, []string{"8004", "8010"}) {
+		t.Errorf("fixed %v, want 8004 and 8010", fixed)
+	}
+	var removed *Change
+	walk(d.Entries, func(c *Change) {
+		if !c.Added {
+			removed = c
+		}
+	})
+	if removed == nil || !slices.Equal(removed.Checks, []string{"8004", "8010"}) {
+		t.Errorf("the term's old position is not tied to 8004 and 8010: %+v", removed)
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"checklist/cisco"
+	"checklist/configdiff"
+	"checklist/fetch"
+	"checklist/inventory"
+	"checklist/junos"
+	"checklist/netdevice"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deviceOS        string
+	deviceWordlist  string
+	deviceSIEM      []string
+	deviceSet       bool
+	deviceTarget    fetch.Target
+	deviceKnown     string
+	deviceInsecure  bool
+	deviceOut       string
+	deviceTimeout   time.Duration
+	deviceInventory string
+	deviceTags      []string
+	deviceWorkers   int
+	deviceVars      string
+)
+
+var deviceCmd = &cobra.Command{
+	Use:   "device",
+	Short: "Audit network device configurations",
+}
+
+var deviceSecretsCmd = &cobra.Command{
+	Use:   "secrets <config>...",
+	Short: "Classify the passwords, secrets and keys in Cisco configurations",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceSecrets,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceACLCmd = &cobra.Command{
+	Use:   "acl <config>...",
+	Short: "Report the sources that can reach VTY, SNMP and HTTP management in Cisco configurations",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceACL,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceJunosCmd = &cobra.Command{
+	Use:   "junos <config>...",
+	Short: "Run the JunOS checks 8001-8015 against set or curly-brace configurations",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceJunos,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceFetchCmd = &cobra.Command{
+	Use:   "fetch <address>...",
+	Short: "Pull device configurations over SSH, store them with a timestamp and audit them",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceFetch,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceCiscoCmd = &cobra.Command{
+	Use:   "cisco <config>...",
+	Short: "Run the IOS-XE 7001-7015, IOS-XR 9001-9015 and NX-OS 10001-10015 checks against Cisco configurations",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceCisco,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the devices of an inventory in parallel and report fleet compliance",
+	Args:  cobra.NoArgs,
+	RunE:  runDeviceAudit,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceDiffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two configurations of a device by section and show the changes that affect the checks",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runDeviceDiff,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceControlsCmd = &cobra.Command{
+	Use:   "controls <config>...",
+	Short: "Run the vendor-neutral controls against Cisco and JunOS configurations",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceControls,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var deviceRemediateCmd = &cobra.Command{
+	Use:   "remediate <config>...",
+	Short: "Print the configuration that fixes the failing controls, for review before it is applied",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runDeviceRemediate,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	deviceSecretsCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr or nxos, detected when empty")
+	deviceSecretsCmd.Flags().StringVar(&deviceWordlist, "wordlist", "", "file of weak passwords, one per line, added to the built-in list")
+	deviceACLCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr or nxos, detected when empty")
+	deviceJunosCmd.Flags().StringSliceVar(&deviceSIEM, "siem", junos.DefaultSIEM, "syslog collectors as host or host:port, one must receive the device's logs")
+	deviceJunosCmd.Flags().BoolVar(&deviceSet, "display-set", false, "print the configurations as set commands instead of checking them")
+	deviceFetchCmd.Flags().StringVar(&deviceTarget.OS, "os", "", "device OS: iosxe, iosxr, nxos or junos")
+	deviceFetchCmd.Flags().StringVarP(&deviceTarget.User, "user", "u", os.Getenv("USER"), "login user")
+	deviceFetchCmd.Flags().StringVar(&deviceTarget.Password, "password", "", "login password, read from CHECKLIST_PASSWORD when empty")
+	deviceFetchCmd.Flags().StringVar(&deviceTarget.EnablePassword, "enable-password", "", "IOS-XE enable password, read from CHECKLIST_ENABLE_PASSWORD when empty")
+	deviceFetchCmd.Flags().StringVarP(&deviceTarget.KeyFile, "key", "i", "", "private key file")
+	deviceFetchCmd.Flags().StringVar(&deviceKnown, "known-hosts", "", "known_hosts file to check host keys against, defaults to ~/.ssh/known_hosts")
+	deviceFetchCmd.Flags().BoolVar(&deviceInsecure, "insecure", false, "accept any host key")
+	deviceFetchCmd.Flags().StringVarP(&deviceOut, "out", "o", ".", "directory to store the configurations in")
+	deviceFetchCmd.Flags().DurationVar(&deviceTimeout, "timeout", 30*time.Second, "time to wait for the device at each step")
+	deviceFetchCmd.Flags().StringSliceVar(&deviceSIEM, "siem", nil, "syslog collectors as host or host:port, defaults to the site collectors for each OS")
+	deviceFetchCmd.MarkFlagRequired("os")
+	deviceCiscoCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr or nxos, detected when empty")
+	deviceCiscoCmd.Flags().StringSliceVar(&deviceSIEM, "siem", nil, "syslog collectors as host or host:port, defaults to the site collectors for each OS")
+	deviceAuditCmd.Flags().StringVar(&deviceInventory, "inventory", "", "inventory file listing the devices")
+	deviceAuditCmd.Flags().StringSliceVar(&deviceTags, "tags", nil, "only audit devices with one of these tags")
+	deviceAuditCmd.Flags().IntVar(&deviceWorkers, "workers", runtime.NumCPU(), "number of devices to audit at once")
+	deviceAuditCmd.Flags().StringSliceVar(&deviceSIEM, "siem", nil, "syslog collectors as host or host:port, defaults to the site collectors for each OS")
+	deviceAuditCmd.Flags().StringVarP(&deviceTarget.User, "user", "u", os.Getenv("USER"), "login user for devices without a config")
+	deviceAuditCmd.Flags().StringVar(&deviceTarget.Password, "password", "", "login password, read from CHECKLIST_PASSWORD when empty")
+	deviceAuditCmd.Flags().StringVar(&deviceTarget.EnablePassword, "enable-password", "", "IOS-XE enable password, read from CHECKLIST_ENABLE_PASSWORD when empty")
+	deviceAuditCmd.Flags().StringVarP(&deviceTarget.KeyFile, "key", "i", "", "private key file")
+	deviceAuditCmd.Flags().StringVar(&deviceKnown, "known-hosts", "", "known_hosts file to check host keys against, defaults to ~/.ssh/known_hosts")
+	deviceAuditCmd.Flags().BoolVar(&deviceInsecure, "insecure", false, "accept any host key")
+	deviceAuditCmd.Flags().StringVarP(&deviceOut, "out", "o", ".", "directory to store fetched configurations in")
+	deviceAuditCmd.Flags().DurationVar(&deviceTimeout, "timeout", 30*time.Second, "time to wait for a device at each step")
+	deviceAuditCmd.MarkFlagRequired("inventory")
+	deviceDiffCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr, nxos or junos, detected when empty")
+	deviceDiffCmd.Flags().StringSliceVar(&deviceSIEM, "siem", nil, "syslog collectors as host or host:port, defaults to the site collectors for the OS")
+	deviceControlsCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr, nxos or junos, detected when empty")
+	deviceControlsCmd.Flags().StringSliceVar(&deviceSIEM, "siem", nil, "syslog collectors as host or host:port, defaults to the site collectors for each OS")
+	deviceRemediateCmd.Flags().StringVar(&deviceOS, "os", "", "configuration OS: iosxe, iosxr, nxos or junos, detected when empty")
+	deviceRemediateCmd.Flags().StringVar(&deviceVars, "vars", "", "YAML file of site values, such as siem and ntp, for the placeholders")
+	deviceCmd.AddCommand(deviceSecretsCmd, deviceACLCmd, deviceJunosCmd, deviceFetchCmd, deviceCiscoCmd, deviceAuditCmd, deviceDiffCmd, deviceControlsCmd, deviceRemediateCmd)
+	rootCmd.AddCommand(deviceCmd)
+}
+
+func runDeviceSecrets(cmd *cobra.Command, args []string) error {
+	result, err := cisco.GetSecrets(args, deviceOS, deviceWordlist)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceACL(cmd *cobra.Command, args []string) error {
+	result, err := cisco.GetManagementACLs(args, deviceOS)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceJunos(cmd *cobra.Command, args []string) error {
+	if deviceSet {
+		for _, path := range args {
+			config, err := junos.Parse(path)
+			if err != nil {
+				return err
+			}
+			fmt.Print(junos.FormatSet(config.Root))
+		}
+		return nil
+	}
+	result, err := junos.GetChecks(args, deviceSIEM)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceCisco(cmd *cobra.Command, args []string) error {
+	result, err := cisco.GetChecks(args, deviceOS, deviceSIEM)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceAudit(cmd *cobra.Command, args []string) error {
+	inv, err := inventory.Load(deviceInventory)
+	if err != nil {
+		return err
+	}
+	devices := inv.Select(deviceTags)
+	if len(devices) == 0 {
+		return fmt.Errorf("no device in %s has the tags %s", deviceInventory, strings.Join(deviceTags, ", "))
+	}
+	opts := inventory.Options{Workers: deviceWorkers, SIEM: deviceSIEM}
+	for _, d := range devices {
+		if d.Config == "" {
+			// Credentials and host keys are only needed to fetch.
+			if opts.Fetch, err = fetchOptions(); err != nil {
+				return err
+			}
+			opts.Target = deviceTarget
+			break
+		}
+	}
+	fmt.Print(inventory.Matrix(inventory.Audit(devices, opts)))
+	return nil
+}
+
+func runDeviceDiff(cmd *cobra.Command, args []string) error {
+	result, err := configdiff.GetDiff(args[0], args[1], deviceOS, deviceSIEM)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceControls(cmd *cobra.Command, args []string) error {
+	result, err := netdevice.GetControls(args, deviceOS, deviceSIEM)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+func runDeviceRemediate(cmd *cobra.Command, args []string) error {
+	var vars netdevice.Variables
+	if deviceVars != "" {
+		var err error
+		if vars, err = netdevice.LoadVariables(deviceVars); err != nil {
+			return err
+		}
+	}
+	result, err := netdevice.GetRemediation(args, deviceOS, vars)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+// fetchOptions fills the passwords from the environment and prepares the
+// host key check and the output directory.
+func fetchOptions() (fetch.Options, error) {
+	if deviceTarget.Password == "" {
+		deviceTarget.Password = os.Getenv("CHECKLIST_PASSWORD")
+	}
+	if deviceTarget.EnablePassword == "" {
+		deviceTarget.EnablePassword = os.Getenv("CHECKLIST_ENABLE_PASSWORD")
+	}
+	if deviceKnown == "" && !deviceInsecure {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fetch.Options{}, err
+		}
+		deviceKnown = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := fetch.HostKeys(deviceKnown, deviceInsecure)
+	if err != nil {
+		return fetch.Options{}, err
+	}
+	if err := os.MkdirAll(deviceOut, 0700); err != nil {
+		return fetch.Options{}, err
+	}
+	return fetch.Options{HostKeyCallback: hostKeys, Timeout: deviceTimeout, Dir: deviceOut}, nil
+}
+
+func runDeviceFetch(cmd *cobra.Command, args []string) error {
+	opts, err := fetchOptions()
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, address := range args {
+		target := deviceTarget
+		target.Address = address
+		path, err := fetch.Fetch(target, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", address, err)
+		}
+		fmt.Printf("saved %s\n", path)
+		paths = append(paths, path)
+	}
+
+	var result string
+	if deviceTarget.OS == fetch.Junos {
+		result, err = junos.GetChecks(paths, deviceSIEM)
+	} else {
+		result, err = cisco.GetSecrets(paths, deviceTarget.OS, "")
+		if err == nil {
+			var acls string
+			acls, err = cisco.GetManagementACLs(paths, deviceTarget.OS)
+			result += acls
+		}
+		if err == nil {
+			var checks string
+			checks, err = cisco.GetChecks(paths, deviceTarget.OS, deviceSIEM)
+			result += checks
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
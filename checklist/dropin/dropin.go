@@ -0,0 +1,38 @@
+package dropin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Files lists the files with the given suffix across dirs, sorted by file
+// name. dirs go from lowest to highest precedence: a file in a later
+// directory masks one with the same name in an earlier directory, as
+// systemd does for /usr/lib, /run and /etc.
+func Files(dirs []string, suffix string) []string {
+	byName := make(map[string]string)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), suffix) {
+				continue
+			}
+			byName[e.Name()] = filepath.Join(dir, e.Name())
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		files = append(files, byName[name])
+	}
+	return files
+}
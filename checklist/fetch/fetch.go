@@ -0,0 +1,341 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	IOSXE = "iosxe"
+	IOSXR = "iosxr"
+	NXOS  = "nxos"
+	Junos = "junos"
+)
+
+// cli holds the commands that turn off paging and print the configuration.
+type cli struct {
+	paging string
+	show   string
+	enable bool
+}
+
+var clis = map[string]cli{
+	IOSXE: {"terminal length 0", "show running-config", true},
+	IOSXR: {"terminal length 0", "show running-config", false},
+	NXOS:  {"terminal length 0", "show running-config", false},
+	Junos: {"set cli screen-length 0", "show configuration | display set", false},
+}
+
+// Target is a device to log into. Either Password or KeyFile is needed;
+// EnablePassword is used when IOS-XE logs in at an unprivileged prompt.
+type Target struct {
+	Address        string
+	OS             string
+	User           string
+	Password       string
+	EnablePassword string
+	KeyFile        string
+}
+
+// Options control how devices are reached and where configurations go.
+type Options struct {
+	HostKeyCallback ssh.HostKeyCallback
+	Timeout         time.Duration
+	Dir             string
+}
+
+// Fetch logs into the device, pulls its configuration and stores it in
+// opts.Dir as <host>_<timestamp>.cfg. It returns the file's path.
+func Fetch(t Target, opts Options) (string, error) {
+	config, err := Run(t, opts)
+	if err != nil {
+		return "", err
+	}
+	host, _, err := net.SplitHostPort(t.Address)
+	if err != nil {
+		host = t.Address
+	}
+	name := fmt.Sprintf("%s_%s.cfg", strings.NewReplacer(":", "_", "/", "_").Replace(host), time.Now().Format("20060102T150405"))
+	path := filepath.Join(opts.Dir, name)
+	// Configurations hold secrets, so only the owner may read them.
+	if err := os.WriteFile(path, config, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Run logs into the device and returns its configuration.
+func Run(t Target, opts Options) ([]byte, error) {
+	c, ok := clis[t.OS]
+	if !ok {
+		return nil, fmt.Errorf("unknown device OS %q, want %s, %s, %s or %s", t.OS, IOSXE, IOSXR, NXOS, Junos)
+	}
+	if opts.HostKeyCallback == nil {
+		return nil, errors.New("no host key callback")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	auth, err := authMethods(t)
+	if err != nil {
+		return nil, err
+	}
+	address := t.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+	client, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            t.User,
+		Auth:            auth,
+		HostKeyCallback: opts.HostKeyCallback,
+		Timeout:         opts.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	modes := ssh.TerminalModes{ssh.ECHO: 0, ssh.TTY_OP_ISPEED: 38400, ssh.TTY_OP_OSPEED: 38400}
+	if err := session.RequestPty("vt100", 0, 511, modes); err != nil {
+		return nil, err
+	}
+	in, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.Shell(); err != nil {
+		return nil, err
+	}
+	sh := newShell(in, out, opts.Timeout)
+	defer sh.close()
+
+	greeting, err := sh.expect(anyPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for prompt: %w", err)
+	}
+	prompt := lastLine(greeting)
+	base := prompt[:len(prompt)-1]
+	atPrompt := func(s string) bool {
+		l := lastLine(s)
+		return len(l) == len(base)+1 && strings.HasPrefix(l, base) && strings.ContainsAny(l[len(base):], ">#%")
+	}
+
+	if c.enable && strings.HasSuffix(prompt, ">") {
+		if err := sh.send("enable"); err != nil {
+			return nil, err
+		}
+		reply, err := sh.expect(func(s string) bool {
+			return atPrompt(s) || strings.HasSuffix(strings.ToLower(lastLine(s)), "password:")
+		})
+		if err != nil {
+			return nil, fmt.Errorf("enable: %w", err)
+		}
+		if !atPrompt(reply) {
+			if t.EnablePassword == "" {
+				return nil, errors.New("enable: device asks for a password and none was given")
+			}
+			if err := sh.send(t.EnablePassword); err != nil {
+				return nil, err
+			}
+			if reply, err = sh.expect(atPrompt); err != nil {
+				return nil, fmt.Errorf("enable: %w", err)
+			}
+		}
+		if !strings.HasSuffix(lastLine(reply), "#") {
+			return nil, errors.New("enable: still at the unprivileged prompt")
+		}
+	}
+
+	if err := sh.send(c.paging); err != nil {
+		return nil, err
+	}
+	if _, err := sh.expect(atPrompt); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.paging, err)
+	}
+	if err := sh.send(c.show); err != nil {
+		return nil, err
+	}
+	output, err := sh.expect(atPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.show, err)
+	}
+	if err := sh.send("exit"); err == nil {
+		sh.wait()
+	}
+	return clean(output, c.show), nil
+}
+
+func authMethods(t Target) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if t.KeyFile != "" {
+		key, err := os.ReadFile(t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.KeyFile, err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if t.Password != "" {
+		// Many devices only offer keyboard-interactive for passwords.
+		methods = append(methods, ssh.Password(t.Password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = t.Password
+				}
+				return answers, nil
+			}))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no password or key given")
+	}
+	return methods, nil
+}
+
+// clean drops the echoed command, the closing prompt and carriage returns.
+func clean(output, command string) []byte {
+	lines := strings.Split(strings.ReplaceAll(output, "\r", ""), "\n")
+	if len(lines) > 0 && strings.Contains(lines[0], command) {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+func lastLine(s string) string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r", ""), " ")
+	return s[strings.LastIndex(s, "\n")+1:]
+}
+
+// anyPrompt matches the first prompt after login, such as "router>",
+// "RP/0/RP0/CPU0:asr9k#" or "admin@jsw>".
+func anyPrompt(s string) bool {
+	l := lastLine(s)
+	return len(l) > 1 && len(l) <= 128 && strings.ContainsAny(l[len(l)-1:], ">#%") && !strings.ContainsAny(l, " \t")
+}
+
+var pagers = []string{"--More--", "---(more)---"}
+
+// shell reads a device's interactive output in the background so expect
+// can wait for a prompt with a deadline.
+type shell struct {
+	in      io.Writer
+	out     chan []byte
+	done    chan struct{}
+	buf     bytes.Buffer
+	timeout time.Duration
+}
+
+func newShell(in io.Writer, out io.Reader, timeout time.Duration) *shell {
+	sh := &shell{in: in, out: make(chan []byte), done: make(chan struct{}), timeout: timeout}
+	go func() {
+		defer close(sh.out)
+		for {
+			chunk := make([]byte, 4096)
+			n, err := out.Read(chunk)
+			if n > 0 {
+				select {
+				case sh.out <- chunk[:n]:
+				case <-sh.done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return sh
+}
+
+// wait lets the device close the session, reading whatever it prints
+// until then.
+func (sh *shell) wait() {
+	timer := time.NewTimer(sh.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-sh.out:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func (sh *shell) close() {
+	close(sh.done)
+}
+
+func (sh *shell) send(line string) error {
+	_, err := io.WriteString(sh.in, line+"\n")
+	return err
+}
+
+// expect reads until done reports true for everything read since the last
+// call, answering pager prompts on the way, and returns what was read.
+func (sh *shell) expect(done func(string) bool) (string, error) {
+	timer := time.NewTimer(sh.timeout)
+	defer timer.Stop()
+	for {
+		for _, pager := range pagers {
+			if strings.HasSuffix(strings.TrimRight(sh.buf.String(), " "), pager) {
+				text := strings.TrimRight(sh.buf.String(), " ")
+				sh.buf.Reset()
+				sh.buf.WriteString(strings.TrimRight(strings.TrimSuffix(text, pager), " "))
+				if _, err := io.WriteString(sh.in, " "); err != nil {
+					return "", err
+				}
+			}
+		}
+		if done(sh.buf.String()) {
+			text := sh.buf.String()
+			sh.buf.Reset()
+			return text, nil
+		}
+		select {
+		case chunk, ok := <-sh.out:
+			if !ok {
+				return "", io.ErrUnexpectedEOF
+			}
+			sh.buf.Write(chunk)
+		case <-timer.C:
+			return "", fmt.Errorf("timed out after %s", sh.timeout)
+		}
+	}
+}
+
+// HostKeys checks host keys against a known_hosts file, or accepts any key
+// when insecure is set.
+func HostKeys(knownHostsFile string, insecure bool) (ssh.HostKeyCallback, error) {
+	if insecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	return knownhosts.New(knownHostsFile)
+}
@@ -0,0 +1,341 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"checklist/cisco"
+	"checklist/junos"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// device is an in-process SSH server that answers like a device CLI.
+type device struct {
+	os             string
+	prompt         string
+	password       string
+	enablePassword string
+	authorizedKey  ssh.PublicKey
+	config         string
+	ignorePaging   bool
+
+	mu       sync.Mutex
+	commands []string
+	hostKey  ssh.PublicKey
+}
+
+var pagingCommands = map[string]string{
+	IOSXE: "terminal length 0",
+	IOSXR: "terminal length 0",
+	NXOS:  "terminal length 0",
+	Junos: "set cli screen-length 0",
+}
+
+var showCommands = map[string]string{
+	IOSXE: "show running-config",
+	IOSXR: "show running-config",
+	NXOS:  "show running-config",
+	Junos: "show configuration | display set",
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// start listens on a loopback port and returns the address to dial.
+func (d *device) start(t *testing.T) string {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "admin" && d.password != "" && string(password) == d.password {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if c.User() == "admin" && d.authorizedKey != nil && bytes.Equal(key.Marshal(), d.authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	signer := newSigner(t)
+	config.AddHostKey(signer)
+	d.hostKey = signer.PublicKey()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn, config)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (d *device) serve(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		shell := make(chan bool, 1)
+		go func() {
+			for req := range requests {
+				ok := req.Type == "pty-req" || req.Type == "shell"
+				req.Reply(ok, nil)
+				if req.Type == "shell" {
+					shell <- true
+				}
+			}
+		}()
+		<-shell
+		d.cli(ch)
+		ch.Close()
+	}
+}
+
+func (d *device) record(command string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commands = append(d.commands, command)
+}
+
+func (d *device) received() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.commands...)
+}
+
+func (d *device) cli(ch ssh.Channel) {
+	prompt := d.prompt
+	paging := true
+	write := func(s string) { ch.Write([]byte(s)) }
+	in := bufio.NewReader(ch)
+	readLine := func() (string, bool) {
+		line, err := in.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err == nil
+	}
+
+	write("\r\nUser Access Verification\r\n\r\n" + prompt)
+	for {
+		command, ok := readLine()
+		if !ok {
+			return
+		}
+		d.record(command)
+		write(command + "\r\n")
+		switch {
+		case command == "exit":
+			return
+		case command == "enable" && d.os == IOSXE:
+			write("Password: ")
+			password, ok := readLine()
+			if !ok {
+				return
+			}
+			write("\r\n")
+			if password == d.enablePassword {
+				prompt = strings.TrimSuffix(prompt, ">") + "#"
+			} else {
+				write("% Access denied\r\n")
+			}
+		case command == pagingCommands[d.os]:
+			paging = d.ignorePaging
+		case command == showCommands[d.os] && strings.HasSuffix(prompt, ">") && d.os == IOSXE:
+			write("                    ^\r\n% Invalid input detected at '^' marker.\r\n")
+		case command == showCommands[d.os]:
+			for i, line := range strings.Split(strings.TrimRight(d.config, "\n"), "\n") {
+				if paging && i > 0 && i%20 == 0 {
+					write(" --More-- ")
+					if _, err := in.ReadByte(); err != nil {
+						return
+					}
+				}
+				write(line + "\r\n")
+			}
+		default:
+			write("% Invalid input\r\n")
+		}
+		write(prompt)
+	}
+}
+
+func fixture(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func options(t *testing.T, d *device) Options {
+	return Options{HostKeyCallback: ssh.FixedHostKey(d.hostKey), Timeout: 5 * time.Second, Dir: t.TempDir()}
+}
+
+func TestFetchIOSXEWithEnable(t *testing.T) {
+	d := &device{os: IOSXE, prompt: "cat8k>", password: "s3cret", enablePassword: "en4ble", config: fixture(t, "../../iosxe/cat8k.cfg")}
+	addr := d.start(t)
+	path, err := Fetch(Target{Address: addr, OS: IOSXE, User: "admin", Password: "s3cret", EnablePassword: "en4ble"}, options(t, d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fixture(t, path)
+	if strings.TrimSpace(got) != strings.TrimSpace(d.config) {
+		t.Errorf("stored configuration differs from the device's")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("stored configuration mode %v, want 0600", info.Mode().Perm())
+	}
+	if !strings.HasPrefix(filepath.Base(path), "127.0.0.1_") {
+		t.Errorf("file name %s does not start with the host", filepath.Base(path))
+	}
+	want := []string{"enable", "terminal length 0", "show running-config", "exit"}
+	if got := d.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands %q, want %q", got, want)
+	}
+	config, err := cisco.ParseConfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.OS != cisco.IOSXE {
+		t.Errorf("stored configuration detected as %s", config.OS)
+	}
+}
+
+func TestFetchCisco(t *testing.T) {
+	for _, tt := range []struct {
+		os, prompt, fixture string
+	}{
+		{IOSXR, "RP/0/RSP0/CPU0:asr9k#", "../../iosxr/asr9k.cfg"},
+		{NXOS, "n9k#", "../../nxos/n9k.cfg"},
+	} {
+		d := &device{os: tt.os, prompt: tt.prompt, password: "s3cret", config: fixture(t, tt.fixture)}
+		addr := d.start(t)
+		path, err := Fetch(Target{Address: addr, OS: tt.os, User: "admin", Password: "s3cret"}, options(t, d))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.os, err)
+		}
+		config, err := cisco.ParseConfig(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.OS != tt.os {
+			t.Errorf("%s: stored configuration detected as %s", tt.os, config.OS)
+		}
+		if got := fixture(t, path); strings.TrimSpace(got) != strings.TrimSpace(d.config) {
+			t.Errorf("%s: stored configuration differs from the device's", tt.os)
+		}
+	}
+}
+
+func TestFetchJunosWithKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &device{os: Junos, prompt: "admin@jsw> ", authorizedKey: signer.PublicKey(), config: fixture(t, "../../junos/jsw.cfg")}
+	addr := d.start(t)
+	path, err := Fetch(Target{Address: addr, OS: Junos, User: "admin", KeyFile: keyFile}, options(t, d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := junos.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := config.Resolve().Value("system", "host-name"); name != "jsw" {
+		t.Errorf("host-name %q, want jsw", name)
+	}
+	want := []string{"set cli screen-length 0", "show configuration | display set", "exit"}
+	if got := d.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands %q, want %q", got, want)
+	}
+}
+
+func TestFetchAnswersPager(t *testing.T) {
+	d := &device{os: NXOS, prompt: "n9k#", password: "s3cret", config: fixture(t, "../../nxos/n9k.cfg"), ignorePaging: true}
+	addr := d.start(t)
+	config, err := Run(Target{Address: addr, OS: NXOS, User: "admin", Password: "s3cret"}, options(t, d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), "--More--") {
+		t.Error("pager prompt left in the configuration")
+	}
+	if strings.TrimSpace(string(config)) != strings.TrimSpace(d.config) {
+		t.Error("paged configuration differs from the device's")
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	d := &device{os: IOSXE, prompt: "cat8k>", password: "s3cret", enablePassword: "en4ble", config: "hostname cat8k\n"}
+	addr := d.start(t)
+	opts := options(t, d)
+	for name, tt := range map[string]struct {
+		target Target
+		opts   Options
+	}{
+		"wrong password":       {Target{Address: addr, OS: IOSXE, User: "admin", Password: "wrong"}, opts},
+		"no credentials":       {Target{Address: addr, OS: IOSXE, User: "admin"}, opts},
+		"unknown OS":           {Target{Address: addr, OS: "eos", User: "admin", Password: "s3cret"}, opts},
+		"no enable password":   {Target{Address: addr, OS: IOSXE, User: "admin", Password: "s3cret"}, opts},
+		"wrong enable":         {Target{Address: addr, OS: IOSXE, User: "admin", Password: "s3cret", EnablePassword: "nope"}, opts},
+		"unexpected host key":  {Target{Address: addr, OS: IOSXE, User: "admin", Password: "s3cret", EnablePassword: "en4ble"}, Options{HostKeyCallback: ssh.FixedHostKey(newSigner(t).PublicKey()), Timeout: time.Second}},
+		"no host key callback": {Target{Address: addr, OS: IOSXE, User: "admin", Password: "s3cret"}, Options{}},
+	} {
+		if _, err := Run(tt.target, tt.opts); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
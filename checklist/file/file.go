@@ -11,17 +11,21 @@ func Files(folders []string) string {
 	slices.Sort(folders)
 	var result string
 	for _, folder := range folders {
-		files := getFiles(folder)
+		files, err := getFiles(folder)
+		if err != nil {
+			result += fmt.Sprintf("%q: error: %v\n", folder, err)
+			continue
+		}
 		slices.Sort(files)
 		result += fmt.Sprintf(`"%s": %s\n`, folder, strings.Join(files, ", "))
 	}
 	return result
 }
 
-func getFiles(folder string) []string {
+func getFiles(folder string) ([]string, error) {
 	entry, err := os.ReadDir(folder)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	var files []string
 	for _, e := range entry {
@@ -30,5 +34,5 @@ func getFiles(folder string) []string {
 		}
 		files = append(files, e.Name())
 	}
-	return files
+	return files, nil
 }
@@ -0,0 +1,301 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	FindingWorldWritable    = "world-writable"
+	FindingSUID             = "suid"
+	FindingSGID             = "sgid"
+	FindingUnowned          = "unowned"
+	FindingUngrouped        = "ungrouped"
+	FindingDirWithoutSticky = "world-writable-dir-without-sticky"
+)
+
+// Options controls how Inventory walks each folder.
+type Options struct {
+	// MaxDepth limits recursion below each folder; a negative value means
+	// unlimited and 0 lists only the folder's immediate entries.
+	MaxDepth int
+	// Excludes are glob patterns matched against the full path and the base
+	// name; matching directories are not descended into.
+	Excludes []string
+	// FollowSymlinks descends into symlinked directories and reports the
+	// target's metadata instead of the link's.
+	FollowSymlinks bool
+	// OneFilesystem stays on the device of each starting folder, like find -xdev.
+	OneFilesystem bool
+}
+
+// DefaultOptions walks the whole tree without following symlinks.
+func DefaultOptions() Options {
+	return Options{MaxDepth: -1}
+}
+
+// Entry is one file system object found during the inventory.
+type Entry struct {
+	Path     string
+	Type     string
+	Mode     fs.FileMode
+	Size     int64
+	Owner    string
+	Group    string
+	ModTime  time.Time
+	Target   string
+	Findings []string
+}
+
+type inventory struct {
+	opts    Options
+	entries []Entry
+	errors  []string
+	visited map[fileID]struct{}
+	names   *ownerNames
+}
+
+// Inventory walks folders recursively and returns one line per entry with its
+// metadata, followed by the security findings and any read errors.
+func Inventory(folders []string, opts Options) string {
+	slices.Sort(folders)
+	var result strings.Builder
+	for _, folder := range folders {
+		inv := &inventory{
+			opts:    opts,
+			visited: make(map[fileID]struct{}),
+			names:   newOwnerNames(),
+		}
+		inv.walk(folder)
+
+		fmt.Fprintf(&result, "%q:\n", folder)
+		var findings []string
+		for _, e := range inv.entries {
+			result.WriteString(formatEntry(e))
+			for _, f := range e.Findings {
+				findings = append(findings, fmt.Sprintf("%s: %s", f, e.Path))
+			}
+		}
+		slices.Sort(findings)
+		result.WriteString("****findings****\n")
+		for _, f := range findings {
+			result.WriteString(f + "\n")
+		}
+		if len(inv.errors) > 0 {
+			result.WriteString("****errors****\n")
+			for _, e := range inv.errors {
+				result.WriteString(e + "\n")
+			}
+		}
+	}
+	return result.String()
+}
+
+func (inv *inventory) walk(root string) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		inv.errors = append(inv.errors, fmt.Sprintf("%s: %v", root, err))
+		return
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		// The folder itself was named explicitly, so always resolve it.
+		if info, err = os.Stat(root); err != nil {
+			inv.errors = append(inv.errors, fmt.Sprintf("%s: %v", root, err))
+			return
+		}
+	}
+	rootDev, _ := deviceOf(info)
+	if info.IsDir() {
+		if id, ok := idOf(info); ok {
+			inv.visited[id] = struct{}{}
+		}
+		inv.walkDir(root, 0, rootDev)
+		return
+	}
+	inv.add(root, info, "")
+}
+
+func (inv *inventory) walkDir(dir string, depth int, rootDev uint64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		inv.errors = append(inv.errors, fmt.Sprintf("%s: %v", dir, err))
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if inv.excluded(path) {
+			continue
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			inv.errors = append(inv.errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		var target string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, _ = os.Readlink(path)
+			if inv.opts.FollowSymlinks {
+				resolved, err := os.Stat(path)
+				if err != nil {
+					inv.errors = append(inv.errors, fmt.Sprintf("%s: %v", path, err))
+					inv.add(path, info, target)
+					continue
+				}
+				info = resolved
+			}
+		}
+		inv.add(path, info, target)
+
+		if !info.IsDir() {
+			continue
+		}
+		if inv.opts.MaxDepth >= 0 && depth >= inv.opts.MaxDepth {
+			continue
+		}
+		if dev, ok := deviceOf(info); ok && inv.opts.OneFilesystem && dev != rootDev {
+			continue
+		}
+		if id, ok := idOf(info); ok {
+			if _, seen := inv.visited[id]; seen {
+				continue
+			}
+			inv.visited[id] = struct{}{}
+		}
+		inv.walkDir(path, depth+1, rootDev)
+	}
+}
+
+func (inv *inventory) excluded(path string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range inv.opts.Excludes {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (inv *inventory) add(path string, info fs.FileInfo, target string) {
+	e := Entry{
+		Path:    path,
+		Type:    fileType(info.Mode()),
+		Mode:    info.Mode(),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Target:  target,
+	}
+	uid, gid, ok := ownerOf(info)
+	if ok {
+		var known bool
+		if e.Owner, known = inv.names.user(uid); !known {
+			e.Findings = append(e.Findings, FindingUnowned)
+		}
+		if e.Group, known = inv.names.group(gid); !known {
+			e.Findings = append(e.Findings, FindingUngrouped)
+		}
+	}
+	e.Findings = append(e.Findings, modeFindings(info.Mode())...)
+	inv.entries = append(inv.entries, e)
+}
+
+func modeFindings(mode fs.FileMode) []string {
+	var findings []string
+	worldWritable := mode.Perm()&0o002 != 0
+	switch {
+	case mode.IsDir():
+		if worldWritable && mode&fs.ModeSticky == 0 {
+			findings = append(findings, FindingDirWithoutSticky)
+		}
+	case mode.IsRegular():
+		if worldWritable {
+			findings = append(findings, FindingWorldWritable)
+		}
+		if mode&fs.ModeSetuid != 0 {
+			findings = append(findings, FindingSUID)
+		}
+		if mode&fs.ModeSetgid != 0 {
+			findings = append(findings, FindingSGID)
+		}
+	}
+	return findings
+}
+
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "char-device"
+	case mode&fs.ModeDevice != 0:
+		return "block-device"
+	}
+	return "other"
+}
+
+func formatEntry(e Entry) string {
+	line := fmt.Sprintf("%s %s %s %d %s:%s %s", e.Path, e.Type, e.Mode, e.Size, e.Owner, e.Group, e.ModTime.UTC().Format(time.RFC3339))
+	if e.Target != "" {
+		line += " -> " + e.Target
+	}
+	if len(e.Findings) > 0 {
+		line += " [" + strings.Join(e.Findings, ", ") + "]"
+	}
+	return line + "\n"
+}
+
+// ownerNames caches uid and gid lookups, which hit NSS on every call.
+type ownerNames struct {
+	users  map[string]ownerName
+	groups map[string]ownerName
+}
+
+type ownerName struct {
+	name  string
+	known bool
+}
+
+func newOwnerNames() *ownerNames {
+	return &ownerNames{users: make(map[string]ownerName), groups: make(map[string]ownerName)}
+}
+
+func (n *ownerNames) user(uid string) (string, bool) {
+	o, ok := n.users[uid]
+	if !ok {
+		o = ownerName{name: uid}
+		if u, err := user.LookupId(uid); err == nil {
+			o = ownerName{name: u.Username, known: true}
+		}
+		n.users[uid] = o
+	}
+	return o.name, o.known
+}
+
+func (n *ownerNames) group(gid string) (string, bool) {
+	o, ok := n.groups[gid]
+	if !ok {
+		o = ownerName{name: gid}
+		if g, err := user.LookupGroupId(gid); err == nil {
+			o = ownerName{name: g.Name, known: true}
+		}
+		n.groups[gid] = o
+	}
+	return o.name, o.known
+}
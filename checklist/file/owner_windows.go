@@ -0,0 +1,18 @@
+package file
+
+import "io/fs"
+
+// fileID is unused on Windows, where FileInfo carries no inode number.
+type fileID struct{}
+
+func ownerOf(info fs.FileInfo) (string, string, bool) {
+	return "", "", false
+}
+
+func deviceOf(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+func idOf(info fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -1,38 +1,254 @@
 package filechecksum
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"runtime"
 	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	ErrMissing          = "missing"
+	ErrPermissionDenied = "permission denied"
+	ErrNotRegular       = "not a regular file"
 )
 
-func Checksums(files []string) string {
-	slices.Sort(files)
+var hashers = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+	"blake2b": func() hash.Hash {
+		h, _ := blake2b.New512(nil)
+		return h
+	},
+}
+
+// Options selects the digests to compute and how many files to hash at once.
+type Options struct {
+	Algorithms []string
+	Workers    int
+}
+
+// DefaultOptions hashes with SHA-256 using one worker per CPU.
+func DefaultOptions() Options {
+	return Options{Algorithms: []string{"sha256"}, Workers: runtime.NumCPU()}
+}
+
+// Result is the outcome of hashing one file. Err is set instead of Sums when
+// the file could not be hashed.
+type Result struct {
+	Path string
+	Sums map[string]string
+	Err  string
+}
+
+// Checksums expands directories and glob patterns in files and hashes every
+// regular file found. Files that cannot be hashed are reported with the
+// reason rather than skipped.
+func Checksums(files []string, opts Options) (string, error) {
+	algorithms, err := normalizeAlgorithms(opts.Algorithms)
+	if err != nil {
+		return "", err
+	}
+	opts.Algorithms = algorithms
+	results, err := Compute(files, opts)
+	if err != nil {
+		return "", err
+	}
 	var result string
-	for _, file := range files {
-		checksum, err := calculateSHA256(file)
-		if err != nil {
+	for _, r := range results {
+		if r.Err != "" {
+			result += fmt.Sprintf("%q: error: %s\n", r.Path, r.Err)
 			continue
-		} else {
-			result += fmt.Sprintf(`"%s": %s\n`, file, checksum)
 		}
+		result += fmt.Sprintf("%q: %s\n", r.Path, formatSums(r.Sums, algorithms))
 	}
-	return result
+	return result, nil
+}
+
+// Compute returns one Result per file, sorted by path.
+func Compute(files []string, opts Options) ([]Result, error) {
+	paths, results := expand(files)
+	return hashAll(paths, results, opts)
+}
+
+// Hash is Compute for paths that are already resolved, such as those from a
+// directory walk: each path is hashed as named, without glob expansion.
+func Hash(paths []string, opts Options) ([]Result, error) {
+	return hashAll(paths, nil, opts)
 }
 
-func calculateSHA256(filePath string) (string, error) {
-	f, err := os.Open(filePath)
+// hashAll hashes paths and returns them with results, sorted by path.
+func hashAll(paths []string, results []Result, opts Options) ([]Result, error) {
+	algorithms, err := normalizeAlgorithms(opts.Algorithms)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, workers)
+	)
+	for _, path := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r := hashFile(path, algorithms)
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(results, func(a, b Result) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return results, nil
+}
+
+// expand resolves directories and glob patterns into file paths. A path
+// that exists is taken literally even if it holds glob characters, so
+// /usr/bin/[ is hashed rather than read as a pattern. Inputs that resolve
+// to nothing become error results straight away.
+func expand(files []string) ([]string, []Result) {
+	var (
+		paths   []string
+		results []Result
+	)
+	seen := make(map[string]struct{})
+	add := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+
+	for _, file := range files {
+		matches := []string{file}
+		if _, err := os.Lstat(file); err != nil && strings.ContainsAny(file, "*?[") {
+			var err error
+			matches, err = filepath.Glob(file)
+			if err != nil || len(matches) == 0 {
+				results = append(results, Result{Path: file, Err: ErrMissing})
+				continue
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				// hashFile reports the precise error for these.
+				add(match)
+				continue
+			}
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					results = append(results, Result{Path: path, Err: classify(err)})
+					return nil
+				}
+				if d.Type().IsRegular() {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				results = append(results, Result{Path: match, Err: classify(err)})
+			}
+		}
+	}
+	return paths, results
+}
+
+func hashFile(path string, algorithms []string) Result {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Result{Path: path, Err: classify(err)}
+	}
+	if !info.Mode().IsRegular() {
+		return Result{Path: path, Err: ErrNotRegular}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{Path: path, Err: classify(err)}
 	}
 	defer f.Close()
 
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		return "", err
+	hs := make([]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, len(algorithms))
+	for i, algorithm := range algorithms {
+		hs[i] = hashers[algorithm]()
+		writers[i] = hs[i]
 	}
+	if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+		return Result{Path: path, Err: classify(err)}
+	}
+
+	sums := make(map[string]string, len(algorithms))
+	for i, algorithm := range algorithms {
+		sums[algorithm] = fmt.Sprintf("%x", hs[i].Sum(nil))
+	}
+	return Result{Path: path, Sums: sums}
+}
 
-	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+func classify(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrMissing
+	case errors.Is(err, fs.ErrPermission):
+		return ErrPermissionDenied
+	}
+	return err.Error()
+}
+
+func normalizeAlgorithms(algorithms []string) ([]string, error) {
+	if len(algorithms) == 0 {
+		return []string{"sha256"}, nil
+	}
+	normalized := make([]string, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		algorithm = strings.ToLower(strings.ReplaceAll(algorithm, "-", ""))
+		if _, ok := hashers[algorithm]; !ok {
+			return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+		}
+		if !slices.Contains(normalized, algorithm) {
+			normalized = append(normalized, algorithm)
+		}
+	}
+	return normalized, nil
+}
+
+// formatSums prints a bare digest for a single algorithm, keeping the
+// original output, and name:digest pairs otherwise.
+func formatSums(sums map[string]string, algorithms []string) string {
+	if len(algorithms) == 1 {
+		return sums[algorithms[0]]
+	}
+	parts := make([]string, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		parts = append(parts, fmt.Sprintf("%s:%s", algorithm, sums[algorithm]))
+	}
+	return strings.Join(parts, ", ")
 }
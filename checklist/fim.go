@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"checklist/fim"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fimDB       string
+	fimKey      string
+	fimPaths    []string
+	fimExcludes []string
+)
+
+var fimCmd = &cobra.Command{
+	Use:   "fim",
+	Short: "Build and verify a file integrity database",
+}
+
+var fimInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Record a signed integrity baseline for the configured paths",
+	Args:  cobra.NoArgs,
+	RunE:  runFimInit,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+var fimCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Compare the configured paths against the integrity baseline",
+	Args:  cobra.NoArgs,
+	RunE:  runFimCheck,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	fimCmd.PersistentFlags().StringVar(&fimDB, "db", "/var/lib/checklist/fim.db", "integrity database file")
+	fimCmd.PersistentFlags().StringVar(&fimKey, "key", "/etc/checklist/fim.key", "key used to sign the integrity database")
+	fimInitCmd.Flags().StringSliceVar(&fimPaths, "paths", fim.DefaultPaths, "paths to record")
+	fimInitCmd.Flags().StringSliceVar(&fimExcludes, "exclude", []string{}, "glob patterns to leave out of the database")
+	fimCmd.AddCommand(fimInitCmd, fimCheckCmd)
+	rootCmd.AddCommand(fimCmd)
+}
+
+func runFimInit(cmd *cobra.Command, args []string) error {
+	key, err := fim.LoadKey(fimKey, true)
+	if err != nil {
+		return err
+	}
+	db, err := fim.Build(fimPaths, fimExcludes)
+	if err != nil {
+		return err
+	}
+	if err = fim.Save(fimDB, db, key); err != nil {
+		return err
+	}
+	fmt.Print(fim.FormatErrors(db.Errors))
+	fmt.Printf("recorded %d entries in %s\n", len(db.Entries), fimDB)
+	return nil
+}
+
+func runFimCheck(cmd *cobra.Command, args []string) error {
+	key, err := fim.LoadKey(fimKey, false)
+	if err != nil {
+		return err
+	}
+	db, err := fim.Load(fimDB, key)
+	if err != nil {
+		return err
+	}
+	current, err := fim.Build(db.Paths, db.Excludes)
+	if err != nil {
+		return err
+	}
+	fmt.Print(fim.FormatChanges(fim.Compare(db, current)))
+	fmt.Print(fim.FormatErrors(current.Errors))
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package fim
+
+import (
+	"encoding/base64"
+	"io/fs"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func ownership(info fs.FileInfo) (string, string, uint64) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", "", 0
+	}
+	return strconv.FormatUint(uint64(st.Uid), 10), strconv.FormatUint(uint64(st.Gid), 10), uint64(st.Ino)
+}
+
+// xattrs returns the extended attributes of path without following symlinks.
+// Values are base64 encoded since SELinux labels and capabilities are binary.
+func xattrs(path string) map[string]string {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	size, err = unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil
+	}
+	attrs := make(map[string]string)
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+		vsize, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, vsize)
+		if vsize > 0 {
+			if vsize, err = unix.Lgetxattr(path, name, value); err != nil {
+				continue
+			}
+		}
+		attrs[name] = base64.StdEncoding.EncodeToString(value[:vsize])
+	}
+	return attrs
+}
@@ -0,0 +1,362 @@
+package fim
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"checklist/filechecksum"
+)
+
+const (
+	databaseVersion = 1
+	keySize         = 32
+)
+
+// DefaultPaths mirrors the directories AIDE watches in its stock configuration.
+var DefaultPaths = []string{
+	"/bin",
+	"/sbin",
+	"/usr/bin",
+	"/usr/sbin",
+	"/lib/modules",
+	"/etc",
+	"/boot",
+}
+
+// Entry holds the attributes recorded for one path.
+type Entry struct {
+	Path   string            `json:"path"`
+	Type   string            `json:"type"`
+	SHA256 string            `json:"sha256,omitempty"`
+	Mode   string            `json:"mode"`
+	UID    string            `json:"uid,omitempty"`
+	GID    string            `json:"gid,omitempty"`
+	Inode  uint64            `json:"inode,omitempty"`
+	Size   int64             `json:"size"`
+	MTime  time.Time         `json:"mtime"`
+	Target string            `json:"target,omitempty"`
+	Xattrs map[string]string `json:"xattrs,omitempty"`
+}
+
+// Database is the integrity baseline recorded by "checklist fim init".
+type Database struct {
+	Version  int              `json:"version"`
+	Created  time.Time        `json:"created"`
+	Paths    []string         `json:"paths"`
+	Excludes []string         `json:"excludes,omitempty"`
+	Entries  map[string]Entry `json:"entries"`
+
+	// Errors holds the paths that could not be read during the scan and
+	// why. They are reported, not recorded in the baseline.
+	Errors map[string]string `json:"-"`
+}
+
+type signedDatabase struct {
+	Signature string          `json:"signature"`
+	Database  json.RawMessage `json:"database"`
+}
+
+// Change is one difference between the baseline and the current state.
+type Change struct {
+	Path       string
+	Kind       string
+	Attributes []string
+}
+
+// Build scans paths and records every file, directory and symlink below them.
+func Build(paths, excludes []string) (*Database, error) {
+	db := &Database{
+		Version:  databaseVersion,
+		Created:  time.Now().UTC(),
+		Paths:    slices.Clone(paths),
+		Excludes: slices.Clone(excludes),
+		Entries:  make(map[string]Entry),
+		Errors:   make(map[string]string),
+	}
+
+	var regular []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) || path != root {
+					db.Errors[path] = err.Error()
+				}
+				// A directory that cannot be read was recorded when it was
+				// first visited; skip its contents and keep walking.
+				return nil
+			}
+			if excluded(path, excludes) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := os.Lstat(path)
+			if err != nil {
+				db.Errors[path] = err.Error()
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			e := newEntry(path, info)
+			db.Entries[path] = e
+			if info.Mode().IsRegular() {
+				regular = append(regular, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+
+	opts := filechecksum.DefaultOptions()
+	results, err := filechecksum.Hash(regular, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		e, ok := db.Entries[r.Path]
+		if !ok {
+			continue
+		}
+		if r.Err != "" {
+			db.Errors[r.Path] = r.Err
+			continue
+		}
+		e.SHA256 = r.Sums["sha256"]
+		db.Entries[r.Path] = e
+	}
+	return db, nil
+}
+
+func newEntry(path string, info fs.FileInfo) Entry {
+	e := Entry{
+		Path:  path,
+		Type:  entryType(info.Mode()),
+		Mode:  info.Mode().String(),
+		Size:  info.Size(),
+		MTime: info.ModTime().UTC(),
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		e.Target, _ = os.Readlink(path)
+	}
+	e.UID, e.GID, e.Inode = ownership(info)
+	e.Xattrs = xattrs(path)
+	return e
+}
+
+// Compare returns the changes from db to current, sorted by path.
+func Compare(db, current *Database) []Change {
+	var changes []Change
+	for path, old := range db.Entries {
+		cur, ok := current.Entries[path]
+		if !ok {
+			changes = append(changes, Change{Path: path, Kind: "removed"})
+			continue
+		}
+		if _, failed := current.Errors[path]; failed && cur.SHA256 == "" {
+			// The file could not be hashed this time; it is listed with
+			// the scan errors rather than as a changed checksum.
+			cur.SHA256 = old.SHA256
+		}
+		if attrs := changedAttributes(old, cur); len(attrs) > 0 {
+			changes = append(changes, Change{Path: path, Kind: "modified", Attributes: attrs})
+		}
+	}
+	for path := range current.Entries {
+		if _, ok := db.Entries[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: "added"})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+func changedAttributes(old, cur Entry) []string {
+	var attrs []string
+	if old.Type != cur.Type {
+		attrs = append(attrs, "type")
+	}
+	if old.SHA256 != cur.SHA256 {
+		attrs = append(attrs, "sha256")
+	}
+	if old.Mode != cur.Mode {
+		attrs = append(attrs, "mode")
+	}
+	if old.UID != cur.UID {
+		attrs = append(attrs, "uid")
+	}
+	if old.GID != cur.GID {
+		attrs = append(attrs, "gid")
+	}
+	if old.Inode != cur.Inode {
+		attrs = append(attrs, "inode")
+	}
+	if old.Size != cur.Size {
+		attrs = append(attrs, "size")
+	}
+	if !old.MTime.Equal(cur.MTime) {
+		attrs = append(attrs, "mtime")
+	}
+	if old.Target != cur.Target {
+		attrs = append(attrs, "target")
+	}
+	if !maps.Equal(old.Xattrs, cur.Xattrs) {
+		attrs = append(attrs, "xattrs")
+	}
+	return attrs
+}
+
+// Save writes db to path signed with an HMAC-SHA256 of key.
+func Save(path string, db *Database, key []byte) error {
+	body, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(signedDatabase{Signature: sign(body, key), Database: body})
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+// Load reads a database written by Save and verifies its signature.
+func Load(path string, key []byte) (*Database, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var signed signedDatabase
+	if err = json.Unmarshal(content, &signed); err != nil {
+		return nil, fmt.Errorf("failed to parse integrity database: %w", err)
+	}
+	want, err := hex.DecodeString(signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integrity database signature: %w", err)
+	}
+	got, _ := hex.DecodeString(sign(signed.Database, key))
+	if !hmac.Equal(want, got) {
+		return nil, errors.New("integrity database signature mismatch")
+	}
+	var db Database
+	if err = json.Unmarshal(signed.Database, &db); err != nil {
+		return nil, fmt.Errorf("failed to parse integrity database: %w", err)
+	}
+	return &db, nil
+}
+
+// LoadKey reads the signing key at path. When create is set and the file does
+// not exist a new random key is written there.
+func LoadKey(path string, create bool) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) == 0 {
+			return nil, fmt.Errorf("signing key %s is empty", path)
+		}
+		return key, nil
+	}
+	if !create || !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	key = make([]byte, keySize)
+	if _, err = rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, key, 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// FormatChanges renders changes in the checklist text layout.
+func FormatChanges(changes []Change) string {
+	var added, removed, modified []string
+	for _, c := range changes {
+		switch c.Kind {
+		case "added":
+			added = append(added, c.Path)
+		case "removed":
+			removed = append(removed, c.Path)
+		case "modified":
+			modified = append(modified, fmt.Sprintf("%s (%s)", c.Path, strings.Join(c.Attributes, ", ")))
+		}
+	}
+	result := fmt.Sprintf("****added: %d****\n", len(added))
+	for _, p := range added {
+		result += p + "\n"
+	}
+	result += fmt.Sprintf("****removed: %d****\n", len(removed))
+	for _, p := range removed {
+		result += p + "\n"
+	}
+	result += fmt.Sprintf("****modified: %d****\n", len(modified))
+	for _, p := range modified {
+		result += p + "\n"
+	}
+	return result
+}
+
+// FormatErrors renders the paths a scan could not read, sorted by path.
+func FormatErrors(errs map[string]string) string {
+	if len(errs) == 0 {
+		return ""
+	}
+	result := fmt.Sprintf("****errors: %d****\n", len(errs))
+	for _, path := range slices.Sorted(maps.Keys(errs)) {
+		result += fmt.Sprintf("%s: %s\n", path, errs[path])
+	}
+	return result
+}
+
+func sign(body, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func excluded(path string, excludes []string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range excludes {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func entryType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "dir"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	}
+	return "other"
+}
@@ -0,0 +1,305 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"checklist/check"
+	"checklist/cisco"
+	"checklist/fetch"
+	"checklist/junos"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Cisco   = "cisco"
+	Juniper = "juniper"
+)
+
+// Device is one network device in the inventory. Config is audited when
+// set; otherwise the configuration is fetched from Address over SSH. OS
+// is iosxe, iosxr or nxos for Cisco and may be left out when Config is
+// set, as it is detected from the file.
+type Device struct {
+	Hostname string   `yaml:"hostname"`
+	Vendor   string   `yaml:"vendor"`
+	OS       string   `yaml:"os,omitempty"`
+	Config   string   `yaml:"config,omitempty"`
+	Address  string   `yaml:"address,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+}
+
+// Inventory is the list of devices, as in:
+//
+//	devices:
+//	  - hostname: core1
+//	    vendor: cisco
+//	    config: configs/core1.cfg
+//	    tags: [core, hanoi]
+//	  - hostname: edge1
+//	    vendor: juniper
+//	    address: 10.0.0.2
+type Inventory struct {
+	Devices []Device `yaml:"devices"`
+}
+
+// Load reads an inventory file. Relative config paths are taken from the
+// file's directory.
+func Load(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, d := range inv.Devices {
+		if d.Config != "" && !filepath.IsAbs(d.Config) {
+			inv.Devices[i].Config = filepath.Join(filepath.Dir(path), d.Config)
+		}
+	}
+	return inv, nil
+}
+
+// Parse reads an inventory from YAML and rejects entries that cannot be
+// audited.
+func Parse(content []byte) (*Inventory, error) {
+	var inv Inventory
+	if err := yaml.Unmarshal(content, &inv); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+	var problems []string
+	seen := make(map[string]bool)
+	for i, d := range inv.Devices {
+		where := fmt.Sprintf("device %d", i+1)
+		if d.Hostname != "" {
+			where = d.Hostname
+		}
+		switch {
+		case d.Hostname == "":
+			problems = append(problems, where+": hostname is missing")
+		case seen[d.Hostname]:
+			problems = append(problems, where+": hostname is listed twice")
+		}
+		seen[d.Hostname] = true
+		switch d.Vendor {
+		case Cisco:
+			if d.OS != "" && d.OS != cisco.IOSXE && d.OS != cisco.IOSXR && d.OS != cisco.NXOS {
+				problems = append(problems, fmt.Sprintf("%s: unknown Cisco os %q", where, d.OS))
+			}
+			if d.Config == "" && d.OS == "" {
+				problems = append(problems, where+": os is needed to fetch a Cisco configuration")
+			}
+		case Juniper:
+			if d.OS != "" && d.OS != fetch.Junos {
+				problems = append(problems, fmt.Sprintf("%s: unknown Juniper os %q", where, d.OS))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown vendor %q, want %s or %s", where, d.Vendor, Cisco, Juniper))
+		}
+		if d.Config == "" && d.Address == "" {
+			problems = append(problems, where+": neither config nor address is set")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return &inv, nil
+}
+
+// Select returns the devices carrying any of tags, or all of them when
+// tags is empty.
+func (inv *Inventory) Select(tags []string) []Device {
+	if len(tags) == 0 {
+		return inv.Devices
+	}
+	var devices []Device
+	for _, d := range inv.Devices {
+		for _, t := range tags {
+			if slices.Contains(d.Tags, t) {
+				devices = append(devices, d)
+				break
+			}
+		}
+	}
+	return devices
+}
+
+// Options control how devices are audited. SIEM overrides each vendor's
+// default syslog collectors. Target holds the credentials and Fetch the
+// SSH settings used for devices without a config.
+type Options struct {
+	Workers int
+	SIEM    []string
+	Target  fetch.Target
+	Fetch   fetch.Options
+}
+
+// Report is the audit of one device. Err is set when the configuration
+// could not be fetched or parsed.
+type Report struct {
+	Device  Device
+	OS      string
+	Path    string
+	Results []check.Result
+	Err     error
+}
+
+// Passed counts the checks that passed.
+func (r Report) Passed() int {
+	var passed int
+	for _, res := range r.Results {
+		if res.Pass() {
+			passed++
+		}
+	}
+	return passed
+}
+
+// Audit checks every device, running up to opts.Workers at a time, and
+// returns the reports in the order of devices.
+func Audit(devices []Device, opts Options) []Report {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	reports := make([]Report, len(devices))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, workers)
+	)
+	for i, d := range devices {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			reports[i] = audit(d, opts)
+		}()
+	}
+	wg.Wait()
+	return reports
+}
+
+// audit checks one device. A panic in a parser or check becomes the
+// device's Err, so one odd configuration cannot take down the whole run.
+func audit(d Device, opts Options) (r Report) {
+	r = Report{Device: d, OS: d.OS, Path: d.Config}
+	defer func() {
+		if p := recover(); p != nil {
+			r.Results = nil
+			r.Err = fmt.Errorf("audit failed: %v", p)
+		}
+	}()
+	if d.Vendor == Juniper {
+		r.OS = fetch.Junos
+	}
+	if r.Path == "" {
+		target := opts.Target
+		target.Address, target.OS = d.Address, r.OS
+		if r.Path, r.Err = fetch.Fetch(target, opts.Fetch); r.Err != nil {
+			return r
+		}
+	}
+	if d.Vendor == Juniper {
+		config, err := junos.Parse(r.Path)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		siem := opts.SIEM
+		if len(siem) == 0 {
+			siem = junos.DefaultSIEM
+		}
+		r.Results = junos.Audit(config, junos.Options{SIEM: siem})
+		return r
+	}
+	config, err := cisco.ParseConfig(r.Path, d.OS)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.OS = config.OS
+	r.Results = cisco.Audit(config, cisco.Options{SIEM: opts.SIEM})
+	return r
+}
+
+var osOrder = []string{cisco.IOSXE, cisco.IOSXR, cisco.NXOS, fetch.Junos}
+
+// Matrix renders one table per OS with a row per device and a column per
+// check, then the devices that could not be audited and the share of
+// checks passed across the fleet.
+func Matrix(reports []Report) string {
+	var (
+		result    string
+		passed    int
+		total     int
+		compliant int
+		failed    []Report
+	)
+	width := len("device")
+	for _, r := range reports {
+		width = max(width, len(r.Device.Hostname))
+	}
+	for _, osName := range osOrder {
+		var rows []Report
+		for _, r := range reports {
+			if r.Err == nil && r.OS == osName {
+				rows = append(rows, r)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		result += fmt.Sprintf("****%s****\n", osName)
+		result += fmt.Sprintf("%-*s", width, "device")
+		for _, res := range rows[0].Results {
+			result += " " + res.ID
+		}
+		result += " passed\n"
+		for _, r := range rows {
+			result += fmt.Sprintf("%-*s", width, r.Device.Hostname)
+			for _, res := range r.Results {
+				mark := "-"
+				if res.Pass() {
+					mark = "+"
+				}
+				result += fmt.Sprintf(" %-*s", len(res.ID), mark)
+			}
+			result += fmt.Sprintf(" %d/%d\n", r.Passed(), len(r.Results))
+			passed += r.Passed()
+			total += len(r.Results)
+			if r.Passed() == len(r.Results) {
+				compliant++
+			}
+		}
+	}
+	for _, r := range reports {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		result += "****not audited****\n"
+		for _, r := range failed {
+			result += fmt.Sprintf("-%s: %v\n", r.Device.Hostname, r.Err)
+		}
+	}
+	percent := 0.0
+	if total > 0 {
+		percent = 100 * float64(passed) / float64(total)
+	}
+	// The rate only covers audited devices, so it is printed with how
+	// many were not, which count against compliant devices.
+	result += fmt.Sprintf("fleet compliance: %.1f%% (%d/%d checks on %d audited, %d not audited), compliant devices: %d/%d\n",
+		percent, passed, total, len(reports)-len(failed), len(failed), compliant, len(reports))
+	return result
+}
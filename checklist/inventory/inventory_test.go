@@ -0,0 +1,102 @@
+package inventory
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"checklist/check"
+	"checklist/cisco"
+	"checklist/fetch"
+)
+
+func TestParseRejects(t *testing.T) {
+	for text, want := range map[string]string{
+		"devices:\n  - vendor: cisco\n    config: a.cfg\n":                                                             "device 1: hostname is missing",
+		"devices:\n  - {hostname: a, vendor: cisco, config: a.cfg}\n  - {hostname: a, vendor: cisco, config: b.cfg}\n": "a: hostname is listed twice",
+		"devices:\n  - {hostname: a, vendor: arista, config: a.cfg}\n":                                                 `a: unknown vendor "arista"`,
+		"devices:\n  - {hostname: a, vendor: cisco, os: ios, config: a.cfg}\n":                                         `a: unknown Cisco os "ios"`,
+		"devices:\n  - {hostname: a, vendor: cisco, address: 10.0.0.1}\n":                                              "a: os is needed to fetch a Cisco configuration",
+		"devices:\n  - {hostname: a, vendor: juniper, os: iosxe, address: 10.0.0.1}\n":                                 `a: unknown Juniper os "iosxe"`,
+		"devices:\n  - {hostname: a, vendor: juniper}\n":                                                               "a: neither config nor address is set",
+		"devices: [": "failed to parse inventory",
+	} {
+		_, err := Parse([]byte(text))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got %v, want %q", text, err, want)
+		}
+	}
+	inv, err := Parse([]byte("devices:\n  - {hostname: a, vendor: cisco, os: nxos, address: 10.0.0.1}\n  - {hostname: b, vendor: juniper, config: b.cfg}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Devices) != 2 {
+		t.Errorf("got %d devices, want 2", len(inv.Devices))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	inv := &Inventory{Devices: []Device{
+		{Hostname: "core1", Tags: []string{"core", "hanoi"}},
+		{Hostname: "edge1", Tags: []string{"edge", "hanoi"}},
+		{Hostname: "edge2", Tags: []string{"edge", "hue"}},
+		{Hostname: "lab1"},
+	}}
+	for _, tc := range []struct {
+		tags []string
+		want []string
+	}{
+		{nil, []string{"core1", "edge1", "edge2", "lab1"}},
+		{[]string{"edge"}, []string{"edge1", "edge2"}},
+		{[]string{"core", "hue"}, []string{"core1", "edge2"}},
+		{[]string{"hanoi", "edge"}, []string{"core1", "edge1", "edge2"}},
+		{[]string{"dalat"}, nil},
+	} {
+		var got []string
+		for _, d := range inv.Select(tc.tags) {
+			got = append(got, d.Hostname)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.tags, got, tc.want)
+		}
+	}
+}
+
+func results(marks string) []check.Result {
+	var results []check.Result
+	for i, m := range marks {
+		r := check.Result{ID: string(rune('1' + i))}
+		if m == '-' {
+			r.Findings = []string{"wrong"}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestMatrix(t *testing.T) {
+	reports := []Report{
+		{Device: Device{Hostname: "core1"}, OS: cisco.IOSXE, Results: results("++++")},
+		{Device: Device{Hostname: "core2"}, OS: cisco.IOSXE, Results: results("+-+-")},
+		{Device: Device{Hostname: "edge1"}, OS: fetch.Junos, Results: results("+--")},
+		{Device: Device{Hostname: "lab1"}, Err: errors.New("not reachable")},
+	}
+	got := Matrix(reports)
+	want := "****iosxe****\n" +
+		"device 1 2 3 4 passed\n" +
+		"core1  + + + + 4/4\n" +
+		"core2  + - + - 2/4\n" +
+		"****junos****\n" +
+		"device 1 2 3 passed\n" +
+		"edge1  + - - 1/3\n" +
+		"****not audited****\n" +
+		"-lab1: not reachable\n" +
+		"fleet compliance: 63.6% (7/11 checks on 3 audited, 1 not audited), compliant devices: 1/4\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := Matrix(nil); got != "fleet compliance: 0.0% (0/0 checks on 0 audited, 0 not audited), compliant devices: 0/0\n" {
+		t.Errorf("empty fleet: got %q", got)
+	}
+}
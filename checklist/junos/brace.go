@@ -0,0 +1,323 @@
+package junos
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Parse reads a configuration in either "display set" or the hierarchical
+// curly-brace form, telling them apart by the first statement.
+func Parse(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root *Node
+	if isSet(content) {
+		root, err = ReadSet(bytes.NewReader(content))
+	} else {
+		root, err = ReadBrace(bytes.NewReader(content))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Config{Path: path, Root: root}, nil
+}
+
+// ParseBrace reads a configuration in the form "show configuration" prints.
+func ParseBrace(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	root, err := ReadBrace(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Config{Path: path, Root: root}, nil
+}
+
+// Recognize reports whether content is a JunOS configuration in either
+// form: its first statement is a set command or ends in "{" or ";".
+func Recognize(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "/*") || strings.HasPrefix(text, "!") {
+			continue
+		}
+		verb, _, _ := strings.Cut(text, " ")
+		return verb == "set" || strings.HasSuffix(text, "{") || strings.HasSuffix(text, ";")
+	}
+	return false
+}
+
+func isSet(content []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "/*") {
+			continue
+		}
+		switch verb, _, _ := strings.Cut(text, " "); verb {
+		case "set", "delete", "deactivate", "activate", "protect", "unprotect":
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+type token struct {
+	text   string
+	line   int
+	quoted bool
+}
+
+// punct reports whether the token is one of the structural characters.
+func (t token) punct(c string) bool {
+	return !t.quoted && t.text == c
+}
+
+func tokenize(content string) ([]token, error) {
+	var (
+		tokens []token
+		line   = 1
+	)
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(content[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			var word strings.Builder
+			start := line
+			for i++; ; i++ {
+				if i >= len(content) {
+					return nil, fmt.Errorf("line %d: unterminated quote", start)
+				}
+				if content[i] == '\\' && i+1 < len(content) {
+					i++
+				} else if content[i] == '"' {
+					break
+				}
+				if content[i] == '\n' {
+					line++
+				}
+				word.WriteByte(content[i])
+			}
+			i++
+			tokens = append(tokens, token{text: word.String(), line: start, quoted: true})
+		case strings.IndexByte("{};[]", c) >= 0:
+			tokens = append(tokens, token{text: string(c), line: line})
+			i++
+		default:
+			start := i
+			for i < len(content) && strings.IndexByte(" \t\r\n{};[]\"", content[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, token{text: content[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
+
+// ReadBrace builds the hierarchy from curly-brace configuration. A
+// statement marked "inactive:" or "protect:" sets Inactive or Protected
+// on its last word, as "deactivate" and "protect" do on its path.
+func ReadBrace(r io.Reader) (*Node, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(content))
+	if err != nil {
+		return nil, err
+	}
+	root := &Node{}
+	var (
+		stack    = []*Node{root}
+		words    []token
+		values   []token
+		inList   bool
+		inactive bool
+		protect  bool
+	)
+	add := func(words []token) *Node {
+		n := stack[len(stack)-1]
+		for _, w := range words {
+			n = n.add(w.text, w.line)
+		}
+		n.Inactive = n.Inactive || inactive
+		n.Protected = n.Protected || protect
+		return n
+	}
+	for _, t := range tokens {
+		switch {
+		case inList && t.punct("]"):
+			inList = false
+		case inList:
+			values = append(values, t)
+		case t.punct("["):
+			if len(words) == 0 {
+				return nil, fmt.Errorf("line %d: list without a name", t.line)
+			}
+			inList = true
+		case t.punct(";"):
+			if len(words) == 0 {
+				return nil, fmt.Errorf("line %d: empty statement", t.line)
+			}
+			if values == nil {
+				add(words)
+			}
+			for _, v := range values {
+				add(append(words[:len(words):len(words)], v))
+			}
+			words, values, inactive, protect = nil, nil, false, false
+		case t.punct("{"):
+			if len(words) == 0 || values != nil {
+				return nil, fmt.Errorf("line %d: block without a name", t.line)
+			}
+			stack = append(stack, add(words))
+			words, inactive, protect = nil, false, false
+		case t.punct("}"):
+			if len(words) > 0 || len(stack) == 1 {
+				return nil, fmt.Errorf("line %d: unexpected }", t.line)
+			}
+			stack = stack[:len(stack)-1]
+		case len(words) == 0 && !t.quoted && t.text == "inactive:":
+			inactive = true
+		case len(words) == 0 && !t.quoted && t.text == "protect:":
+			protect = true
+		case len(words) == 0 && !t.quoted && t.text == "replace:":
+		default:
+			words = append(words, t)
+		}
+	}
+	if inList || len(words) > 0 || len(stack) > 1 {
+		return nil, fmt.Errorf("unexpected end of configuration")
+	}
+	return root, nil
+}
+
+// FormatSet renders the hierarchy as set commands, each followed by
+// deactivate and protect commands for its annotated statements, as
+// "show configuration | display set" does.
+func FormatSet(root *Node) string {
+	var b strings.Builder
+	formatSet(&b, root, nil)
+	return b.String()
+}
+
+func formatSet(b *strings.Builder, n *Node, path []string) {
+	for _, c := range n.Children {
+		p := append(path[:len(path):len(path)], quote(c.Name))
+		if len(c.Children) == 0 {
+			fmt.Fprintf(b, "set %s\n", strings.Join(p, " "))
+		} else {
+			formatSet(b, c, p)
+		}
+		if c.Inactive {
+			fmt.Fprintf(b, "deactivate %s\n", strings.Join(p, " "))
+		}
+		if c.Protected {
+			fmt.Fprintf(b, "protect %s\n", strings.Join(p, " "))
+		}
+	}
+}
+
+// FormatBrace renders the hierarchy in curly-brace form. A statement with
+// a single block below it shares its line, as in "unit 0 {".
+func FormatBrace(root *Node) string {
+	var b strings.Builder
+	formatBrace(&b, root, 0)
+	return b.String()
+}
+
+func formatBrace(b *strings.Builder, n *Node, depth int) {
+	indent := strings.Repeat("    ", depth)
+	for _, c := range n.Children {
+		words := []string{quote(c.Name)}
+		annotated := c
+		// Fold "name key {" onto one line while nothing between is annotated.
+		for len(annotated.Children) == 1 && len(annotated.Children[0].Children) > 0 &&
+			!annotated.Inactive && !annotated.Protected {
+			annotated = annotated.Children[0]
+			words = append(words, quote(annotated.Name))
+		}
+		prefix := indent + annotation(annotated) + strings.Join(words, " ")
+		switch {
+		case len(annotated.Children) == 0:
+			fmt.Fprintf(b, "%s;\n", prefix)
+		case annotated.leaf() && annotation(annotated) == "" && !annotatedChild(annotated):
+			var values []string
+			for _, v := range annotated.Children {
+				values = append(values, quote(v.Name))
+			}
+			if len(values) == 1 {
+				fmt.Fprintf(b, "%s %s;\n", prefix, values[0])
+			} else {
+				fmt.Fprintf(b, "%s [ %s ];\n", prefix, strings.Join(values, " "))
+			}
+		default:
+			fmt.Fprintf(b, "%s {\n", prefix)
+			formatBrace(b, annotated, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+		}
+	}
+}
+
+func annotation(n *Node) string {
+	var a string
+	if n.Protected {
+		a += "protect: "
+	}
+	if n.Inactive {
+		a += "inactive: "
+	}
+	return a
+}
+
+func annotatedChild(n *Node) bool {
+	for _, c := range n.Children {
+		if c.Inactive || c.Protected {
+			return true
+		}
+	}
+	return false
+}
+
+// quote wraps a word in double quotes when it would not read back as one
+// word otherwise.
+func quote(word string) string {
+	switch word {
+	case "inactive:", "protect:", "replace:":
+	default:
+		if word != "" && !strings.ContainsAny(word, " \t\r\n;{}[]\"#\\$@") && !strings.HasPrefix(word, "/*") {
+			return word
+		}
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(word) + `"`
+}
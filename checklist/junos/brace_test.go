@@ -0,0 +1,200 @@
+package junos
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const jswSet = "../../junos/jsw.cfg"
+
+func lines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// jsw.conf is jsw.cfg as "show configuration" prints it, with a few
+// inactive statements added. JunOS gives a trace file's name and options
+// on one line, which the converter keeps as one statement.
+var braceOnly = map[string]string{
+	"set system processes dhcp-service traceoptions file dhcp_logfile size 10m": "set system processes dhcp-service traceoptions file dhcp_logfile\n" +
+		"set system processes dhcp-service traceoptions file size 10m",
+}
+
+var braceInactive = []string{
+	"deactivate system login user olduser",
+	"deactivate system services telnet",
+	"deactivate system services web-management",
+	"deactivate system ntp server 10.255.100.60",
+	"deactivate snmp community public",
+}
+
+func TestBraceConvertsToSet(t *testing.T) {
+	want, err := os.ReadFile(jswSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := ParseBrace("testdata/jsw.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inactive []string
+	for _, l := range lines(FormatSet(config.Root)) {
+		if strings.HasPrefix(l, "deactivate ") {
+			inactive = append(inactive, l)
+		}
+	}
+	if !reflect.DeepEqual(inactive, braceInactive) {
+		t.Errorf("inactive statements: got %q, want %q", inactive, braceInactive)
+	}
+
+	var got []string
+	for _, l := range lines(FormatSet(active(config.Root, ""))) {
+		if split, ok := braceOnly[l]; ok {
+			l = split
+		}
+		got = append(got, lines(l)...)
+	}
+	if !reflect.DeepEqual(got, lines(string(want))) {
+		for i, l := range lines(string(want)) {
+			if i >= len(got) || got[i] != l {
+				t.Fatalf("line %d: got %q, want %q", i+1, at(got, i), l)
+			}
+		}
+		t.Fatalf("got %d extra lines", len(got)-len(lines(string(want))))
+	}
+}
+
+func at(l []string, i int) string {
+	if i < len(l) {
+		return l[i]
+	}
+	return ""
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	config, err := ParseSet(jswSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FormatSet(config.Root)
+	root, err := ReadBrace(strings.NewReader(FormatBrace(config.Root)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatSet(root); got != want {
+		t.Errorf("set -> brace -> set changed the configuration:\n%s", got)
+	}
+}
+
+func TestBothFormatsAuditAlike(t *testing.T) {
+	set, err := Parse(jswSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brace, err := Parse("testdata/jsw.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{SIEM: DefaultSIEM}
+	if got, want := Audit(brace, opts), Audit(set, opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("brace results %v, set results %v", got, want)
+	}
+}
+
+const annotated = `## Last changed: 2025-06-12 09:41:07 +07
+system {
+    /* managed by the NOC */
+    host-name "core \"1\"";
+    inactive: services {
+        telnet;
+        ssh {
+            protect: root-login deny;
+        }
+    }
+    protect: syslog {
+        inactive: host 10.0.0.1 {
+            any info;
+        }
+        host 10.0.0.2 any warning; # collector
+    }
+    authentication-order [ tacplus password ];
+}
+protect: inactive: snmp community x;
+`
+
+var annotatedSet = []string{
+	`set system host-name "core \"1\""`,
+	`set system services telnet`,
+	`set system services ssh root-login deny`,
+	`protect system services ssh root-login deny`,
+	`deactivate system services`,
+	`set system syslog host 10.0.0.1 any info`,
+	`deactivate system syslog host 10.0.0.1`,
+	`set system syslog host 10.0.0.2 any warning`,
+	`protect system syslog`,
+	`set system authentication-order tacplus`,
+	`set system authentication-order password`,
+	`set snmp community x`,
+	`deactivate snmp community x`,
+	`protect snmp community x`,
+}
+
+func TestAnnotations(t *testing.T) {
+	root, err := ReadBrace(strings.NewReader(annotated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := lines(FormatSet(root))
+	if !reflect.DeepEqual(got, annotatedSet) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(annotatedSet, "\n"))
+	}
+
+	// The set commands read back to the same hierarchy.
+	fromSet, err := ReadSet(strings.NewReader(strings.Join(annotatedSet, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lines(FormatSet(fromSet)), annotatedSet) {
+		t.Errorf("set form did not read back:\n%s", FormatSet(fromSet))
+	}
+
+	// So does the brace form written from it.
+	again, err := ReadBrace(strings.NewReader(FormatBrace(root)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lines(FormatSet(again)), annotatedSet) {
+		t.Errorf("brace form did not read back:\n%s", FormatBrace(root))
+	}
+
+	resolved := (&Config{Root: root}).Resolve()
+	if resolved.Has("system", "services") || resolved.Has("system", "syslog", "host", "10.0.0.1") {
+		t.Error("inactive statements are still in effect")
+	}
+	if !resolved.Has("system", "syslog", "host", "10.0.0.2") {
+		t.Error("active syslog host was dropped")
+	}
+}
+
+func TestReadBraceErrors(t *testing.T) {
+	for _, config := range []string{
+		"system {\n    host-name jsw;\n",
+		"system {\n}\n}\n",
+		"system host-name jsw\n",
+		"system { host-name \"jsw; }\n",
+		"system { name-server [ 8.8.8.8 ; }\n",
+		"/* unterminated\n",
+		"{ host-name jsw; }\n",
+	} {
+		if _, err := ReadBrace(strings.NewReader(config)); err == nil {
+			t.Errorf("%q: no error", config)
+		}
+	}
+}
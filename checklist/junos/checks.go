@@ -0,0 +1,594 @@
+package junos
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"checklist/check"
+)
+
+// DefaultSIEM are the syslog collectors junos/8013.sh expects, as host or
+// host:port.
+var DefaultSIEM = []string{"192.168.1.100:514", "192.168.100.104:514"}
+
+// Options are the site values some checks compare against.
+type Options struct {
+	SIEM []string
+}
+
+// Check is one junos/80xx.sh control evaluated against the resolved
+// configuration. run returns what is wrong, nothing when the check passes.
+type Check struct {
+	ID    string
+	Title string
+	run   func(root *Node, opts Options) []string
+}
+
+var Checks = []Check{
+	{"8001", "Host name is configured", checkHostName},
+	{"8002", "Login announcement is configured", checkAnnouncement},
+	{"8003", "Login sessions time out after 5 idle minutes", checkIdleTimeout},
+	{"8004", "Management services are protected by a firewall filter", checkServiceFilter},
+	{"8005", "Unnecessary system services are disabled", checkUnusedServices},
+	{"8007", "Console and auxiliary ports are secured", checkPorts},
+	{"8008", "Remote access uses RADIUS or TACACS+", checkCentralAuth},
+	{"8009", "Time zone and NTP are configured", checkTime},
+	{"8010", "SNMP is read-only and restricted to known clients", checkSNMP},
+	{"8011", "SSH is version 2 only, limited and uses strong ciphers", checkSSH},
+	{"8012", "Passwords are hashed and the password policy is enforced", checkPasswords},
+	{"8013", "Syslog is sent to the SIEM", checkSyslog},
+	{"8014", "Login retry options limit password guessing", checkRetryOptions},
+	{"8015", "Configuration is archived automatically", checkArchival},
+}
+
+func GetChecks(paths []string, siem []string) (string, error) {
+	opts := Options{SIEM: siem}
+	if len(opts.SIEM) == 0 {
+		opts.SIEM = DefaultSIEM
+	}
+	var result string
+	for _, path := range paths {
+		config, err := Parse(path)
+		if err != nil {
+			return "", err
+		}
+		result += check.Format(config.Path+" (junos)", Audit(config, opts))
+	}
+	return result, nil
+}
+
+// Audit runs every check against the configuration in effect.
+func Audit(config *Config, opts Options) []check.Result {
+	root := config.Resolve()
+	var results []check.Result
+	for _, c := range Checks {
+		results = append(results, check.Result{ID: c.ID, Title: c.Title, Findings: c.run(root, opts)})
+	}
+	return results
+}
+
+func checkHostName(root *Node, opts Options) []string {
+	switch name := root.Value("system", "host-name"); name {
+	case "":
+		return []string{"system host-name is not set"}
+	case "Amnesiac":
+		return []string{"system host-name is the factory default Amnesiac"}
+	}
+	return nil
+}
+
+func checkAnnouncement(root *Node, opts Options) []string {
+	if strings.TrimSpace(root.Value("system", "login", "announcement")) == "" {
+		return []string{"system login announcement is not set"}
+	}
+	return nil
+}
+
+func checkIdleTimeout(root *Node, opts Options) []string {
+	var findings []string
+	if f := idleTimeout(root.Find("system", "login"), "system login"); f != "" {
+		findings = append(findings, f)
+	}
+	for _, class := range root.Find("system", "login", "class").children() {
+		// super-user has no idle timeout to set.
+		if class.Name == "super-user" {
+			continue
+		}
+		if f := idleTimeout(class, "class "+class.Name); f != "" {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func idleTimeout(n *Node, where string) string {
+	value := n.Value("idle-timeout")
+	if value == "" {
+		return where + ": idle-timeout is not set"
+	}
+	if minutes, err := strconv.Atoi(value); err != nil || minutes < 1 || minutes > 5 {
+		return fmt.Sprintf("%s: idle-timeout is %s, want 5 or less", where, value)
+	}
+	return ""
+}
+
+// ManagementServices returns the TCP port of each enabled management
+// service.
+func ManagementServices(root *Node) map[string]int {
+	services := root.Find("system", "services")
+	open := make(map[string]int)
+	add := func(name string, n *Node, port int) {
+		if n == nil {
+			return
+		}
+		if p, err := strconv.Atoi(n.Value("port")); err == nil {
+			port = p
+		}
+		open[name] = port
+	}
+	add("ssh", services.Child("ssh"), 22)
+	add("telnet", services.Child("telnet"), 23)
+	add("http", services.Find("web-management", "http"), 80)
+	add("https", services.Find("web-management", "https"), 443)
+	add("netconf", services.Find("netconf", "ssh"), 830)
+	return open
+}
+
+func checkServiceFilter(root *Node, opts Options) []string {
+	open := ManagementServices(root)
+	if len(open) == 0 {
+		return nil
+	}
+	filters := appliedFilters(root)
+	if len(filters) == 0 {
+		return []string{"no firewall filter is applied as an interface input filter"}
+	}
+	var (
+		findings []string
+		allowed  bool
+	)
+	for _, name := range sortedKeys(open) {
+		protected, allows := protects(root, filters, open[name])
+		allowed = allowed || allows
+		if !protected {
+			findings = append(findings, fmt.Sprintf("%s (port %d) is reachable from any source", name, open[name]))
+		}
+	}
+	if !allowed {
+		findings = append(findings, "no filter term accepts management traffic from listed sources only")
+	}
+	return findings
+}
+
+// Protected reports whether traffic to port from sources the interface
+// input filters do not list is discarded.
+func Protected(root *Node, port int) bool {
+	protected, _ := protects(root, appliedFilters(root), port)
+	return protected
+}
+
+// appliedFilters returns the IPv4 input filters applied on any interface.
+func appliedFilters(root *Node) []string {
+	var filters []string
+	for _, ifd := range root.Find("interfaces").children() {
+		for _, unit := range ifd.Find("unit").children() {
+			filter := unit.Find("family", "inet", "filter")
+			for _, name := range append(filter.Values("input"), filter.Values("input-list")...) {
+				if !contains(filters, name) {
+					filters = append(filters, name)
+				}
+			}
+		}
+	}
+	return filters
+}
+
+func filterTerms(root *Node, name string) []*Node {
+	if f := root.Find("firewall", "family", "inet", "filter", name); f != nil {
+		return f.Find("term").children()
+	}
+	return root.Find("firewall", "filter", name, "term").children()
+}
+
+// knownMatches are the from conditions protects understands. A term with
+// any other condition, such as icmp-type or destination-address, may not
+// match the traffic, so it cannot be relied on to discard it.
+var knownMatches = []string{
+	"destination-port", "port", "protocol",
+	"source-address", "source-prefix-list", "address", "prefix-list",
+}
+
+// protects walks the terms of each filter in order, as the device does,
+// and reports whether traffic to port from an unlisted source is
+// discarded by at least one filter. A term without a terminating action
+// accepts, and a filter ends with an implicit discard. allows reports
+// whether a term accepts the port from listed sources.
+func protects(root *Node, filters []string, port int) (protected, allows bool) {
+	for _, name := range filters {
+		filterProtects := true
+		for _, term := range filterTerms(root, name) {
+			from, then := term.Child("from"), term.Child("then")
+			if ports := append(from.Values("destination-port"), from.Values("port")...); len(ports) > 0 && !portIn(ports, port) {
+				continue
+			}
+			if protocols := from.Values("protocol"); len(protocols) > 0 && !contains(protocols, protocolOf(port)) {
+				continue
+			}
+			if then.Has("next") {
+				continue
+			}
+			bySource := from.Has("source-address") || from.Has("source-prefix-list") ||
+				from.Has("address") || from.Has("prefix-list")
+			discards := then.Has("discard") || then.Has("reject")
+			if bySource {
+				allows = allows || !discards
+				continue
+			}
+			if !onlyKnownMatches(from) {
+				if discards {
+					// It may not discard this traffic; look further.
+					continue
+				}
+				// It may accept this traffic from any source.
+				filterProtects = false
+				break
+			}
+			filterProtects = discards
+			break
+		}
+		protected = protected || filterProtects
+	}
+	return protected, allows
+}
+
+func onlyKnownMatches(from *Node) bool {
+	for _, c := range from.children() {
+		if !contains(knownMatches, c.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// protocolOf returns the transport of a management port: SNMP runs over
+// UDP, the others over TCP.
+func protocolOf(port int) string {
+	if port == 161 {
+		return "udp"
+	}
+	return "tcp"
+}
+
+var portNames = map[string]int{
+	"ftp":     21,
+	"ssh":     22,
+	"telnet":  23,
+	"http":    80,
+	"snmp":    161,
+	"https":   443,
+	"netconf": 830,
+}
+
+// portIn reports whether port is one of values, which are numbers,
+// names or ranges like 1024-65535.
+func portIn(values []string, port int) bool {
+	for _, v := range values {
+		if p, ok := portNames[v]; ok && p == port {
+			return true
+		}
+		if first, last, ok := strings.Cut(v, "-"); ok {
+			lo, err1 := strconv.Atoi(first)
+			hi, err2 := strconv.Atoi(last)
+			if err1 == nil && err2 == nil && port >= lo && port <= hi {
+				return true
+			}
+		}
+		if p, err := strconv.Atoi(v); err == nil && p == port {
+			return true
+		}
+	}
+	return false
+}
+
+var unusedServices = []string{
+	"bbe-stats-service",
+	"database-replication",
+	"dhcp-local-server",
+	"dtcp-only",
+	"extension-service",
+	"finger",
+	"ftp",
+	"netproxy",
+	"outbound-ssh",
+	"resource-monitor",
+	"rest",
+	"service-deployment",
+	"subscriber-management",
+	"telnet",
+	"tftp-server",
+	"web-management",
+	"xnm-clear-text",
+	"xnm-ssl",
+}
+
+func checkUnusedServices(root *Node, opts Options) []string {
+	var findings []string
+	services := root.Find("system", "services")
+	for _, name := range unusedServices {
+		if n := services.Child(name); n != nil {
+			findings = append(findings, fmt.Sprintf("system services %s is enabled%s", name, inherited(n)))
+		}
+	}
+	return findings
+}
+
+func checkPorts(root *Node, opts Options) []string {
+	var findings []string
+	for _, p := range [][]string{
+		{"console", "log-out-on-disconnect"},
+		{"console", "insecure"},
+		{"auxiliary", "insecure"},
+	} {
+		if !root.Has(append([]string{"system", "ports"}, p...)...) {
+			findings = append(findings, "system ports "+strings.Join(p, " ")+" is not set")
+		}
+	}
+	return findings
+}
+
+func checkCentralAuth(root *Node, opts Options) []string {
+	var (
+		findings   []string
+		configured []string
+	)
+	for _, kind := range []string{"radius", "tacplus"} {
+		servers := root.Find("system", kind+"-server").children()
+		if len(servers) > 0 {
+			configured = append(configured, kind)
+		}
+		for _, s := range servers {
+			if s.Value("secret") == "" {
+				findings = append(findings, fmt.Sprintf("%s-server %s has no secret", kind, s.Name))
+			}
+		}
+	}
+	if len(configured) == 0 {
+		return []string{"no radius-server or tacplus-server is configured"}
+	}
+	order := root.Values("system", "authentication-order")
+	var used bool
+	for _, kind := range configured {
+		used = used || contains(order, kind)
+	}
+	if !used {
+		findings = append(findings, fmt.Sprintf("system authentication-order does not include %s", strings.Join(configured, " or ")))
+	}
+	return findings
+}
+
+func checkTime(root *Node, opts Options) []string {
+	var findings []string
+	switch tz := root.Value("system", "time-zone"); tz {
+	case "Asia/Saigon", "Asia/Ho_Chi_Minh":
+	case "":
+		findings = append(findings, "system time-zone is not set")
+	default:
+		findings = append(findings, fmt.Sprintf("system time-zone is %s, want Asia/Saigon", tz))
+	}
+	if len(root.Values("system", "ntp", "server")) == 0 {
+		findings = append(findings, "no system ntp server is configured")
+	}
+	return findings
+}
+
+func checkSNMP(root *Node, opts Options) []string {
+	snmp := root.Child("snmp")
+	if snmp == nil {
+		return nil
+	}
+	var findings []string
+	lists := snmp.Child("client-list")
+	for _, list := range lists.children() {
+		if !restrictsDefault(list) {
+			findings = append(findings, fmt.Sprintf("client-list %s does not end with 0.0.0.0/0 restrict", list.Name))
+		}
+	}
+	for _, c := range snmp.Find("community").children() {
+		// The community is a secret, so only its line is shown.
+		where := fmt.Sprintf("community on line %d", c.Line)
+		for _, unsafe := range check.WellKnownCommunities {
+			if strings.Contains(strings.ToLower(c.Name), unsafe) {
+				findings = append(findings, fmt.Sprintf("%s uses the well-known string %q", where, unsafe))
+			}
+		}
+		if c.Value("authorization") == "read-write" {
+			findings = append(findings, where+" has read-write authorization")
+		}
+		clients, list := c.Child("clients"), c.Value("client-list-name")
+		switch {
+		case clients == nil && list == "":
+			findings = append(findings, where+" has no clients or client-list-name")
+		case clients != nil && !restrictsDefault(clients):
+			findings = append(findings, where+" clients do not end with 0.0.0.0/0 restrict")
+		case list != "" && lists.Child(list) == nil:
+			findings = append(findings, fmt.Sprintf("%s uses undefined client-list %s", where, list))
+		}
+	}
+	if v3 := snmp.Child("v3"); v3 != nil {
+		if !snmp.Has("interface") {
+			findings = append(findings, "SNMPv3 is not limited to an interface with snmp interface")
+		}
+		for _, access := range v3.Find("vacm", "access", "group").children() {
+			if find(access, "write-view") != nil {
+				findings = append(findings, fmt.Sprintf("vacm access group %s has a write-view", access.Name))
+			}
+		}
+		if protected, _ := protects(root, appliedFilters(root), 161); !protected {
+			findings = append(findings, "SNMP (port 161) is not discarded for unlisted sources by an interface filter")
+		}
+	}
+	return findings
+}
+
+// restrictsDefault reports whether a client list rejects every source not
+// listed in it.
+func restrictsDefault(list *Node) bool {
+	return list.Has("0.0.0.0/0", "restrict") || list.Has("default", "restrict")
+}
+
+// find returns the first node called name at or below n.
+func find(n *Node, name string) *Node {
+	for _, c := range n.children() {
+		if c.Name == name {
+			return c
+		}
+		if f := find(c, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func checkSSH(root *Node, opts Options) []string {
+	ssh := root.Find("system", "services", "ssh")
+	if ssh == nil {
+		return []string{"system services ssh is not enabled"}
+	}
+	var findings []string
+	if versions := ssh.Values("protocol-version"); len(versions) != 1 || versions[0] != "v2" {
+		findings = append(findings, "ssh protocol-version is not v2 only")
+	}
+	for _, limit := range []string{"connection-limit", "rate-limit"} {
+		if n, err := strconv.Atoi(ssh.Value(limit)); err != nil || n <= 0 {
+			findings = append(findings, "ssh "+limit+" is not set")
+		}
+	}
+	if ssh.Value("root-login") != "deny" {
+		findings = append(findings, "ssh root-login is not deny")
+	}
+	ciphers := ssh.Values("ciphers")
+	if len(ciphers) == 0 {
+		findings = append(findings, "ssh ciphers are not restricted")
+	}
+	for _, c := range ciphers {
+		if lower := strings.ToLower(c); !strings.Contains(lower, "aes") && !strings.Contains(lower, "3des") {
+			findings = append(findings, "ssh cipher "+c+" is not AES or 3DES")
+		}
+	}
+	findings = append(findings, checkPasswords(root, opts)...)
+	password := root.Find("system", "login", "password")
+	for _, class := range []string{"minimum-lower-cases", "minimum-upper-cases", "minimum-numerics", "minimum-punctuations"} {
+		if n, err := strconv.Atoi(password.Value(class)); err != nil || n < 1 {
+			findings = append(findings, "login password "+class+" is not set")
+		}
+	}
+	return findings
+}
+
+func checkPasswords(root *Node, opts Options) []string {
+	var findings []string
+	for _, user := range root.Find("system", "login", "user").children() {
+		if f := passwordHash(user.Find("authentication"), "user "+user.Name); f != "" {
+			findings = append(findings, f)
+		}
+	}
+	if f := passwordHash(root.Find("system", "root-authentication"), "root-authentication"); f != "" {
+		findings = append(findings, f)
+	}
+	password := root.Find("system", "login", "password")
+	switch format := password.Value("format"); format {
+	case "sha1", "sha256", "sha512":
+	case "":
+		findings = append(findings, "login password format is not set")
+	default:
+		findings = append(findings, "login password format is "+format+", want sha512, sha256 or sha1")
+	}
+	if n, err := strconv.Atoi(password.Value("minimum-length")); err != nil || n < 10 {
+		findings = append(findings, "login password minimum-length is less than 10")
+	}
+	if password.Value("change-type") != "character-sets" {
+		findings = append(findings, "login password change-type is not character-sets")
+	}
+	return findings
+}
+
+// passwordHash checks the encrypted-password under an authentication node.
+func passwordHash(auth *Node, where string) string {
+	hash := auth.Value("encrypted-password")
+	switch {
+	case hash == "":
+		return where + " has no encrypted-password"
+	case strings.HasPrefix(hash, "$5$"), strings.HasPrefix(hash, "$6$"), strings.HasPrefix(hash, "$sha1$"):
+		return ""
+	case strings.HasPrefix(hash, "$1$"):
+		return where + " password is an MD5 hash"
+	}
+	return where + " password hash type is unknown"
+}
+
+var syslogLevels = []string{"info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+func checkSyslog(root *Node, opts Options) []string {
+	hosts := root.Find("system", "syslog", "host")
+	for _, siem := range opts.SIEM {
+		addr, port, ok := strings.Cut(siem, ":")
+		if !ok {
+			port = "514"
+		}
+		host := hosts.Child(addr)
+		if host == nil {
+			continue
+		}
+		if p := host.Value("port"); p != port && (p != "" || port != "514") {
+			continue
+		}
+		for _, facility := range host.Children {
+			if leaf := facility.Value(); facility.leaf() && contains(syslogLevels, leaf) {
+				return nil
+			}
+		}
+	}
+	return []string{fmt.Sprintf("no syslog host sends info or higher to %s", strings.Join(opts.SIEM, " or "))}
+}
+
+func checkRetryOptions(root *Node, opts Options) []string {
+	var findings []string
+	retry := root.Find("system", "login", "retry-options")
+	for _, option := range []string{"backoff-factor", "backoff-threshold", "lockout-period", "tries-before-disconnect"} {
+		if !retry.Has(option) {
+			findings = append(findings, "login retry-options "+option+" is not set")
+		}
+	}
+	return findings
+}
+
+func checkArchival(root *Node, opts Options) []string {
+	var findings []string
+	archival := root.Find("system", "archival", "configuration")
+	if len(archival.Values("archive-sites")) == 0 {
+		findings = append(findings, "no archival archive-sites are configured")
+	}
+	if !archival.Has("transfer-on-commit") && !archival.Has("transfer-interval") {
+		findings = append(findings, "neither archival transfer-on-commit nor transfer-interval is set")
+	}
+	return findings
+}
+
+// inherited names the group a statement was inherited from, if any.
+func inherited(n *Node) string {
+	if n.Group == "" {
+		return ""
+	}
+	return " (from group " + n.Group + ")"
+}
+
+func sortedKeys(m map[string]int) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package junos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuditSample(t *testing.T) {
+	config, err := Parse(jswSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []string
+	for _, r := range Audit(config, Options{SIEM: DefaultSIEM}) {
+		if !r.Pass() {
+			failed = append(failed, r.ID)
+		}
+	}
+	if got, want := strings.Join(failed, " "), "8004 8010 8011 8012 8013 8014"; got != want {
+		t.Errorf("failed: %s, want %s", got, want)
+	}
+}
+
+func TestProtected(t *testing.T) {
+	const iface = "set interfaces lo0 unit 0 family inet filter input F\n"
+	for _, tc := range []struct {
+		name   string
+		filter string
+		port   int
+		want   bool
+	}{
+		{"implicit discard", `
+set firewall filter F term mgmt from source-address 10.0.0.0/8
+set firewall filter F term mgmt from destination-port ssh
+set firewall filter F term mgmt then accept
+`, 22, true},
+		{"accept all", `
+set firewall filter F term mgmt from source-address 10.0.0.0/8
+set firewall filter F term mgmt then accept
+set firewall filter F term rest then accept
+`, 22, false},
+		{"discard ssh", `
+set firewall filter F term ssh from protocol tcp
+set firewall filter F term ssh from destination-port ssh
+set firewall filter F term ssh then discard
+set firewall filter F term rest then accept
+`, 22, true},
+		{"discard other port", `
+set firewall filter F term ssh from destination-port telnet
+set firewall filter F term ssh then discard
+set firewall filter F term rest then accept
+`, 22, false},
+		{"discard other protocol", `
+set firewall filter F term ssh from protocol udp
+set firewall filter F term ssh from destination-port 22
+set firewall filter F term ssh then discard
+set firewall filter F term rest then accept
+`, 22, false},
+		{"discard icmp", `
+set firewall filter F term icmp from protocol icmp
+set firewall filter F term icmp then discard
+set firewall filter F term rest then accept
+`, 22, false},
+		{"discard unmodelled match", `
+set firewall filter F term dst from destination-address 10.1.1.1/32
+set firewall filter F term dst then discard
+set firewall filter F term rest then accept
+`, 22, false},
+		{"accept unmodelled match", `
+set firewall filter F term dst from destination-address 10.1.1.1/32
+set firewall filter F term dst then accept
+set firewall filter F term rest then discard
+`, 22, false},
+		{"next term", `
+set firewall filter F term count then count all
+set firewall filter F term count then next term
+set firewall filter F term rest then discard
+`, 22, true},
+		{"snmp over udp", `
+set firewall filter F term snmp from protocol udp
+set firewall filter F term snmp from port snmp
+set firewall filter F term snmp then reject
+set firewall filter F term rest then accept
+`, 161, true},
+	} {
+		root := readSet(t, iface+tc.filter)
+		if got := Protected(root, tc.port); got != tc.want {
+			t.Errorf("%s: got %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
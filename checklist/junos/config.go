@@ -0,0 +1,338 @@
+package junos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Node is one statement in the configuration hierarchy. Set commands are
+// not checked against a schema, so every word of a command is a node and
+// a leaf's value is its only child.
+type Node struct {
+	Name      string
+	Line      int
+	Inactive  bool
+	Protected bool
+	Group     string
+	Children  []*Node
+}
+
+// Child returns the direct child called name, or nil.
+func (n *Node) Child(name string) *Node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find walks path from n and returns the node at its end, or nil.
+func (n *Node) Find(path ...string) *Node {
+	for _, name := range path {
+		n = n.Child(name)
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// Has reports whether path exists below n.
+func (n *Node) Has(path ...string) bool {
+	return n.Find(path...) != nil
+}
+
+// Values returns the names of the children at path, such as the values of
+// a leaf-list or the names of a list's entries.
+func (n *Node) Values(path ...string) []string {
+	var values []string
+	for _, c := range n.Find(path...).children() {
+		values = append(values, c.Name)
+	}
+	return values
+}
+
+// Value returns the first value at path, or "" when it is not set.
+func (n *Node) Value(path ...string) string {
+	if values := n.Values(path...); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (n *Node) children() []*Node {
+	if n == nil {
+		return nil
+	}
+	return n.Children
+}
+
+// leaf reports whether every child of n has no children of its own, which
+// is how a leaf holding a value looks without a schema.
+func (n *Node) leaf() bool {
+	if len(n.Children) == 0 {
+		return false
+	}
+	for _, c := range n.Children {
+		if len(c.Children) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Node) add(name string, line int) *Node {
+	if c := n.Child(name); c != nil {
+		return c
+	}
+	c := &Node{Name: name, Line: line}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *Node) remove(name string) {
+	for i, c := range n.Children {
+		if c.Name == name {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+// singleValued are the leaves the checks read that hold one value. Without
+// a schema every value is kept, so a second set of one of these would
+// otherwise leave both, and Value would return the stale one.
+var singleValued = map[string]bool{
+	"announcement":       true,
+	"authorization":      true,
+	"change-type":        true,
+	"client-list-name":   true,
+	"encrypted-password": true,
+	"format":             true,
+	"host-name":          true,
+	"idle-timeout":       true,
+	"message":            true,
+	"minimum-length":     true,
+	"root-login":         true,
+	"secret":             true,
+	"time-zone":          true,
+}
+
+// Config is a JunOS configuration. Root holds the statements as written,
+// including groups; Resolve gives the configuration the device runs.
+type Config struct {
+	Path string
+	Root *Node
+}
+
+// ParseSet reads a configuration in "display set" form.
+func ParseSet(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	root, err := ReadSet(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Config{Path: path, Root: root}, nil
+}
+
+// ReadSet builds the hierarchy from set, delete, deactivate, activate,
+// protect and unprotect commands, applied in order.
+func ReadSet(r io.Reader) (*Node, error) {
+	root := &Node{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lineNo int
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		words, err := Split(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(words) < 2 {
+			return nil, fmt.Errorf("line %d: incomplete command %q", lineNo, text)
+		}
+		verb, p := words[0], words[1:]
+		switch verb {
+		case "set":
+			n := root
+			for i, w := range p {
+				if i == len(p)-1 && singleValued[n.Name] {
+					// A new value replaces the one already set.
+					n.Children = slices.DeleteFunc(n.Children, func(c *Node) bool {
+						return c.Name != w && len(c.Children) == 0
+					})
+				}
+				n = n.add(w, lineNo)
+			}
+		case "delete":
+			parent := root.Find(p[:len(p)-1]...)
+			if parent != nil {
+				parent.remove(p[len(p)-1])
+			}
+		case "deactivate", "activate":
+			if n := root.Find(p...); n != nil {
+				n.Inactive = verb == "deactivate"
+			}
+		case "protect", "unprotect":
+			if n := root.Find(p...); n != nil {
+				n.Protected = verb == "protect"
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unsupported command %q", lineNo, verb)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Split breaks a command into words. Double quoted strings are one word
+// with the quotes removed and backslash escapes resolved.
+func Split(text string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+		quoted bool
+	)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(text):
+			i++
+			word.WriteByte(text[i])
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Resolve returns the configuration in effect: inactive statements are
+// dropped and apply-groups are expanded. As on the device, statements set
+// directly win over inherited ones, groups applied at a deeper level win
+// over those applied above it, and earlier groups in a list win over later
+// ones. A leaf that already holds a value does not take a group's value.
+func (c *Config) Resolve() *Node {
+	groups := c.Root.Child("groups")
+	root := active(c.Root, "")
+	root.remove("groups")
+	inherit(root, groups, nil, nil)
+	return root
+}
+
+func active(n *Node, group string) *Node {
+	c := &Node{Name: n.Name, Line: n.Line, Protected: n.Protected, Group: group}
+	for _, child := range n.Children {
+		if !child.Inactive {
+			c.Children = append(c.Children, active(child, group))
+		}
+	}
+	return c
+}
+
+// inherit merges the groups in effect at n into it and recurses. applied
+// is the list of groups applied above n, most specific first.
+func inherit(n *Node, groups *Node, p []string, applied []string) {
+	except := n.Values("apply-groups-except")
+	effective := n.Values("apply-groups")
+	for _, g := range applied {
+		if !contains(except, g) && !contains(effective, g) {
+			effective = append(effective, g)
+		}
+	}
+	for _, g := range effective {
+		def := groups.Child(g)
+		if def == nil || def.Inactive {
+			continue
+		}
+		for _, src := range match(def, p) {
+			if n.leaf() && src.leaf() {
+				// Both hold a value; the one already in place wins.
+				continue
+			}
+			for _, child := range src.Children {
+				if child.Inactive || strings.HasPrefix(child.Name, "<") || n.Child(child.Name) != nil {
+					continue
+				}
+				n.Children = append(n.Children, active(child, g))
+			}
+		}
+	}
+	for _, child := range n.Children {
+		if child.Name == "apply-groups" || child.Name == "apply-groups-except" {
+			continue
+		}
+		inherit(child, groups, append(p[:len(p):len(p)], child.Name), effective)
+	}
+}
+
+// match returns the nodes of a group definition at path p. Names in angle
+// brackets are wildcards, such as <ge-*>.
+func match(def *Node, p []string) []*Node {
+	nodes := []*Node{def}
+	for _, name := range p {
+		var next []*Node
+		for _, n := range nodes {
+			for _, c := range n.Children {
+				if c.Inactive {
+					continue
+				}
+				if c.Name == name {
+					next = append(next, c)
+				} else if strings.HasPrefix(c.Name, "<") && strings.HasSuffix(c.Name, ">") {
+					// "*" in a JunOS wildcard also matches "/".
+					pattern := strings.ReplaceAll(strings.Trim(c.Name, "<>"), "/", "\x00")
+					if ok, _ := path.Match(pattern, strings.ReplaceAll(name, "/", "\x00")); ok {
+						next = append(next, c)
+					}
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
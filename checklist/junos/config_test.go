@@ -0,0 +1,131 @@
+package junos
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readSet(t *testing.T, text string) *Node {
+	t.Helper()
+	root, err := ReadSet(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestReadSet(t *testing.T) {
+	root := readSet(t, `
+# comment
+set system host-name a
+set system host-name b
+set system name-server 8.8.8.8
+set system name-server 8.8.4.4
+set system services telnet
+set system services ssh root-login allow
+set system services ssh root-login deny
+delete system services telnet
+set snmp community x authorization read-only
+deactivate snmp community x
+protect system services ssh
+set system login message "Authorized \"staff\" only"
+`)
+	if got := root.Value("system", "host-name"); got != "b" {
+		t.Errorf("host-name: got %q, want b", got)
+	}
+	if got := root.Values("system", "host-name"); len(got) != 1 {
+		t.Errorf("host-name holds %q, want one value", got)
+	}
+	if got := root.Values("system", "name-server"); !reflect.DeepEqual(got, []string{"8.8.8.8", "8.8.4.4"}) {
+		t.Errorf("name-server: got %q", got)
+	}
+	if got := root.Value("system", "services", "ssh", "root-login"); got != "deny" {
+		t.Errorf("root-login: got %q, want deny", got)
+	}
+	if root.Has("system", "services", "telnet") {
+		t.Error("deleted telnet is still set")
+	}
+	if n := root.Find("snmp", "community", "x"); n == nil || !n.Inactive {
+		t.Error("community x is not inactive")
+	}
+	if n := root.Find("system", "services", "ssh"); n == nil || !n.Protected {
+		t.Error("ssh is not protected")
+	}
+	if got := root.Value("system", "login", "message"); got != `Authorized "staff" only` {
+		t.Errorf("message: got %q", got)
+	}
+}
+
+func TestReadSetErrors(t *testing.T) {
+	for _, text := range []string{
+		"set\n",
+		"show system\n",
+		"set system host-name \"jsw\n",
+	} {
+		if _, err := ReadSet(strings.NewReader(text)); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+}
+
+func TestResolveGroups(t *testing.T) {
+	root := readSet(t, `
+set groups global system host-name from-group
+set groups global system time-zone UTC
+set groups global system syslog host 10.0.0.1 any info
+set groups site system time-zone Asia/Saigon
+set groups site system ntp server 10.0.0.9
+set groups ports interfaces <ge-*> unit 0 family inet filter input PROTECT
+set groups ports interfaces <xe-0/0/*> description uplink
+set groups off system services telnet
+deactivate groups off
+set apply-groups site
+set apply-groups global
+set apply-groups off
+set system host-name jsw
+set system syslog apply-groups-except global
+set interfaces apply-groups ports
+set interfaces ge-0/0/1 unit 0 family ethernet-switching
+set interfaces xe-0/0/2 unit 0 family inet
+set interfaces ae0 unit 0 family inet
+set system services ssh
+deactivate system services ssh
+`)
+	r := (&Config{Root: root}).Resolve()
+	for _, tc := range []struct {
+		path []string
+		want string
+	}{
+		// Statements set directly win over groups.
+		{[]string{"system", "host-name"}, "jsw"},
+		// Earlier groups in the list win over later ones.
+		{[]string{"system", "time-zone"}, "Asia/Saigon"},
+		{[]string{"system", "ntp", "server"}, "10.0.0.9"},
+		{[]string{"interfaces", "ge-0/0/1", "unit", "0", "family", "inet", "filter", "input"}, "PROTECT"},
+		// A "*" in a wildcard crosses "/".
+		{[]string{"interfaces", "xe-0/0/2", "description"}, "uplink"},
+	} {
+		if got := r.Value(tc.path...); got != tc.want {
+			t.Errorf("%s: got %q, want %q", strings.Join(tc.path, " "), got, tc.want)
+		}
+	}
+	if r.Has("system", "syslog", "host") {
+		t.Error("apply-groups-except did not keep the global syslog host out")
+	}
+	if r.Has("interfaces", "ae0", "unit", "0", "family", "inet", "filter") {
+		t.Error("<ge-*> matched ae0")
+	}
+	if r.Has("system", "services", "telnet") {
+		t.Error("an inactive group was applied")
+	}
+	if r.Has("system", "services", "ssh") {
+		t.Error("an inactive statement is in effect")
+	}
+	if r.Has("groups") {
+		t.Error("groups are left in the resolved configuration")
+	}
+	if n := r.Find("system", "ntp"); n == nil || n.Group != "site" {
+		t.Errorf("ntp does not record the group it came from: %+v", n)
+	}
+}
@@ -0,0 +1,297 @@
+package kernelmodule
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"checklist/dropin"
+)
+
+// defaultModules are the uncommon file systems and drivers CIS recommends
+// disabling; they are checked when no module list is given.
+var defaultModules = []string{
+	"cramfs",
+	"freevxfs",
+	"jffs2",
+	"hfs",
+	"hfsplus",
+	"squashfs",
+	"udf",
+	"usb-storage",
+}
+
+// modprobeDirs are listed from lowest to highest precedence; a file in a
+// later directory replaces a file of the same name in an earlier one.
+var modprobeDirs = []string{
+	"/usr/lib/modprobe.d",
+	"/lib/modprobe.d",
+	"/usr/local/lib/modprobe.d",
+	"/run/modprobe.d",
+	"/etc/modprobe.d",
+}
+
+// disabledCommands are install targets that make modprobe a no-op.
+var disabledCommands = map[string]struct{}{
+	"/bin/true":      {},
+	"/bin/false":     {},
+	"/usr/bin/true":  {},
+	"/usr/bin/false": {},
+	"true":           {},
+	"false":          {},
+}
+
+const (
+	VerdictLoaded      = "loaded"
+	VerdictDisabled    = "disabled"
+	VerdictNotPresent  = "not present"
+	VerdictBlacklisted = "blacklisted but loadable"
+	VerdictLoadable    = "loadable"
+)
+
+// Module is the state of one kernel module on the running kernel.
+type Module struct {
+	Name        string
+	Loaded      bool
+	Builtin     bool
+	Available   bool
+	Blacklisted bool
+	Install     string
+	Sources     []string
+	Verdict     string
+}
+
+type modprobeConfig struct {
+	blacklist map[string]string
+	install   map[string]modprobeInstall
+}
+
+type modprobeInstall struct {
+	command string
+	source  string
+}
+
+func GetKernelModules(modules []string) (string, error) {
+	if len(modules) == 0 {
+		modules = defaultModules
+	}
+	states, err := Inspect(modules)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	for _, m := range states {
+		result += fmt.Sprintf("\"%s\": %s\n", m.Name, m.Verdict)
+		result += fmt.Sprintf("loaded=%t builtin=%t available=%t blacklisted=%t", m.Loaded, m.Builtin, m.Available, m.Blacklisted)
+		if m.Install != "" {
+			result += fmt.Sprintf(" install=%q", m.Install)
+		}
+		result += "\n"
+		for _, source := range m.Sources {
+			result += fmt.Sprintf("  %s\n", source)
+		}
+	}
+	return result, nil
+}
+
+// Inspect returns the state of each module on the running kernel.
+func Inspect(modules []string) ([]Module, error) {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return nil, err
+	}
+	moduleDir := filepath.Join("/lib/modules", strings.TrimSpace(string(release)))
+
+	loaded, err := loadedModules("/proc/modules")
+	if err != nil {
+		return nil, err
+	}
+	builtin := builtinModules(filepath.Join(moduleDir, "modules.builtin"))
+	available := availableModules(moduleDir)
+	config, err := parseModprobeConfig(modprobeDirs, "/etc/modprobe.conf")
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := slices.Clone(modules)
+	slices.Sort(sorted)
+	states := make([]Module, 0, len(sorted))
+	for _, name := range sorted {
+		key := normalize(name)
+		m := Module{Name: name}
+		_, m.Loaded = loaded[key]
+		_, m.Builtin = builtin[key]
+		_, m.Available = available[key]
+		if source, ok := config.blacklist[key]; ok {
+			m.Blacklisted = true
+			m.Sources = append(m.Sources, source)
+		}
+		if install, ok := config.install[key]; ok {
+			m.Install = install.command
+			m.Sources = append(m.Sources, install.source)
+		}
+		m.Verdict = verdict(m)
+		states = append(states, m)
+	}
+	return states, nil
+}
+
+func verdict(m Module) string {
+	var disabled bool
+	if fields := strings.Fields(m.Install); len(fields) > 0 {
+		_, disabled = disabledCommands[fields[0]]
+	}
+	switch {
+	case m.Loaded:
+		return VerdictLoaded
+	case m.Builtin:
+		// Built-in code cannot be unloaded or disabled by modprobe.
+		return VerdictLoadable
+	case disabled:
+		return VerdictDisabled
+	case !m.Available:
+		return VerdictNotPresent
+	case m.Blacklisted:
+		return VerdictBlacklisted
+	}
+	return VerdictLoadable
+}
+
+func loadedModules(path string) (map[string]struct{}, error) {
+	loaded := make(map[string]struct{})
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Kernels built without module support have no /proc/modules.
+		return loaded, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			loaded[normalize(fields[0])] = struct{}{}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+func builtinModules(path string) map[string]struct{} {
+	builtin := make(map[string]struct{})
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return builtin
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			builtin[moduleName(line)] = struct{}{}
+		}
+	}
+	return builtin
+}
+
+// availableModules indexes every module object under the kernel's module
+// tree, which is what modinfo and modprobe would find.
+func availableModules(moduleDir string) map[string]struct{} {
+	available := make(map[string]struct{})
+	_ = filepath.WalkDir(moduleDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() && strings.Contains(d.Name(), ".ko") {
+			available[moduleName(d.Name())] = struct{}{}
+		}
+		return nil
+	})
+	return available
+}
+
+func parseModprobeConfig(dirs []string, legacy string) (*modprobeConfig, error) {
+	config := &modprobeConfig{
+		blacklist: make(map[string]string),
+		install:   make(map[string]modprobeInstall),
+	}
+	var files []string
+	if _, err := os.Stat(legacy); err == nil {
+		files = append(files, legacy)
+	}
+	files = append(files, dropin.Files(dirs, ".conf")...)
+
+	for _, path := range files {
+		if err := parseModprobeFile(path, config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+func parseModprobeFile(path string, config *modprobeConfig) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		lineNo  int
+		pending string
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line = pending + line
+		pending = ""
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		source := fmt.Sprintf("%s:%d: %s", path, lineNo, line)
+		switch fields[0] {
+		case "blacklist":
+			config.blacklist[normalize(fields[1])] = source
+		case "install":
+			// The first install line for a module wins, as in modprobe.
+			key := normalize(fields[1])
+			if _, ok := config.install[key]; !ok {
+				config.install[key] = modprobeInstall{
+					command: strings.Join(fields[2:], " "),
+					source:  source,
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// moduleName turns "kernel/fs/cramfs/cramfs.ko.zst" into "cramfs".
+func moduleName(path string) string {
+	name := filepath.Base(path)
+	if idx := strings.Index(name, ".ko"); idx >= 0 {
+		name = name[:idx]
+	}
+	return normalize(name)
+}
+
+// normalize treats dashes and underscores alike, as the kernel does.
+func normalize(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
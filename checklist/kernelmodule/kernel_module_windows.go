@@ -0,0 +1,5 @@
+package kernelmodule
+
+func GetKernelModules(modules []string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,117 @@
+package logconfig
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"checklist/dropin"
+)
+
+const (
+	auditdConfPath  = "/etc/audit/auditd.conf"
+	auditRulesPath  = "/etc/audit/audit.rules"
+	auditRulesDir   = "/etc/audit/rules.d"
+	auditRuleSuffix = ".rules"
+)
+
+// auditdKeys are the auditd.conf settings relevant to log retention and to
+// what happens when the audit partition fills up.
+var auditdKeys = []string{
+	"log_file",
+	"log_format",
+	"max_log_file",
+	"max_log_file_action",
+	"num_logs",
+	"space_left_action",
+	"admin_space_left_action",
+	"disk_full_action",
+	"disk_error_action",
+}
+
+// AuditRules summarises the audit rules that augenrules would load.
+type AuditRules struct {
+	Files     []string
+	Watches   []string
+	Syscalls  []string
+	Immutable bool
+	Enabled   string
+}
+
+// ParseAuditdConfig returns the key = value settings of auditd.conf.
+func ParseAuditdConfig(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ParseAuditRules reads every *.rules file in dir in the order augenrules
+// concatenates them. When dir has no rules the compiled rulesPath is used.
+func ParseAuditRules(dir, rulesPath string) (*AuditRules, error) {
+	files := dropin.Files([]string{dir}, auditRuleSuffix)
+	if len(files) == 0 {
+		if _, err := os.Stat(rulesPath); err != nil {
+			return nil, err
+		}
+		files = []string{rulesPath}
+	}
+
+	rules := &AuditRules{Files: files}
+	for _, path := range files {
+		if err := parseAuditRuleFile(path, rules); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+func parseAuditRuleFile(path string, rules *AuditRules) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "-w":
+			rules.Watches = append(rules.Watches, line)
+		case "-a", "-A":
+			rules.Syscalls = append(rules.Syscalls, line)
+		case "-e":
+			if len(fields) < 2 {
+				continue
+			}
+			// -e 2 locks the configuration until the next reboot.
+			rules.Enabled = fields[1]
+			rules.Immutable = fields[1] == "2"
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,87 @@
+package logconfig
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"checklist/dropin"
+)
+
+const journaldConfPath = "/etc/systemd/journald.conf"
+
+var journaldDropInDirs = []string{
+	"/usr/lib/systemd/journald.conf.d",
+	"/run/systemd/journald.conf.d",
+	"/etc/systemd/journald.conf.d",
+}
+
+// journaldDefaults are the values systemd uses when a key is not set.
+var journaldDefaults = map[string]string{
+	"Storage":         "auto",
+	"Compress":        "yes",
+	"ForwardToSyslog": "no",
+	"SystemMaxUse":    "10%",
+	"MaxRetentionSec": "0",
+	"MaxFileSec":      "1month",
+}
+
+// JournaldSetting is the effective value of one [Journal] key and the file
+// that set it; File is empty when the systemd default applies.
+type JournaldSetting struct {
+	Key   string
+	Value string
+	File  string
+}
+
+// ParseJournaldConfig returns the effective [Journal] settings after applying
+// journald.conf and its drop-ins in the order systemd reads them.
+func ParseJournaldConfig(mainPath string, dropInDirs []string) (map[string]JournaldSetting, error) {
+	settings := make(map[string]JournaldSetting)
+	for key, value := range journaldDefaults {
+		settings[key] = JournaldSetting{Key: key, Value: value}
+	}
+
+	paths := make([]string, 0)
+	if _, err := os.Stat(mainPath); err == nil {
+		paths = append(paths, mainPath)
+	}
+	paths = append(paths, dropin.Files(dropInDirs, ".conf")...)
+	for _, path := range paths {
+		if err := parseJournaldFile(path, settings); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+func parseJournaldFile(path string, settings map[string]JournaldSetting) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if section != "Journal" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		settings[key] = JournaldSetting{Key: key, Value: strings.TrimSpace(value), File: path}
+	}
+	return scanner.Err()
+}
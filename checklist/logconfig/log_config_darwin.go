@@ -1,5 +1,7 @@
 package logconfig
 
-func GetLogConfig() (string, error) {
+import "checklist/agent"
+
+func GetLogConfig(agents []agent.Agent) (string, error) {
 	return "", nil
 }
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
+
+	"checklist/agent"
 )
 
 var filesToCheck = []string{
@@ -13,14 +17,17 @@ var filesToCheck = []string{
 	"/etc/profile",
 }
 
-func GetLogConfig() (string, error) {
+func GetLogConfig(agents []agent.Agent) (string, error) {
 	var result string
 	result += "----------SIEM----------\n"
 	result += getBashrc()
 	result += getRsyslogConfig()
 	result += getRsyslogStatus()
-	result += "\n----------Kaspersky----------\n"
-	result += getKasperskyStatus()
+	result += getJournaldConfig()
+	result += getSyslogNGConfig()
+	result += getAuditdConfig()
+	result += "\n----------Agents----------\n"
+	result += agent.Format(agent.Collect(agents))
 	return result, nil
 }
 
@@ -62,48 +69,120 @@ func getBashrc() string {
 }
 
 func getRsyslogConfig() string {
-	result := "****/etc/rsyslog.conf****\n"
-	f, err := os.Open("/etc/rsyslog.conf")
+	result := fmt.Sprintf("****%s****\n", rsyslogConfPath)
+	rules, err := ParseRsyslogConfig(rsyslogConfPath)
 	if err != nil {
 		return result
 	}
-	defer f.Close()
 
-	found := map[string]bool{
-		"local6.* /var/log/cmdlog.log": false,
+	var cmdlog bool
+	for _, rule := range rules {
+		if strings.TrimPrefix(rule.Action, "-") == "/var/log/cmdlog.log" && SelectorCovers(rule.Selector, "local6", "debug") {
+			cmdlog = true
+		}
+	}
+	if cmdlog {
+		result += "+local6.* /var/log/cmdlog.log\n"
+	} else {
+		result += "-local6.* /var/log/cmdlog.log\n"
 	}
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if _, ok := found[line]; ok {
-			found[line] = true
+
+	result += "****rsyslog rules****\n"
+	var forwards []string
+	for _, rule := range rules {
+		result += fmt.Sprintf("%s:%d: %s %s\n", rule.File, rule.Line, rule.Selector, rule.Action)
+		if rule.Forward == nil {
+			continue
 		}
+		facilities := SelectorFacilities(rule.Selector)
+		if len(facilities) == 0 {
+			facilities = []string{rule.Selector}
+		}
+		forwards = append(forwards, fmt.Sprintf(`"%s": %s (%s:%d)`, strings.Join(facilities, ", "), rule.Forward, rule.File, rule.Line))
+	}
+
+	result += "****rsyslog forwarding****\n"
+	for _, fwd := range forwards {
+		result += fwd + "\n"
 	}
-	if err = scanner.Err(); err != nil {
+	return result
+}
+
+func getJournaldConfig() string {
+	result := fmt.Sprintf("****%s****\n", journaldConfPath)
+	settings, err := ParseJournaldConfig(journaldConfPath, journaldDropInDirs)
+	if err != nil {
 		return result
 	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		setting := settings[key]
+		source := setting.File
+		if source == "" {
+			source = "default"
+		}
+		result += fmt.Sprintf("%s=%s (%s)\n", key, setting.Value, source)
+	}
+	return result
+}
 
-	for fd, ok := range found {
-		if ok {
-			result += fmt.Sprintf("+%s\n", fd)
+func getSyslogNGConfig() string {
+	result := fmt.Sprintf("****%s****\n", syslogNGConfPath)
+	destinations, err := ParseSyslogNGConfig(syslogNGConfPath)
+	if err != nil {
+		return result
+	}
+	for _, dest := range destinations {
+		used := "unused"
+		if dest.Used {
+			used = "used"
+		}
+		if dest.Forward != nil {
+			result += fmt.Sprintf("%q: %s %s (%s)\n", dest.Name, dest.Driver, dest.Forward, used)
 		} else {
-			result += fmt.Sprintf("-%s\n", fd)
+			result += fmt.Sprintf("%q: %s %s (%s)\n", dest.Name, dest.Driver, dest.Target, used)
 		}
 	}
 	return result
 }
 
-func getRsyslogStatus() string {
-	result := "****systemctl status rsyslog****\n"
-	cmd := exec.Command("systemctl", "status", "rsyslog")
-	output, _ := cmd.CombinedOutput()
-	result += fmt.Sprintf("%s\n", string(output))
+func getAuditdConfig() string {
+	result := fmt.Sprintf("****%s****\n", auditdConfPath)
+	if settings, err := ParseAuditdConfig(auditdConfPath); err == nil {
+		for _, key := range auditdKeys {
+			if value, ok := settings[key]; ok {
+				result += fmt.Sprintf("+%s = %s\n", key, value)
+			} else {
+				result += fmt.Sprintf("-%s\n", key)
+			}
+		}
+	}
+
+	result += fmt.Sprintf("****%s****\n", auditRulesDir)
+	rules, err := ParseAuditRules(auditRulesDir, auditRulesPath)
+	if err != nil {
+		return result
+	}
+	result += fmt.Sprintf("files: %s\n", strings.Join(rules.Files, ", "))
+	result += fmt.Sprintf("watches: %d\n", len(rules.Watches))
+	result += fmt.Sprintf("syscall rules: %d\n", len(rules.Syscalls))
+	result += fmt.Sprintf("immutable: %t\n", rules.Immutable)
+	for _, rule := range rules.Watches {
+		result += rule + "\n"
+	}
+	for _, rule := range rules.Syscalls {
+		result += rule + "\n"
+	}
 	return result
 }
 
-func getKasperskyStatus() string {
-	result := ""
-	cmd := exec.Command("systemctl", "status", "kesl.service")
+func getRsyslogStatus() string {
+	result := "****systemctl status rsyslog****\n"
+	cmd := exec.Command("systemctl", "status", "rsyslog")
 	output, _ := cmd.CombinedOutput()
 	result += fmt.Sprintf("%s\n", string(output))
 	return result
@@ -0,0 +1,600 @@
+package logconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	rsyslogConfPath   = "/etc/rsyslog.conf"
+	rsyslogMaxInclude = 8
+	defaultSyslogPort = "514"
+)
+
+// RsyslogRule is one selector→action pair found in the rsyslog configuration.
+type RsyslogRule struct {
+	File     string
+	Line     int
+	Selector string
+	Action   string
+	Forward  *Forward
+}
+
+// Forward describes a remote target a logging action sends messages to.
+type Forward struct {
+	Target   string
+	Port     string
+	Protocol string
+}
+
+func (f *Forward) String() string {
+	target := f.Target
+	if strings.Contains(target, ":") {
+		target = "[" + target + "]"
+	}
+	return fmt.Sprintf("%s:%s/%s", target, f.Port, f.Protocol)
+}
+
+type rsyslogStatement struct {
+	file string
+	line int
+	text string
+}
+
+type rsyslogParser struct {
+	rules []RsyslogRule
+	seen  map[string]struct{}
+
+	// Legacy $ directives that change how later forwarding actions behave.
+	defaultDriver    string
+	actionDriverMode string
+	lastSelector     string
+
+	// Selectors of the enclosing { } blocks, and of an if or ruleset whose
+	// { is still to come.
+	blocks  []string
+	opening string
+}
+
+// ParseRsyslogConfig reads path and every file it includes, returning all
+// selector→action pairs in the order rsyslog would load them.
+func ParseRsyslogConfig(path string) ([]RsyslogRule, error) {
+	p := &rsyslogParser{seen: make(map[string]struct{})}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+	return p.rules, nil
+}
+
+func (p *rsyslogParser) parseFile(path string, depth int) error {
+	if depth > rsyslogMaxInclude {
+		return fmt.Errorf("include depth exceeded at %s", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := p.seen[abs]; ok {
+		return nil
+	}
+	p.seen[abs] = struct{}{}
+
+	statements, err := readRsyslogStatements(path)
+	if err != nil {
+		return err
+	}
+	for _, st := range statements {
+		if err = p.parseStatement(st, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rsyslogParser) parseStatement(st rsyslogStatement, depth int) error {
+	text := st.text
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, "$includeconfig"):
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil
+		}
+		return p.include(st.file, fields[1], depth)
+	case strings.HasPrefix(lower, "include("):
+		params := parseRainerParams(text)
+		if pattern, ok := params["file"]; ok {
+			return p.include(st.file, pattern, depth)
+		}
+		return nil
+	case strings.HasPrefix(lower, "$defaultnetstreamdriver "):
+		p.defaultDriver = strings.ToLower(lastField(text))
+		return nil
+	case strings.HasPrefix(lower, "$actionsendstreamdrivermode "):
+		p.actionDriverMode = lastField(text)
+		return nil
+	case strings.HasPrefix(lower, "global("):
+		if driver, ok := parseRainerParams(text)["defaultnetstreamdriver"]; ok {
+			p.defaultDriver = strings.ToLower(driver)
+		}
+		return nil
+	case strings.HasPrefix(text, "$"),
+		strings.HasPrefix(lower, "module("),
+		strings.HasPrefix(lower, "input("),
+		strings.HasPrefix(lower, "template("),
+		strings.HasPrefix(lower, "main_queue("),
+		strings.HasPrefix(lower, "lookup_table("),
+		strings.HasPrefix(lower, "set "),
+		strings.HasPrefix(lower, "unset "):
+		return nil
+	case text == "{":
+		p.blocks = append(p.blocks, p.opening)
+		return nil
+	case strings.HasPrefix(text, "}"):
+		if len(p.blocks) > 0 {
+			p.blocks = p.blocks[:len(p.blocks)-1]
+		}
+		return nil
+	case strings.HasPrefix(lower, "ruleset("):
+		// Actions inside a ruleset block have no selector of their own.
+		p.open(fmt.Sprintf("ruleset(%s)", parseRainerParams(text)["name"]), text)
+		return nil
+	case strings.HasPrefix(lower, "action("), lower == "stop":
+		// Outside any block an action applies to every message.
+		selector := "*.*"
+		if len(p.blocks) > 0 {
+			selector = p.blocks[len(p.blocks)-1]
+		}
+		p.addRule(st, selector, text)
+		return nil
+	case strings.HasPrefix(lower, "if "):
+		idx := strings.Index(lower, " then ")
+		if idx < 0 {
+			return nil
+		}
+		selector := strings.TrimSpace(text[len("if "):idx])
+		action := strings.TrimSpace(text[idx+len(" then "):])
+		if strings.HasPrefix(action, "{") && !strings.HasSuffix(action, "}") {
+			// The actions follow on their own lines inside the { } block.
+			p.open(selector, "{")
+			action = strings.TrimSpace(action[1:])
+			if action != "" {
+				p.addRule(st, selector, action)
+			}
+			return nil
+		}
+		action = strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(action, "}"), "{"))
+		p.addRule(st, selector, action)
+		return nil
+	case strings.HasPrefix(text, "&"):
+		p.addRule(st, p.lastSelector, strings.TrimSpace(text[1:]))
+		return nil
+	case strings.HasPrefix(text, ":"):
+		// Property based filter, e.g. :msg, contains, "error" /var/log/error.log
+		selector, action := splitPropertyFilter(text)
+		p.addRule(st, selector, action)
+		return nil
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return nil
+	}
+	selector := fields[0]
+	action := strings.TrimSpace(text[len(selector):])
+	p.addRule(st, selector, action)
+	return nil
+}
+
+// open starts a block with selector when text ends with its {, or keeps
+// the selector for a { on its own line.
+func (p *rsyslogParser) open(selector, text string) {
+	p.opening = selector
+	if strings.HasSuffix(text, "{") {
+		p.blocks = append(p.blocks, selector)
+	}
+}
+
+func (p *rsyslogParser) include(from, pattern string, depth int) error {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+	slices.Sort(matches)
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		files := []string{match}
+		if info.IsDir() {
+			// A directory includes every file in it, in name order.
+			if files, err = dirFiles(match); err != nil {
+				return err
+			}
+		}
+		for _, file := range files {
+			if err = p.parseFile(file, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dirFiles returns the regular files directly in dir, sorted by name.
+func dirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+func (p *rsyslogParser) addRule(st rsyslogStatement, selector, action string) {
+	if selector == "" || action == "" {
+		return
+	}
+	p.lastSelector = selector
+	p.rules = append(p.rules, RsyslogRule{
+		File:     st.file,
+		Line:     st.line,
+		Selector: selector,
+		Action:   action,
+		Forward:  p.parseForward(action),
+	})
+}
+
+func (p *rsyslogParser) parseForward(action string) *Forward {
+	lower := strings.ToLower(action)
+	if strings.HasPrefix(lower, "action(") {
+		params := parseRainerParams(action)
+		switch strings.ToLower(params["type"]) {
+		case "omfwd":
+			fwd := &Forward{
+				Target:   params["target"],
+				Port:     params["port"],
+				Protocol: strings.ToLower(params["protocol"]),
+			}
+			if fwd.Protocol == "" {
+				fwd.Protocol = "udp"
+			}
+			if fwd.Protocol == "tcp" && params["streamdrivermode"] == "1" {
+				driver := strings.ToLower(params["streamdriver"])
+				if driver == "" {
+					driver = p.defaultDriver
+				}
+				if driver != "" && driver != "ptcp" {
+					fwd.Protocol = "tls"
+				}
+			}
+			if fwd.Port == "" {
+				fwd.Port = defaultSyslogPort
+			}
+			return fwd
+		case "omrelp":
+			fwd := &Forward{
+				Target:   params["target"],
+				Port:     params["port"],
+				Protocol: "relp",
+			}
+			if strings.EqualFold(params["tls"], "on") {
+				fwd.Protocol = "relp+tls"
+			}
+			if fwd.Port == "" {
+				fwd.Port = defaultSyslogPort
+			}
+			return fwd
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(action, "@") {
+		return nil
+	}
+	protocol := "udp"
+	dest := strings.TrimPrefix(action, "@")
+	if strings.HasPrefix(dest, "@") {
+		protocol = "tcp"
+		dest = dest[1:]
+		if p.actionDriverMode == "1" && p.defaultDriver != "" && p.defaultDriver != "ptcp" {
+			protocol = "tls"
+		}
+	}
+	if strings.HasPrefix(dest, "(") {
+		if end := strings.Index(dest, ")"); end >= 0 {
+			dest = dest[end+1:]
+		}
+	}
+	if idx := strings.IndexAny(dest, "; \t"); idx >= 0 {
+		dest = dest[:idx]
+	}
+	target, port := splitHostPort(dest)
+	return &Forward{Target: target, Port: port, Protocol: protocol}
+}
+
+// SelectorCovers reports whether a traditional selector such as
+// "*.info;mail.none" matches messages of the given facility and priority.
+func SelectorCovers(selector, facility, priority string) bool {
+	want := syslogPriority(priority)
+	var covered bool
+	for _, part := range strings.Split(selector, ";") {
+		facs, prio, ok := strings.Cut(strings.TrimSpace(part), ".")
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(strings.Split(facs, ","), func(f string) bool {
+			return f == "*" || strings.EqualFold(f, facility)
+		}) {
+			continue
+		}
+		switch {
+		case prio == "none":
+			covered = false
+		case prio == "*":
+			covered = true
+		case strings.HasPrefix(prio, "!="):
+			if want == syslogPriority(prio[2:]) {
+				covered = false
+			}
+		case strings.HasPrefix(prio, "!"):
+			if want >= syslogPriority(prio[1:]) {
+				covered = false
+			}
+		case strings.HasPrefix(prio, "="):
+			if want == syslogPriority(prio[1:]) {
+				covered = true
+			}
+		default:
+			if want >= syslogPriority(prio) {
+				covered = true
+			}
+		}
+	}
+	return covered
+}
+
+// SelectorFacilities returns the facilities a traditional selector enables,
+// ignoring those only mentioned to exclude them with ".none".
+func SelectorFacilities(selector string) []string {
+	var facilities []string
+	for _, part := range strings.Split(selector, ";") {
+		facs, prio, ok := strings.Cut(strings.TrimSpace(part), ".")
+		if !ok || prio == "none" {
+			continue
+		}
+		facilities = append(facilities, strings.Split(facs, ",")...)
+	}
+	slices.Sort(facilities)
+	return slices.Compact(facilities)
+}
+
+var syslogPriorities = map[string]int{
+	"debug":   0,
+	"info":    1,
+	"notice":  2,
+	"warning": 3,
+	"warn":    3,
+	"err":     4,
+	"error":   4,
+	"crit":    5,
+	"alert":   6,
+	"emerg":   7,
+	"panic":   7,
+}
+
+func syslogPriority(name string) int {
+	if p, ok := syslogPriorities[strings.ToLower(name)]; ok {
+		return p
+	}
+	return 0
+}
+
+func readRsyslogStatements(path string) ([]rsyslogStatement, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		statements []rsyslogStatement
+		pending    strings.Builder
+		start      int
+		depth      int
+		lineNo     int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if pending.Len() == 0 {
+			start = lineNo
+		} else {
+			pending.WriteByte(' ')
+		}
+		// A trailing backslash continues the statement on the next line.
+		line, continued := strings.CutSuffix(line, "\\")
+		pending.WriteString(strings.TrimSpace(line))
+		depth += parenDepth(line)
+		if depth > 0 || continued || openIf(pending.String()) {
+			continue
+		}
+		statements = append(statements, rsyslogStatement{file: path, line: start, text: pending.String()})
+		pending.Reset()
+		depth = 0
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pending.Len() > 0 {
+		statements = append(statements, rsyslogStatement{file: path, line: start, text: pending.String()})
+	}
+	return statements, nil
+}
+
+// openIf reports whether text is an if statement whose then and action
+// are still to come.
+func openIf(text string) bool {
+	lower := strings.ToLower(text)
+	return strings.HasPrefix(lower, "if ") && !strings.Contains(lower, " then ")
+}
+
+// stripComment removes a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parenDepth(line string) int {
+	var (
+		depth int
+		quote byte
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		}
+	}
+	return depth
+}
+
+// parseRainerParams extracts name="value" pairs from a RainerScript object
+// such as action(type="omfwd" target="10.0.0.1"). Names are lower-cased.
+func parseRainerParams(text string) map[string]string {
+	params := make(map[string]string)
+	open := strings.Index(text, "(")
+	if open < 0 {
+		return params
+	}
+	body := text[open+1:]
+	for {
+		eq := strings.Index(body, "=")
+		if eq < 0 {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(body[:eq]))
+		if idx := strings.LastIndexAny(name, " \t,"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		rest := strings.TrimLeft(body[eq+1:], " \t")
+		if rest == "" {
+			break
+		}
+		var value string
+		if rest[0] == '"' || rest[0] == '\'' {
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t)")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+		params[name] = value
+		body = rest
+	}
+	return params
+}
+
+func splitPropertyFilter(text string) (string, string) {
+	// :property, compare-operation, "value" action
+	var quote bool
+	commas := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			if i == 0 || text[i-1] != '\\' {
+				quote = !quote
+				if !quote && commas == 2 {
+					return strings.TrimSpace(text[:i+1]), strings.TrimSpace(text[i+1:])
+				}
+			}
+		case ',':
+			if !quote {
+				commas++
+			}
+		}
+	}
+	return text, ""
+}
+
+func splitHostPort(dest string) (string, string) {
+	if strings.HasPrefix(dest, "[") {
+		end := strings.Index(dest, "]")
+		if end < 0 {
+			return dest, defaultSyslogPort
+		}
+		host := dest[1:end]
+		if port, ok := strings.CutPrefix(dest[end+1:], ":"); ok && port != "" {
+			return host, port
+		}
+		return host, defaultSyslogPort
+	}
+	if strings.Count(dest, ":") == 1 {
+		host, port, _ := strings.Cut(dest, ":")
+		if port == "" {
+			port = defaultSyslogPort
+		}
+		return host, port
+	}
+	return dest, defaultSyslogPort
+}
+
+func lastField(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
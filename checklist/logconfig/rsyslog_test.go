@@ -0,0 +1,83 @@
+package logconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// forwards lists the forwarding rules as "selector -> target file:line".
+func forwards(t *testing.T, path string) []string {
+	t.Helper()
+	rules, err := ParseRsyslogConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rules {
+		if r.Forward != nil {
+			got = append(got, fmt.Sprintf("%s -> %s %s:%d", r.Selector, r.Forward, filepath.Base(r.File), r.Line))
+		}
+	}
+	return got
+}
+
+func TestRsyslogLegacy(t *testing.T) {
+	want := []string{
+		"*.* -> 192.168.1.100:10514/tcp legacy.conf:4",
+		"auth,authpriv.* -> 192.168.100.104:514/udp legacy.conf:5",
+		"kern.* -> [2001:db8::1]:1514/udp legacy.conf:6",
+		"local0.* -> siem.example.com:6514/tls 20-tls.conf:3",
+	}
+	if got := forwards(t, "testdata/rsyslog/legacy.conf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestRsyslogIncludeDirectory(t *testing.T) {
+	rules, err := ParseRsyslogConfig("testdata/rsyslog/legacy.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, r := range rules {
+		if base := filepath.Base(r.File); len(files) == 0 || files[len(files)-1] != base {
+			files = append(files, base)
+		}
+	}
+	// The directory's files follow in name order.
+	if want := []string{"legacy.conf", "10-first.conf", "20-tls.conf"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files: got %q, want %q", files, want)
+	}
+}
+
+func TestRsyslogRainerScript(t *testing.T) {
+	want := []string{
+		"*.* -> 192.168.1.100:10514/tcp rainer.conf:4",
+		`$syslogfacility-text == "authpriv" -> 192.168.100.104:514/tls rainer.conf:6`,
+		"authpriv.* -> 10.0.0.9:514/udp rainer.conf:10",
+		"*.* -> relp.example.com:2514/relp+tls 50-relp.conf:1",
+	}
+	if got := forwards(t, "testdata/rsyslog/rainer.conf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestSelectorCovers(t *testing.T) {
+	for _, tc := range []struct {
+		selector, facility, priority string
+		want                         bool
+	}{
+		{"*.info;mail.none", "auth", "info", true},
+		{"*.info;mail.none", "mail", "err", false},
+		{"*.info", "auth", "debug", false},
+		{"auth,authpriv.*", "authpriv", "debug", true},
+		{"*.*;auth.!err", "auth", "crit", false},
+		{"kern.=warn", "kern", "err", false},
+	} {
+		if got := SelectorCovers(tc.selector, tc.facility, tc.priority); got != tc.want {
+			t.Errorf("%s %s.%s: got %t, want %t", tc.selector, tc.facility, tc.priority, got, tc.want)
+		}
+	}
+}
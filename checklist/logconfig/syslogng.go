@@ -0,0 +1,357 @@
+package logconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	syslogNGConfPath   = "/etc/syslog-ng/syslog-ng.conf"
+	syslogNGMaxInclude = 8
+)
+
+// SyslogNGDestination is one driver inside a syslog-ng destination block.
+type SyslogNGDestination struct {
+	Name    string
+	Driver  string
+	Target  string
+	Forward *Forward
+	Used    bool
+}
+
+// syslogNGNode is a driver or option call such as network("10.0.0.1" port(514)).
+type syslogNGNode struct {
+	name     string
+	args     []string
+	children []*syslogNGNode
+}
+
+func (n *syslogNGNode) option(name string) string {
+	for _, c := range n.children {
+		if strings.EqualFold(strings.ReplaceAll(c.name, "_", "-"), name) && len(c.args) > 0 {
+			return c.args[0]
+		}
+	}
+	return ""
+}
+
+func (n *syslogNGNode) hasOption(name string) bool {
+	return slices.ContainsFunc(n.children, func(c *syslogNGNode) bool {
+		return strings.EqualFold(c.name, name)
+	})
+}
+
+type syslogNGParser struct {
+	destinations []SyslogNGDestination
+	used         map[string]struct{}
+	seen         map[string]struct{}
+	anonymous    int
+}
+
+// ParseSyslogNGConfig reads a syslog-ng configuration with its @include
+// files and returns every destination driver, marking the ones referenced
+// from a log path.
+func ParseSyslogNGConfig(path string) ([]SyslogNGDestination, error) {
+	p := &syslogNGParser{
+		used: make(map[string]struct{}),
+		seen: make(map[string]struct{}),
+	}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+	for i := range p.destinations {
+		_, p.destinations[i].Used = p.used[p.destinations[i].Name]
+	}
+	return p.destinations, nil
+}
+
+func (p *syslogNGParser) parseFile(path string, depth int) error {
+	if depth > syslogNGMaxInclude {
+		return fmt.Errorf("include depth exceeded at %s", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := p.seen[abs]; ok {
+		return nil
+	}
+	p.seen[abs] = struct{}{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tokens := tokenizeSyslogNG(string(content))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok == "@include" && i+1 < len(tokens) {
+			i++
+			if err = p.include(path, tokens[i], depth); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(tok, "@") {
+			// @version, @define and friends take a single argument.
+			i++
+			continue
+		}
+		switch tok {
+		case "destination":
+			// destination NAME { driver(...); ... };
+			if i+2 < len(tokens) && tokens[i+2] == "{" {
+				name := tokens[i+1]
+				body, next := syslogNGBlock(tokens, i+2)
+				p.addDestinations(name, body)
+				i = next
+			}
+		case "log":
+			if i+1 < len(tokens) && tokens[i+1] == "{" {
+				body, next := syslogNGBlock(tokens, i+1)
+				p.parseLogPath(body)
+				i = next
+			}
+		}
+	}
+	return nil
+}
+
+func (p *syslogNGParser) include(from, pattern string, depth int) error {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		pattern = filepath.Join(pattern, "*")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+	slices.Sort(matches)
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if err = p.parseFile(match, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *syslogNGParser) addDestinations(name string, body []string) {
+	for _, node := range parseSyslogNGNodes(body) {
+		dest := SyslogNGDestination{Name: name, Driver: node.name}
+		if len(node.args) > 0 {
+			dest.Target = node.args[0]
+		}
+		dest.Forward = syslogNGForward(node)
+		p.destinations = append(p.destinations, dest)
+	}
+}
+
+func (p *syslogNGParser) parseLogPath(body []string) {
+	for _, node := range parseSyslogNGNodes(body) {
+		if node.name != "destination" {
+			continue
+		}
+		if len(node.args) > 0 {
+			p.used[node.args[0]] = struct{}{}
+			continue
+		}
+		if len(node.children) > 0 {
+			// Inline destination { network(...); } inside the log path.
+			p.anonymous++
+			name := fmt.Sprintf("<inline #%d>", p.anonymous)
+			for _, child := range node.children {
+				dest := SyslogNGDestination{Name: name, Driver: child.name, Used: true}
+				if len(child.args) > 0 {
+					dest.Target = child.args[0]
+				}
+				dest.Forward = syslogNGForward(child)
+				p.destinations = append(p.destinations, dest)
+			}
+			p.used[name] = struct{}{}
+		}
+	}
+}
+
+func syslogNGForward(node *syslogNGNode) *Forward {
+	if len(node.args) == 0 {
+		return nil
+	}
+	fwd := &Forward{Target: node.args[0], Port: node.option("port")}
+	if fwd.Port == "" {
+		fwd.Port = node.option("destport")
+	}
+	transport := strings.ToLower(node.option("transport"))
+	switch node.name {
+	case "tcp", "tcp6":
+		fwd.Protocol = "tcp"
+	case "udp", "udp6":
+		fwd.Protocol = "udp"
+	case "network", "syslog":
+		fwd.Protocol = "tcp"
+		if transport != "" {
+			fwd.Protocol = transport
+		}
+	default:
+		return nil
+	}
+	if node.hasOption("tls") && fwd.Protocol == "tcp" {
+		fwd.Protocol = "tls"
+	}
+	if fwd.Port == "" {
+		switch {
+		case fwd.Protocol == "tls":
+			fwd.Port = "6514"
+		case node.name == "syslog":
+			fwd.Port = "601"
+		default:
+			fwd.Port = defaultSyslogPort
+		}
+	}
+	return fwd
+}
+
+// syslogNGBlock returns the tokens between the brace at open and its match,
+// and the index of the token that closes the statement.
+func syslogNGBlock(tokens []string, open int) ([]string, int) {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				end := i
+				if i+1 < len(tokens) && tokens[i+1] == ";" {
+					end = i + 1
+				}
+				return tokens[open+1 : i], end
+			}
+		}
+	}
+	return tokens[open+1:], len(tokens)
+}
+
+// parseSyslogNGNodes parses a sequence of name(args...); calls. A call may
+// also carry a brace block, as inline destinations in a log path do.
+func parseSyslogNGNodes(tokens []string) []*syslogNGNode {
+	var nodes []*syslogNGNode
+	for i := 0; i < len(tokens); i++ {
+		if isSyslogNGPunct(tokens[i]) {
+			continue
+		}
+		node := &syslogNGNode{name: tokens[i]}
+		switch {
+		case i+1 < len(tokens) && tokens[i+1] == "(":
+			end := matchingSyslogNG(tokens, i+1, "(", ")")
+			node.args, node.children = parseSyslogNGArgs(tokens[i+2 : end])
+			i = end
+		case i+1 < len(tokens) && tokens[i+1] == "{":
+			body, end := syslogNGBlock(tokens, i+1)
+			node.children = parseSyslogNGNodes(body)
+			i = end
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func parseSyslogNGArgs(tokens []string) ([]string, []*syslogNGNode) {
+	var (
+		args     []string
+		children []*syslogNGNode
+	)
+	for i := 0; i < len(tokens); i++ {
+		if isSyslogNGPunct(tokens[i]) {
+			continue
+		}
+		if i+1 < len(tokens) && tokens[i+1] == "(" {
+			end := matchingSyslogNG(tokens, i+1, "(", ")")
+			child := &syslogNGNode{name: tokens[i]}
+			child.args, child.children = parseSyslogNGArgs(tokens[i+2 : end])
+			children = append(children, child)
+			i = end
+			continue
+		}
+		args = append(args, tokens[i])
+	}
+	return args, children
+}
+
+func matchingSyslogNG(tokens []string, open int, left, right string) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i] {
+		case left:
+			depth++
+		case right:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+func isSyslogNGPunct(tok string) bool {
+	switch tok {
+	case "(", ")", "{", "}", ";", ",":
+		return true
+	}
+	return false
+}
+
+// tokenizeSyslogNG splits a syslog-ng configuration into identifiers, quoted
+// strings (unquoted) and punctuation, dropping # comments.
+func tokenizeSyslogNG(content string) []string {
+	var (
+		tokens []string
+		cur    strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '#':
+			flush()
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			flush()
+			j := i + 1
+			for j < len(content) && content[j] != c {
+				if content[j] == '\\' && c == '"' {
+					j++
+				}
+				j++
+			}
+			tokens = append(tokens, content[min(i+1, len(content)):min(j, len(content))])
+			i = j
+		case c == '(' || c == ')' || c == '{' || c == '}' || c == ';' || c == ',':
+			flush()
+			tokens = append(tokens, string(c))
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens
+}
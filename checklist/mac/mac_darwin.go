@@ -0,0 +1,5 @@
+package mac
+
+func GetMAC() (string, error) {
+	return "", nil
+}
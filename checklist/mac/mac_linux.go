@@ -0,0 +1,256 @@
+package mac
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"checklist/platform"
+)
+
+const (
+	selinuxFS       = "/sys/fs/selinux"
+	selinuxConfig   = "/etc/selinux/config"
+	apparmorFS      = "/sys/kernel/security/apparmor"
+	apparmorEnabled = "/sys/module/apparmor/parameters/enabled"
+
+	SELinux  = "selinux"
+	AppArmor = "apparmor"
+)
+
+// unconfinedDomains are the SELinux domains CIS treats as unconfined.
+var unconfinedDomains = []string{
+	"unconfined_t",
+	"unconfined_service_t",
+	"initrc_t",
+}
+
+// apparmorFamilies are the distribution IDs that ship AppArmor as their MAC;
+// everything else is expected to use SELinux.
+var apparmorFamilies = []string{
+	"ubuntu",
+	"debian",
+	"suse",
+	"opensuse",
+	"sles",
+}
+
+// SELinuxStatus is the runtime and configured SELinux state.
+type SELinuxStatus struct {
+	Enabled          bool
+	RuntimeMode      string
+	ConfiguredMode   string
+	ConfiguredPolicy string
+	Unconfined       []string
+}
+
+// AppArmorStatus is the AppArmor module and profile state.
+type AppArmorStatus struct {
+	Enabled    bool
+	Enforce    []string
+	Complain   []string
+	Other      []string
+	Unconfined []string
+}
+
+func GetMAC() (string, error) {
+	expected := expectedMAC(platform.Detect())
+	var result string
+	result += fmt.Sprintf("expected: %s\n", expected)
+
+	selinux := InspectSELinux()
+	apparmor := InspectAppArmor()
+	switch expected {
+	case SELinux:
+		result += formatSELinux(selinux)
+		if apparmor.Enabled {
+			result += "****apparmor****\n"
+			result += "enabled: true\n"
+		}
+	case AppArmor:
+		result += formatAppArmor(apparmor)
+		if selinux.Enabled {
+			result += "****selinux****\n"
+			result += "enabled: true\n"
+		}
+	}
+	return result, nil
+}
+
+func formatSELinux(st SELinuxStatus) string {
+	result := "****selinux****\n"
+	result += fmt.Sprintf("enabled: %t\n", st.Enabled)
+	result += fmt.Sprintf("runtime mode: %s\n", st.RuntimeMode)
+	result += fmt.Sprintf("configured mode: %s\n", valueOr(st.ConfiguredMode, "<not set>"))
+	result += fmt.Sprintf("configured policy: %s\n", valueOr(st.ConfiguredPolicy, "<not set>"))
+	if st.ConfiguredMode != "" && st.ConfiguredMode != st.RuntimeMode {
+		result += fmt.Sprintf("-runtime mode %s differs from configured mode %s\n", st.RuntimeMode, st.ConfiguredMode)
+	}
+	result += fmt.Sprintf("unconfined processes: %d\n", len(st.Unconfined))
+	for _, p := range st.Unconfined {
+		result += p + "\n"
+	}
+	return result
+}
+
+func formatAppArmor(st AppArmorStatus) string {
+	result := "****apparmor****\n"
+	result += fmt.Sprintf("enabled: %t\n", st.Enabled)
+	result += fmt.Sprintf("profiles loaded: %d\n", len(st.Enforce)+len(st.Complain)+len(st.Other))
+	result += fmt.Sprintf("enforce: %d\n", len(st.Enforce))
+	result += fmt.Sprintf("complain: %d\n", len(st.Complain))
+	for _, p := range st.Complain {
+		result += fmt.Sprintf("-%s (complain)\n", p)
+	}
+	result += fmt.Sprintf("unconfined processes: %d\n", len(st.Unconfined))
+	for _, p := range st.Unconfined {
+		result += p + "\n"
+	}
+	return result
+}
+
+// expectedMAC picks the MAC the distribution ships by default.
+func expectedMAC(info platform.Info) string {
+	ids := append([]string{info.ID}, info.Like...)
+	for _, id := range ids {
+		if slices.Contains(apparmorFamilies, id) {
+			return AppArmor
+		}
+	}
+	if info.ID == "" {
+		// Without os-release, go by whichever MAC the kernel has enabled.
+		if _, err := os.Stat(apparmorFS); err == nil {
+			return AppArmor
+		}
+	}
+	return SELinux
+}
+
+// InspectSELinux reads selinuxfs and /etc/selinux/config.
+func InspectSELinux() SELinuxStatus {
+	st := SELinuxStatus{RuntimeMode: "disabled"}
+	if enforce, err := os.ReadFile(filepath.Join(selinuxFS, "enforce")); err == nil {
+		st.Enabled = true
+		st.RuntimeMode = "permissive"
+		if strings.TrimSpace(string(enforce)) == "1" {
+			st.RuntimeMode = "enforcing"
+		}
+	}
+
+	if f, err := os.Open(selinuxConfig); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			switch strings.TrimSpace(key) {
+			case "SELINUX":
+				st.ConfiguredMode = strings.ToLower(strings.TrimSpace(value))
+			case "SELINUXTYPE":
+				st.ConfiguredPolicy = strings.TrimSpace(value)
+			}
+		}
+		f.Close()
+	}
+
+	if st.Enabled {
+		st.Unconfined = processesWithLabel(filepath.Join("attr", "current"), func(label string) bool {
+			parts := strings.Split(label, ":")
+			return len(parts) >= 3 && slices.Contains(unconfinedDomains, parts[2])
+		})
+	}
+	return st
+}
+
+// InspectAppArmor reads the loaded profiles from securityfs.
+func InspectAppArmor() AppArmorStatus {
+	var st AppArmorStatus
+	if enabled, err := os.ReadFile(apparmorEnabled); err == nil {
+		st.Enabled = strings.TrimSpace(string(enabled)) == "Y"
+	}
+
+	if f, err := os.Open(filepath.Join(apparmorFS, "profiles")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			name, mode := splitProfile(scanner.Text())
+			switch mode {
+			case "enforce":
+				st.Enforce = append(st.Enforce, name)
+			case "complain":
+				st.Complain = append(st.Complain, name)
+			default:
+				st.Other = append(st.Other, name)
+			}
+		}
+		f.Close()
+	}
+	slices.Sort(st.Complain)
+
+	if st.Enabled {
+		// Newer kernels keep the AppArmor label under attr/apparmor.
+		attr := filepath.Join("attr", "apparmor", "current")
+		if _, err := os.Stat(filepath.Join("/proc/self", attr)); err != nil {
+			attr = filepath.Join("attr", "current")
+		}
+		st.Unconfined = processesWithLabel(attr, func(label string) bool {
+			return label == "unconfined"
+		})
+	}
+	return st
+}
+
+// splitProfile parses "name (mode)" as listed in the profiles file.
+func splitProfile(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	open := strings.LastIndex(line, " (")
+	if open < 0 || !strings.HasSuffix(line, ")") {
+		return line, ""
+	}
+	return line[:open], line[open+2 : len(line)-1]
+}
+
+// processesWithLabel lists "pid comm label" for every process whose security
+// label read from /proc/<pid>/<attr> satisfies match. Kernel threads have
+// no command line and are skipped.
+func processesWithLabel(attr string, match func(string) bool) []string {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	var processes []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.Trim(e.Name(), "0123456789") != "" {
+			continue
+		}
+		dir := filepath.Join("/proc", e.Name())
+		label, err := os.ReadFile(filepath.Join(dir, attr))
+		if err != nil {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimRight(string(label), "\x00"))
+		if !match(value) {
+			continue
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err != nil || len(cmdline) == 0 {
+			continue
+		}
+		comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+		processes = append(processes, fmt.Sprintf("%s %s %s", e.Name(), strings.TrimSpace(string(comm)), value))
+	}
+	return processes
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -5,22 +5,37 @@ import (
 	"os"
 
 	"checklist/account"
+	"checklist/agent"
 	"checklist/file"
 	"checklist/filechecksum"
 	"checklist/firewall"
+	"checklist/kernelmodule"
 	"checklist/logconfig"
+	"checklist/mac"
+	"checklist/mount"
 	"checklist/passwordpolicy"
 	"checklist/patching"
 	"checklist/port"
+	"checklist/service"
 	"checklist/ssh"
+	"checklist/sysctl"
+	"checklist/tcpwrappers"
+	"checklist/timesync"
 	"checklist/usergroup"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	folders []string
-	files   []string
+	folders      []string
+	files        []string
+	agentCatalog string
+	recursive    bool
+	fileOptions  = file.DefaultOptions()
+	hashOptions  = filechecksum.DefaultOptions()
+	modules      []string
+	sysctls      []string
+	denylist     string
 )
 
 var rootCmd = &cobra.Command{
@@ -33,6 +48,17 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.Flags().StringSliceVarP(&folders, "folders", "f", []string{}, "folders to check")
 	rootCmd.Flags().StringSliceVarP(&files, "files", "F", []string{}, "files to check")
+	rootCmd.Flags().StringSliceVar(&hashOptions.Algorithms, "algorithms", hashOptions.Algorithms, "checksum algorithms: sha256, sha512, sha1, md5, blake2b")
+	rootCmd.Flags().IntVar(&hashOptions.Workers, "workers", hashOptions.Workers, "number of files to hash in parallel")
+	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "walk folders recursively and report metadata and findings")
+	rootCmd.Flags().IntVar(&fileOptions.MaxDepth, "max-depth", -1, "maximum recursion depth, negative for unlimited")
+	rootCmd.Flags().StringSliceVar(&fileOptions.Excludes, "exclude", []string{}, "glob patterns to skip while walking folders")
+	rootCmd.Flags().BoolVar(&fileOptions.FollowSymlinks, "follow-symlinks", false, "follow symbolic links while walking folders")
+	rootCmd.Flags().BoolVar(&fileOptions.OneFilesystem, "one-file-system", false, "do not cross file system boundaries while walking folders")
+	rootCmd.Flags().StringSliceVar(&modules, "modules", []string{}, "kernel modules to check, defaults to the CIS file system list")
+	rootCmd.Flags().StringSliceVar(&sysctls, "sysctl", []string{}, "key=value kernel parameters to check, defaults to the CIS network set")
+	rootCmd.Flags().StringVar(&agentCatalog, "agents", "", "JSON file describing the endpoint agents to detect")
+	rootCmd.Flags().StringVar(&denylist, "denylist", "", "JSON file describing the unwanted services to detect")
 }
 
 func main() {
@@ -62,17 +88,53 @@ func runChecklist(cmd *cobra.Command, args []string) {
 	case "1095", "11095", "3096", "5096", "2096", "295", "5", "4031":
 		result, err = patching.GetPatching()
 	case "1096", "11096", "3097", "5097", "2097", "296", "6", "4032":
-		result = filechecksum.Checksums(files)
+		result, err = filechecksum.Checksums(files, hashOptions)
 	case "1097", "11097", "3098", "5098", "2098", "297", "7", "4033":
-		result = file.Files(folders)
+		if recursive {
+			result = file.Inventory(folders, fileOptions)
+		} else {
+			result = file.Files(folders)
+		}
 	case "1098", "11098", "3099", "5099", "2099", "298", "8", "4034":
 		result, err = port.GetPorts()
 	case "1099", "11099", "3100", "5100", "2100", "299", "9", "4035":
 		result, err = firewall.GetRules()
 	case "1100", "11100", "3101", "5101", "2101", "300", "10", "4036":
-		result, err = logconfig.GetLogConfig()
+		agents := agent.DefaultCatalog
+		if agentCatalog != "" {
+			agents, err = agent.LoadCatalog(agentCatalog)
+			if err != nil {
+				break
+			}
+		}
+		result, err = logconfig.GetLogConfig(agents)
+	case "1101", "11101", "3102", "5102", "2102", "301", "11":
+		result, err = kernelmodule.GetKernelModules(modules)
+	case "1102", "11102", "3103", "5103", "2103", "302", "12":
+		result, err = mount.GetMounts()
+	case "1103", "11103", "3104", "5104", "2104", "303", "13":
+		result, err = sysctl.GetSysctl(sysctls)
+	case "1104", "11104", "3105", "5105", "2105", "304", "14":
+		result, err = mac.GetMAC()
+	case "1105", "11105", "3106", "5106", "2106", "305", "15":
+		result, err = timesync.GetTimeSync()
+	case "1106", "11106", "3107", "5107", "2107", "306", "16":
+		services := service.DefaultDenylist()
+		if denylist != "" {
+			services, err = service.LoadDenylist(denylist)
+			if err != nil {
+				break
+			}
+		}
+		result, err = service.GetServices(services)
+	case "1107", "11107", "3108", "5108", "2108", "307", "17":
+		result, err = tcpwrappers.GetTCPWrappers()
 	default:
-		fmt.Fprintf(os.Stderr, "Error: invalid id: %s\n", id)
+		if script, ok := localScript(id); ok {
+			fmt.Fprintf(os.Stderr, "Error: %s is a script check, run %s on this host\n", id, script)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: invalid id: %s\n", id)
+		}
 		os.Exit(1)
 	}
 	if err != nil {
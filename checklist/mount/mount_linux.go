@@ -0,0 +1,344 @@
+package mount
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// securityMounts maps each mount point CIS wants on its own file system to
+// the options it should carry.
+var securityMounts = []struct {
+	path    string
+	options []string
+}{
+	{"/tmp", []string{"nodev", "nosuid", "noexec"}},
+	{"/dev/shm", []string{"nodev", "nosuid", "noexec"}},
+	{"/home", []string{"nodev", "nosuid"}},
+	{"/var", []string{"nodev", "nosuid"}},
+	{"/var/tmp", []string{"nodev", "nosuid", "noexec"}},
+	{"/var/log", []string{"nodev", "nosuid", "noexec"}},
+	{"/var/log/audit", []string{"nodev", "nosuid", "noexec"}},
+}
+
+var mountUnitDirs = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/lib/systemd/system",
+	"/lib/systemd/system",
+}
+
+// Mount is one entry of /proc/self/mountinfo.
+type Mount struct {
+	ID         int
+	Device     string
+	Root       string
+	MountPoint string
+	FSType     string
+	Source     string
+	Options    []string
+}
+
+// Configured is a persisted mount from /etc/fstab or a systemd .mount unit.
+type Configured struct {
+	Source     string
+	MountPoint string
+	FSType     string
+	Options    []string
+	Origin     string
+}
+
+// Status is the report for one security-relevant mount point.
+type Status struct {
+	Path              string
+	Separate          bool
+	Mount             *Mount
+	BindOf            string
+	Configured        *Configured
+	MissingEffective  []string
+	MissingConfigured []string
+	NotApplied        []string
+}
+
+func GetMounts() (string, error) {
+	mounts, err := ParseMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	configured, err := ParseFstab("/etc/fstab")
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	configured = append(configured, parseMountUnits(mountUnitDirs)...)
+
+	var result string
+	for _, st := range Evaluate(mounts, configured) {
+		result += fmt.Sprintf("****%s****\n", st.Path)
+		result += fmt.Sprintf("separate: %t\n", st.Separate)
+		if st.Mount != nil {
+			result += fmt.Sprintf("mounted: %s %s %s\n", st.Mount.Source, st.Mount.FSType, strings.Join(st.Mount.Options, ","))
+		}
+		if st.BindOf != "" {
+			result += fmt.Sprintf("bind of: %s\n", st.BindOf)
+		}
+		if st.Configured != nil {
+			result += fmt.Sprintf("configured (%s): %s %s %s\n", st.Configured.Origin, st.Configured.Source, st.Configured.FSType, strings.Join(st.Configured.Options, ","))
+		}
+		for _, opt := range st.MissingEffective {
+			result += fmt.Sprintf("-%s (effective)\n", opt)
+		}
+		for _, opt := range st.MissingConfigured {
+			result += fmt.Sprintf("-%s (configured)\n", opt)
+		}
+		for _, opt := range st.NotApplied {
+			result += fmt.Sprintf("!%s (configured but not effective)\n", opt)
+		}
+	}
+	return result, nil
+}
+
+// Evaluate compares the live and configured state of every security mount.
+func Evaluate(mounts []Mount, configured []Configured) []Status {
+	statuses := make([]Status, 0, len(securityMounts))
+	for _, sm := range securityMounts {
+		st := Status{Path: sm.path}
+
+		// The last mount on a path is the one visible to processes.
+		for i := len(mounts) - 1; i >= 0; i-- {
+			if mounts[i].MountPoint == sm.path {
+				st.Mount = &mounts[i]
+				break
+			}
+		}
+		for i := range configured {
+			if configured[i].MountPoint == sm.path {
+				st.Configured = &configured[i]
+				break
+			}
+		}
+
+		if st.Mount != nil {
+			// A bind mount shares the file system it was bound from.
+			st.BindOf = bindSource(mounts, *st.Mount)
+			st.Separate = st.BindOf == ""
+			for _, opt := range sm.options {
+				if !slices.Contains(st.Mount.Options, opt) {
+					st.MissingEffective = append(st.MissingEffective, opt)
+				}
+			}
+		}
+		if st.Configured != nil {
+			for _, opt := range sm.options {
+				if !slices.Contains(st.Configured.Options, opt) {
+					st.MissingConfigured = append(st.MissingConfigured, opt)
+				}
+			}
+			if st.Mount != nil {
+				for _, opt := range st.Configured.Options {
+					if slices.Contains(sm.options, opt) && !slices.Contains(st.Mount.Options, opt) {
+						st.NotApplied = append(st.NotApplied, opt)
+					}
+				}
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// bindSource finds the mount point m was bound from: another mount of the
+// same device whose root contains m's root. The source must be mounted
+// before m or expose a strictly larger part of the file system; otherwise
+// a tmpfs /tmp bound onto /var/tmp would make /tmp a bind of /var/tmp too.
+// It returns "" for plain mounts.
+func bindSource(mounts []Mount, m Mount) string {
+	var (
+		best     string
+		bestRoot string
+	)
+	for _, other := range mounts {
+		if other.MountPoint == m.MountPoint || other.Device != m.Device {
+			continue
+		}
+		rel, err := filepath.Rel(other.Root, m.Root)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if other.ID > m.ID && len(other.Root) >= len(m.Root) {
+			continue
+		}
+		if best == "" || len(other.Root) > len(bestRoot) {
+			best = filepath.Join(other.MountPoint, rel)
+			bestRoot = other.Root
+		}
+	}
+	return best
+}
+
+// ParseMountInfo reads the kernel's mount table. Per-mount and super block
+// options are merged into one effective option list.
+func ParseMountInfo(path string) ([]Mount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []Mount
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := slices.Index(fields, "-")
+		if sep < 6 || len(fields) < sep+4 {
+			continue
+		}
+		options := strings.Split(fields[5], ",")
+		for _, opt := range strings.Split(fields[sep+3], ",") {
+			if !slices.Contains(options, opt) {
+				options = append(options, opt)
+			}
+		}
+		id, _ := strconv.Atoi(fields[0])
+		mounts = append(mounts, Mount{
+			ID:         id,
+			Device:     fields[2],
+			Root:       unescape(fields[3]),
+			MountPoint: unescape(fields[4]),
+			FSType:     fields[sep+1],
+			Source:     unescape(fields[sep+2]),
+			Options:    options,
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// ParseFstab reads the persisted mounts from an fstab file.
+func ParseFstab(path string) ([]Configured, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		entries []Configured
+		lineNo  int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		entries = append(entries, Configured{
+			Source:     unescape(fields[0]),
+			MountPoint: filepath.Clean(unescape(fields[1])),
+			FSType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+			Origin:     fmt.Sprintf("%s:%d", path, lineNo),
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseMountUnits reads systemd .mount units such as tmp.mount. A unit in an
+// earlier directory masks one of the same name in a later directory.
+func parseMountUnits(dirs []string) []Configured {
+	var entries []Configured
+	seen := make(map[string]struct{})
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.mount"))
+		slices.Sort(matches)
+		for _, path := range matches {
+			name := filepath.Base(path)
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			if target, err := os.Readlink(path); err == nil && target == "/dev/null" {
+				continue
+			}
+			if entry, ok := parseMountUnit(path); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+func parseMountUnit(path string) (Configured, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Configured{}, false
+	}
+	defer f.Close()
+
+	entry := Configured{Origin: path}
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		if section != "Mount" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "What":
+			entry.Source = value
+		case "Where":
+			entry.MountPoint = filepath.Clean(value)
+		case "Type":
+			entry.FSType = value
+		case "Options":
+			entry.Options = strings.Split(value, ",")
+		}
+	}
+	return entry, entry.MountPoint != ""
+}
+
+// unescape decodes the octal escapes (\040 for space) used in mountinfo and fstab.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			b.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
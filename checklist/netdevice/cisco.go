@@ -0,0 +1,560 @@
+package netdevice
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"checklist/cisco"
+)
+
+// FromCisco reduces an IOS-XE, IOS-XR or NX-OS configuration.
+func FromCisco(config *cisco.Config) (*Device, error) {
+	accesses, err := cisco.AnalyzeManagementACLs(config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.Path, err)
+	}
+	c := ciscoConfig{config}
+	d := &Device{Path: config.Path, Vendor: Cisco, OS: config.OS}
+	if l := c.global("hostname"); len(l) > 0 && len(l[0].Fields()) > 1 {
+		d.Hostname = l[0].Fields()[1]
+	}
+	d.Access, d.Exposure = c.access(accesses)
+	d.SSH = c.ssh()
+	d.Logging = c.logging()
+	d.NTP = c.ntp()
+	d.SNMP = c.snmp(accesses)
+	d.Users = c.users()
+	d.Enable = c.enable()
+	d.Lockout = c.lockout()
+	d.Backup = c.backup()
+	return d, nil
+}
+
+type ciscoConfig struct {
+	*cisco.Config
+}
+
+// under returns the lines entered in the mode opened by parent, nil for
+// global configuration, whose words start with prefix.
+func (c ciscoConfig) under(parent *cisco.Line, prefix string) []*cisco.Line {
+	var lines []*cisco.Line
+	for _, l := range c.Lines {
+		text := strings.Join(l.Fields(), " ")
+		if l.Parent == parent && (prefix == "" || text == prefix || strings.HasPrefix(text, prefix+" ")) {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func (c ciscoConfig) global(prefix string) []*cisco.Line {
+	return c.under(nil, prefix)
+}
+
+// value returns the words after prefix on the first matching line below
+// parent.
+func (c ciscoConfig) value(parent *cisco.Line, prefix string) []string {
+	lines := c.under(parent, prefix)
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines[0].Fields()[len(strings.Fields(prefix)):]
+}
+
+func (c ciscoConfig) access(analyzed []cisco.Access) ([]Access, []string) {
+	var (
+		accesses []Access
+		exposure []string
+		lines    = make(map[int]int)
+	)
+	add := func(l *cisco.Line, name, kind, mode string, settings *cisco.Line) {
+		a := Access{Name: name, Kind: kind, Timeout: Unset, Modes: []string{mode}}
+		if settings != nil {
+			a.Timeout = c.timeout(settings)
+			if t := c.value(settings, "transport input"); t != nil {
+				a.Protocols = slices.DeleteFunc(t, func(p string) bool { return p == "none" })
+			}
+			a.Login = c.login(settings, kind)
+		} else {
+			a.Login = c.login(nil, kind)
+		}
+		if kind == Remote && c.OS == cisco.NXOS {
+			// NX-OS lines take no transport; the features decide.
+			a.Protocols = nil
+			if len(c.global("no feature ssh")) == 0 {
+				a.Protocols = append(a.Protocols, "ssh")
+			}
+			if len(c.global("feature telnet")) > 0 {
+				a.Protocols = append(a.Protocols, "telnet")
+			}
+		}
+		lines[l.Number] = len(accesses)
+		accesses = append(accesses, a)
+	}
+	switch c.OS {
+	case cisco.IOSXR:
+		templates := make(map[string]*cisco.Line)
+		for _, l := range append(c.global("line default"), c.global("line template")...) {
+			templates[l.Fields()[len(l.Fields())-1]] = l
+		}
+		for _, l := range c.global("line console") {
+			add(l, l.Text, Console, l.Text, l)
+		}
+		pools := c.global("vty-pool")
+		for _, l := range pools {
+			template := "default"
+			f := l.Fields()
+			if i := slices.Index(f, "line-template"); i >= 0 && i+1 < len(f) {
+				template = f[i+1]
+			}
+			mode := "line template " + template
+			if template == "default" {
+				mode = "line default"
+			}
+			add(l, l.Text, Remote, mode, templates[template])
+		}
+		if !slices.ContainsFunc(pools, func(l *cisco.Line) bool { return startsWith(l, "vty-pool default") }) {
+			// The default pool exists without a line and uses line default.
+			def := templates["default"]
+			accesses = append(accesses, Access{Name: "vty-pool default 0 4", Kind: Remote, Timeout: Unset, Login: c.login(def, Remote), Modes: []string{"line default"}})
+			a := &accesses[len(accesses)-1]
+			if def != nil {
+				a.Timeout = c.timeout(def)
+				a.Protocols = c.value(def, "transport input")
+			}
+			if def == nil || len(c.under(def, "access-class ingress")) == 0 {
+				a.Exposure = append(a.Exposure, "no ACL, all sources permitted")
+			}
+		}
+	default:
+		for _, l := range c.global("line") {
+			switch f := l.Fields(); {
+			case len(f) < 2:
+			case strings.HasPrefix(f[1], "con"):
+				add(l, l.Text, Console, l.Text, l)
+			case f[1] == "aux":
+				add(l, l.Text, Aux, l.Text, l)
+			case f[1] == "vty":
+				add(l, l.Text, Remote, l.Text, l)
+			}
+		}
+	}
+	for _, a := range analyzed {
+		if a.Plane != cisco.PlaneVTY {
+			continue
+		}
+		if i, ok := lines[a.Line]; ok {
+			accesses[i].Exposure = append(accesses[i].Exposure, a.Findings...)
+			continue
+		}
+		// ssh server and management-plane filters apply to every line.
+		for _, f := range a.Findings {
+			exposure = append(exposure, a.Where+": "+f)
+		}
+	}
+	return accesses, exposure
+}
+
+func startsWith(l *cisco.Line, prefix string) bool {
+	text := strings.Join(l.Fields(), " ")
+	return text == prefix || strings.HasPrefix(text, prefix+" ")
+}
+
+// timeout reads exec-timeout, which IOS and IOS-XR give in minutes and
+// seconds and NX-OS in minutes.
+func (c ciscoConfig) timeout(l *cisco.Line) int {
+	values := c.value(l, "exec-timeout")
+	if values == nil {
+		return Unset
+	}
+	var seconds int
+	for i, unit := range []int{60, 1} {
+		if i < len(values) {
+			n, _ := strconv.Atoi(values[i])
+			seconds += n * unit
+		}
+	}
+	return seconds
+}
+
+// login returns the methods a line authenticates with. With AAA, the
+// line's method list is used, or the default list; a list that is not
+// defined falls back to local users.
+func (c ciscoConfig) login(l *cisco.Line, kind string) []string {
+	aaa := c.OS != cisco.IOSXE || len(c.global("aaa new-model")) > 0
+	if !aaa {
+		switch {
+		case l == nil:
+			return []string{None}
+		case len(c.under(l, "login local")) > 0:
+			return []string{Local}
+		case len(c.under(l, "no login")) > 0:
+			return []string{None}
+		case len(c.under(l, "password")) > 0:
+			return []string{LinePassword}
+		case kind == Remote:
+			// VTY lines without a password refuse logins.
+			return nil
+		}
+		return []string{None}
+	}
+	name := "default"
+	if c.OS == cisco.NXOS && kind == Console {
+		name = "console"
+	}
+	if l != nil {
+		if v := c.value(l, "login authentication"); len(v) > 0 {
+			name = v[0]
+		}
+	}
+	var list []string
+	for _, def := range c.global("aaa authentication login " + name) {
+		list = def.Fields()[4:]
+	}
+	if list == nil {
+		return []string{Local}
+	}
+	var (
+		methods []string
+		inGroup bool
+	)
+	for _, m := range list {
+		switch m {
+		case "group":
+			inGroup = true
+		case "local", "local-case":
+			methods, inGroup = append(methods, Local), false
+		case "enable":
+			methods, inGroup = append(methods, Enable), false
+		case "line":
+			methods, inGroup = append(methods, LinePassword), false
+		case "none":
+			methods, inGroup = append(methods, None), false
+		default:
+			if inGroup {
+				if kind := c.serverGroup(m); kind != "" {
+					methods = append(methods, kind)
+				}
+			}
+		}
+	}
+	return methods
+}
+
+// serverGroup returns whether a group sends logins to TACACS+ or RADIUS
+// servers, empty when it has none.
+func (c ciscoConfig) serverGroup(name string) string {
+	switch {
+	case name == "tacacs+" && len(c.global("tacacs-server host"))+len(c.global("tacacs server")) > 0:
+		return TACACS
+	case name == "radius" && len(c.global("radius-server host"))+len(c.global("radius server")) > 0:
+		return RADIUS
+	case len(c.global("aaa group server tacacs+ "+name)) > 0:
+		return TACACS
+	case len(c.global("aaa group server radius "+name)) > 0:
+		return RADIUS
+	}
+	return ""
+}
+
+func (c ciscoConfig) ssh() []string {
+	switch c.OS {
+	case cisco.IOSXE:
+		if len(c.global("ip ssh version 2")) > 0 {
+			return []string{"2"}
+		}
+	case cisco.IOSXR:
+		var versions []string
+		if len(c.global("ssh server v1")) > 0 {
+			versions = append(versions, "1")
+		}
+		if len(c.global("ssh server v2")) > 0 {
+			versions = append(versions, "2")
+		}
+		if versions != nil {
+			return versions
+		}
+	case cisco.NXOS:
+		if len(c.global("no feature ssh")) > 0 {
+			return nil
+		}
+		return []string{"2"}
+	}
+	return []string{"1", "2"}
+}
+
+// logging reads "logging host A transport udp port P" (IOS-XE),
+// "logging A port P" (IOS-XR) and "logging server A port P" (NX-OS).
+func (c ciscoConfig) logging() []Endpoint {
+	var endpoints []Endpoint
+	for _, l := range c.global("logging") {
+		fields := l.Fields()
+		if len(fields) < 2 {
+			continue
+		}
+		host := fields[1]
+		if host == "host" || host == "server" {
+			if len(fields) < 3 {
+				continue
+			}
+			host = fields[2]
+		} else if _, err := netip.ParseAddr(host); err != nil {
+			continue
+		}
+		port := "514"
+		if i := slices.Index(fields, "port"); i > 0 && i+1 < len(fields) && fields[i+1] != "default" {
+			port = fields[i+1]
+		}
+		endpoints = append(endpoints, Endpoint{Host: host, Port: port})
+	}
+	return endpoints
+}
+
+func (c ciscoConfig) ntp() []string {
+	lines := c.global("ntp server")
+	for _, ntp := range c.global("ntp") {
+		lines = append(lines, c.under(ntp, "server")...)
+	}
+	var servers []string
+	for _, l := range lines {
+		fields := l.Fields()
+		for i := slices.Index(fields, "server") + 1; i < len(fields); i++ {
+			if fields[i] == "vrf" {
+				i++
+				continue
+			}
+			servers = append(servers, fields[i])
+			break
+		}
+	}
+	return servers
+}
+
+func (c ciscoConfig) snmp(analyzed []cisco.Access) SNMP {
+	lines := c.global("snmp-server")
+	if len(lines) == 0 {
+		return SNMP{}
+	}
+	s := SNMP{Enabled: true}
+	exposure := make(map[int][]string)
+	for _, a := range analyzed {
+		if a.Plane != cisco.PlaneSNMP {
+			continue
+		}
+		if a.Where == "community" {
+			exposure[a.Line] = a.Findings
+			continue
+		}
+		for _, f := range a.Findings {
+			s.Exposure = append(s.Exposure, a.Where+": "+f)
+		}
+	}
+	// NX-OS keeps an SNMP user for every local account, so only the users
+	// traps are sent as count there.
+	v3Hosts := make(map[string]bool)
+	for _, l := range c.global("snmp-server host") {
+		fields := l.Fields()
+		if i := slices.Index(fields, "priv"); slices.Contains(fields, "3") && i > 0 && i+1 < len(fields) {
+			v3Hosts[fields[i+1]] = true
+		}
+	}
+	communities := make(map[string]int)
+	users := make(map[string]int)
+	for _, l := range lines {
+		fields := l.Fields()
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[1] {
+		case "community":
+			i, ok := communities[fields[2]]
+			if !ok {
+				i = len(s.Communities)
+				communities[fields[2]] = i
+				s.Communities = append(s.Communities, Community{
+					Where:    fmt.Sprintf("community on line %d", l.Number),
+					Name:     fields[2],
+					Exposure: exposure[l.Number],
+				})
+			}
+			for _, f := range fields[3:] {
+				if f == "RW" || f == "rw" || f == "network-admin" {
+					s.Communities[i].Write = true
+				}
+			}
+		case "host":
+			s.Hosts = append(s.Hosts, fields[2])
+		case "vrf":
+			for _, h := range c.under(l, "host") {
+				if hf := h.Fields(); len(hf) > 1 {
+					s.Hosts = append(s.Hosts, hf[1])
+				}
+			}
+		case "user":
+			if c.OS == cisco.NXOS && !v3Hosts[fields[2]] {
+				continue
+			}
+			i, ok := users[fields[2]]
+			if !ok {
+				i = len(s.Users)
+				users[fields[2]] = i
+				s.Users = append(s.Users, SNMPUser{Name: fields[2]})
+			}
+			// NX-OS may set a user's role on a line of its own.
+			if j := slices.Index(fields, "auth"); j > 0 && j+1 < len(fields) {
+				s.Users[i].Auth = protocol(fields[j+1], "sha", "md5")
+			}
+			if j := slices.Index(fields, "priv"); j > 0 && j+1 < len(fields) {
+				s.Users[i].Priv = protocol(fields[j+1], "aes", "des", "3des")
+			}
+		}
+	}
+	return s
+}
+
+// protocol returns the first of families that value names, as "sha" for
+// "sha-256", or value itself.
+func protocol(value string, families ...string) string {
+	value = strings.ToLower(value)
+	for _, f := range families {
+		if strings.HasPrefix(value, f) {
+			return f
+		}
+	}
+	return value
+}
+
+// enable returns the enable password and secret.
+func (c ciscoConfig) enable() []User {
+	var enable []User
+	for _, l := range c.global("enable") {
+		if f := l.Fields(); len(f) > 2 && (f[1] == "secret" || f[1] == "password") {
+			enable = append(enable, User{Name: "enable " + f[1], Storage: ciscoStorage(f[1], f[2:])})
+		}
+	}
+	return enable
+}
+
+func (c ciscoConfig) users() []User {
+	var users []User
+	for _, l := range c.global("username") {
+		fields := l.Fields()
+		if len(fields) < 2 {
+			continue
+		}
+		u := User{Name: fields[1]}
+		for i, f := range fields {
+			if f == "secret" || f == "password" {
+				u.Storage = ciscoStorage(f, fields[i+1:])
+				break
+			}
+		}
+		// IOS-XR sets the password in the user's mode.
+		for _, child := range append(c.under(l, "secret"), c.under(l, "password")...) {
+			f := child.Fields()
+			u.Storage = ciscoStorage(f[0], f[1:])
+		}
+		if u.Storage != "" {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// ciscoStorage classifies "secret" or "password" followed by an optional
+// type number and the stored value.
+func ciscoStorage(keyword string, args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	typ, value := "0", args[0]
+	if len(args) > 1 && len(args[0]) <= 2 && strings.Trim(args[0], "0123456789") == "" {
+		typ, value = args[0], args[1]
+	}
+	switch {
+	case strings.HasPrefix(value, "$1$"):
+		return MD5
+	case strings.HasPrefix(value, "$5$"), strings.HasPrefix(value, "$6$"), strings.HasPrefix(value, "$8$"):
+		return SHA
+	case strings.HasPrefix(value, "$9$"):
+		return Scrypt
+	}
+	switch typ {
+	case "0":
+		if keyword == "secret" {
+			// The device hashes a cleartext secret before storing it.
+			return MD5
+		}
+		return Cleartext
+	case "7", "6":
+		return Reversible
+	case "5":
+		return MD5
+	case "8", "10":
+		return SHA
+	case "9":
+		return Scrypt
+	}
+	return Unknown
+}
+
+func (c ciscoConfig) lockout() []string {
+	var settings []string
+	for _, prefix := range []string{"aaa authentication attempts login", "login block-for", "aaa authentication rejected"} {
+		for _, l := range c.global(prefix) {
+			settings = append(settings, l.Text)
+		}
+	}
+	for _, l := range c.global("aaa password-policy") {
+		if len(c.under(l, "lockout-time")) > 0 && len(c.under(l, "authen-max-attempts")) > 0 {
+			settings = append(settings, l.Text)
+		}
+	}
+	return settings
+}
+
+var backupSchemes = []string{"tftp:", "ftp:", "http:", "https:", "scp:", "sftp:", "usb"}
+
+func (c ciscoConfig) backup() []string {
+	var destinations []string
+	for _, archive := range c.global("archive") {
+		path := c.value(archive, "path")
+		if len(path) > 0 && len(c.under(archive, "write-memory"))+len(c.under(archive, "time-period")) > 0 {
+			destinations = append(destinations, path[0])
+		}
+	}
+	for _, l := range c.global("configuration commit auto-save filename") {
+		destinations = append(destinations, l.Fields()[len(l.Fields())-1])
+	}
+	if len(c.global("feature scheduler")) == 0 {
+		return destinations
+	}
+	jobs := make(map[string]string)
+	for _, l := range c.global("scheduler job name") {
+		if len(l.Fields()) < 4 {
+			continue
+		}
+		for _, cp := range c.under(l, "copy running-config") {
+			fields := cp.Fields()
+			if len(fields) > 2 && slices.ContainsFunc(backupSchemes, func(s string) bool { return strings.HasPrefix(fields[2], s) }) {
+				jobs[l.Fields()[3]] = fields[2]
+			}
+		}
+	}
+	for _, l := range c.global("scheduler schedule name") {
+		if len(c.under(l, "time")) == 0 {
+			continue
+		}
+		for _, job := range c.under(l, "job name") {
+			if fields := job.Fields(); len(fields) < 3 {
+				continue
+			} else if dest, ok := jobs[fields[2]]; ok {
+				destinations = append(destinations, dest)
+			}
+		}
+	}
+	return destinations
+}
@@ -0,0 +1,112 @@
+package netdevice
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// accessSummary reduces an Access to "name kind timeout protocols login
+// exposure" for comparison.
+func accessSummary(a Access) string {
+	return fmt.Sprintf("%s|%s|%d|%s|%s|%s", a.Name, a.Kind, a.Timeout,
+		strings.Join(a.Protocols, ","), strings.Join(a.Login, ","), strings.Join(a.Exposure, ";"))
+}
+
+func load(t *testing.T, name string) *Device {
+	t.Helper()
+	d, err := Load(samples[name], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func checkAccess(t *testing.T, d *Device, want []string) {
+	t.Helper()
+	var got []string
+	for _, a := range d.Access {
+		got = append(got, accessSummary(a))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("access:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func checkField(t *testing.T, name string, got, want any) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: got %+v, want %+v", name, got, want)
+	}
+}
+
+func TestCiscoIOSXE(t *testing.T) {
+	d := load(t, "cat8k")
+	checkField(t, "vendor", d.Vendor, Cisco)
+	checkField(t, "os", d.OS, "iosxe")
+	checkField(t, "hostname", d.Hostname, "cat8k")
+	checkAccess(t, d, []string{
+		"line con 0|console|-1||local|",
+		"line aux 0|aux|-1||local|",
+		"line vty 0 4|remote|-1|ssh|local|99 line 191 permits broad range 10.0.0.0/8",
+		"line vty 5 15|remote|-1|ssh|local|99 line 191 permits broad range 10.0.0.0/8",
+	})
+	checkField(t, "ssh", d.SSH, []string{"2"})
+	checkField(t, "logging", len(d.Logging), 0)
+	checkField(t, "ntp", len(d.NTP), 0)
+	checkField(t, "snmp enabled", d.SNMP.Enabled, false)
+	checkField(t, "users", d.Users, []User{{"devnetad", Scrypt}, {"admin", Scrypt}})
+	checkField(t, "enable", d.Enable, []User{{"enable secret", Scrypt}, {"enable password", Reversible}})
+	checkField(t, "lockout", d.Lockout, []string{"aaa authentication attempts login 5"})
+	checkField(t, "backup", len(d.Backup), 0)
+}
+
+func TestCiscoIOSXR(t *testing.T) {
+	d := load(t, "asr9k")
+	checkField(t, "os", d.OS, "iosxr")
+	checkField(t, "hostname", d.Hostname, "asr9k")
+	checkAccess(t, d, []string{
+		"line console|console|300||tacacs,local|",
+		"vty-pool CUST 5 10 line-template default|remote|300|ssh|tacacs,local|no ACL, all sources permitted",
+		"vty-pool default 0 4 line-template SSH-TEMP|remote|-1|ssh|tacacs,local|no ACL, all sources permitted",
+		"vty-pool UNSECURE-POOL 20 30 line-template VTY-TEMP|remote|-1|ssh|tacacs,local|no ACL, all sources permitted",
+	})
+	checkField(t, "exposure", d.Exposure, []string{
+		"ssh server vrf mgmt ipv6 access-list ACL-VTY-IN: ACL-VTY-IN line 179 permits any source",
+		"ssh server netconf vrf mgmt ipv6 access-list ACL-VTY-IN: ACL-VTY-IN line 179 permits any source",
+	})
+	checkField(t, "logging", d.Logging, []Endpoint{{"192.168.100.104", "514"}, {"10.1.1.1", "514"}, {"192.168.1.100", "514"}})
+	checkField(t, "ntp", d.NTP, []string{"115.165.161.155"})
+	checkField(t, "snmp enabled", d.SNMP.Enabled, true)
+	checkField(t, "communities", len(d.SNMP.Communities), 2)
+	if c := d.SNMP.Communities[0]; c.Where != "community on line 134" || !c.Write || len(c.Exposure) != 1 {
+		t.Errorf("first community: got %+v", c)
+	}
+	if c := d.SNMP.Communities[1]; c.Write || len(c.Exposure) != 0 {
+		t.Errorf("second community: got %+v", c)
+	}
+	checkField(t, "snmp users", d.SNMP.Users, []SNMPUser{{"snmpviewer", "sha", "aes"}})
+	checkField(t, "users", d.Users, []User{{"cisco", SHA}, {"admin", SHA}, {"lab", SHA}})
+	checkField(t, "enable", len(d.Enable), 0)
+	checkField(t, "lockout", d.Lockout, []string{"aaa password-policy AAA-PASSWORD-POL"})
+	checkField(t, "backup", d.Backup, []string{"disk0:/bkp_asr9k"})
+}
+
+func TestCiscoNXOS(t *testing.T) {
+	d := load(t, "n9k")
+	checkField(t, "os", d.OS, "nxos")
+	checkField(t, "hostname", d.Hostname, "nxos_n9k")
+	checkAccess(t, d, []string{
+		"line console|console|300||tacacs,radius|",
+		"line vty|remote|300|ssh|tacacs,radius|ACL-VTY-IN line 68 permits broad range 10.0.0.0/8",
+	})
+	checkField(t, "logging", d.Logging, []Endpoint{{"192.168.89.104", "514"}})
+	checkField(t, "ntp", d.NTP, []string{"1.vn.pool.ntp.org", "1.asia.pool.ntp.org"})
+	checkField(t, "snmp users", d.SNMP.Users, []SNMPUser{{"NMS_MON", "sha", "aes"}})
+	checkField(t, "snmp hosts", d.SNMP.Hosts, []string{"192.168.89.104", "192.168.89.105"})
+	checkField(t, "communities", len(d.SNMP.Communities), 1)
+	checkField(t, "users", d.Users, []User{{"admin", SHA}, {"devnetad", SHA}, {"NMS_MON", SHA}})
+	checkField(t, "lockout", d.Lockout, []string{"aaa authentication rejected 5 in 300 ban 600"})
+	checkField(t, "backup", d.Backup, []string{"tftp://192.168.89.104/$(SWITCHNAME)-cfg.$(TIMESTAMP)"})
+}
@@ -0,0 +1,289 @@
+package netdevice
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"checklist/check"
+	"checklist/cisco"
+	"checklist/junos"
+)
+
+// Options are the site values some controls compare against. An empty
+// SIEM uses the collectors the OS's scripts expect.
+type Options struct {
+	SIEM []string
+}
+
+// Control is one check written against the vendor-neutral model, the same
+// on every platform. Its ID is 120 and Number, which is the number of the
+// vendor checks it stands beside, as 12011 for 7011, 8011, 9011 and 10011.
+// The ID is its own because the vendor checks read more of the
+// configuration than the model holds, so the two can disagree.
+type Control struct {
+	Number string
+	Title  string
+	run    func(d *Device, opts Options) []string
+}
+
+// ID returns the control's check ID.
+func (c Control) ID() string {
+	return "120" + c.Number
+}
+
+var Controls = []Control{
+	{"01", "Host name is not the factory default", controlHostName},
+	{"03", "Login sessions time out after 5 idle minutes", controlTimeout},
+	{"04", "Remote access is restricted to management sources", controlRemoteACL},
+	{"08", "Remote logins use RADIUS or TACACS+", controlRemoteAuth},
+	{"09", "NTP servers are configured", controlNTP},
+	{"10", "SNMP is read-only and restricted to known clients", controlSNMP},
+	{"11", "SSH version 2 is the only remote login protocol", controlSSH},
+	{"12", "Passwords are stored as one-way hashes", controlPasswords},
+	{"13", "Syslog is sent to the SIEM", controlSyslog},
+	{"14", "Repeated login failures are locked out", controlLockout},
+	{"15", "Configuration is backed up automatically", controlBackup},
+}
+
+// Load parses a Cisco or JunOS configuration into the model. osName is
+// iosxe, iosxr, nxos or junos; when empty the vendor and OS are detected.
+func Load(path, osName string) (*Device, error) {
+	if osName == "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if junos.Recognize(content) {
+			osName = "junos"
+		}
+	}
+	if osName == "junos" {
+		config, err := junos.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+		return FromJunos(config), nil
+	}
+	config, err := cisco.ParseConfig(path, osName)
+	if err != nil {
+		return nil, err
+	}
+	return FromCisco(config)
+}
+
+func GetControls(paths []string, osName string, siem []string) (string, error) {
+	var result string
+	for _, path := range paths {
+		d, err := Load(path, osName)
+		if err != nil {
+			return "", err
+		}
+		result += check.Format(fmt.Sprintf("%s (%s)", d.Path, d.OS), Audit(d, Options{SIEM: siem}))
+	}
+	return result, nil
+}
+
+// Audit runs every control against the device.
+func Audit(d *Device, opts Options) []check.Result {
+	opts.SIEM = siem(d, opts)
+	var results []check.Result
+	for _, c := range Controls {
+		results = append(results, check.Result{ID: c.ID(), Title: c.Title, Findings: c.run(d, opts)})
+	}
+	return results
+}
+
+// siem returns the collectors syslog must reach: opts.SIEM, or the
+// defaults of the device's OS.
+func siem(d *Device, opts Options) []string {
+	switch {
+	case len(opts.SIEM) > 0:
+		return opts.SIEM
+	case d.Vendor == Juniper:
+		return junos.DefaultSIEM
+	}
+	return cisco.DefaultSIEM[d.OS]
+}
+
+var defaultHostNames = []string{"router", "switch", "ios", "iosxr", "amnesiac"}
+
+func controlHostName(d *Device, opts Options) []string {
+	if d.Hostname == "" {
+		return []string{"host name is not set"}
+	}
+	if slices.Contains(defaultHostNames, strings.ToLower(d.Hostname)) {
+		return []string{"host name is the factory default " + d.Hostname}
+	}
+	return nil
+}
+
+func controlTimeout(d *Device, opts Options) []string {
+	var findings []string
+	for _, a := range d.Access {
+		switch {
+		case a.Timeout == Unset && a.Kind != Aux:
+			findings = append(findings, a.Name+": idle timeout is not set")
+		case a.Timeout == 0:
+			findings = append(findings, a.Name+": sessions never time out")
+		case a.Timeout > 300:
+			findings = append(findings, fmt.Sprintf("%s: sessions time out after %d seconds, want 300 or less", a.Name, a.Timeout))
+		}
+	}
+	return findings
+}
+
+func controlRemoteACL(d *Device, opts Options) []string {
+	findings := slices.Clone(d.Exposure)
+	for _, a := range d.Access {
+		for _, e := range a.Exposure {
+			findings = append(findings, a.Name+": "+e)
+		}
+	}
+	return findings
+}
+
+func controlRemoteAuth(d *Device, opts Options) []string {
+	var findings []string
+	for _, a := range d.Access {
+		if a.Kind != Remote {
+			continue
+		}
+		if !slices.Contains(a.Login, TACACS) && !slices.Contains(a.Login, RADIUS) {
+			findings = append(findings, fmt.Sprintf("%s: logins use %s", a.Name, methods(a.Login)))
+		}
+		if slices.Contains(a.Login, None) {
+			findings = append(findings, a.Name+": logins fall back to no authentication")
+		}
+	}
+	return findings
+}
+
+func methods(login []string) string {
+	if len(login) == 0 {
+		return "no method"
+	}
+	return strings.Join(login, ", ") + " only"
+}
+
+func controlNTP(d *Device, opts Options) []string {
+	if len(d.NTP) == 0 {
+		return []string{"no NTP server is configured"}
+	}
+	return nil
+}
+
+// controlSNMP passes when SNMP is off. Otherwise communities must be
+// read-only, not guessable and restricted to known clients, SNMPv3 users
+// must use SHA and AES, and traps must go to a host.
+func controlSNMP(d *Device, opts Options) []string {
+	s := d.SNMP
+	if !s.Enabled {
+		return nil
+	}
+	var findings []string
+	for _, c := range s.Communities {
+		if slices.Contains(check.WellKnownCommunities, strings.ToLower(c.Name)) {
+			findings = append(findings, fmt.Sprintf("%s uses the well-known string %q", c.Where, strings.ToLower(c.Name)))
+		}
+		if c.Write {
+			findings = append(findings, c.Where+" has write access")
+		}
+		for _, e := range c.Exposure {
+			findings = append(findings, c.Where+": "+e)
+		}
+	}
+	for _, u := range s.Users {
+		if u.Auth != "sha" {
+			findings = append(findings, "SNMP user "+u.Name+" does not authenticate with SHA")
+		}
+		if u.Priv != "aes" {
+			findings = append(findings, "SNMP user "+u.Name+" does not encrypt with AES")
+		}
+	}
+	findings = append(findings, s.Exposure...)
+	if len(s.Hosts) == 0 {
+		findings = append(findings, "no SNMP trap host is configured")
+	}
+	return findings
+}
+
+func controlSSH(d *Device, opts Options) []string {
+	var findings []string
+	switch {
+	case d.SSH == nil:
+		findings = append(findings, "SSH is not enabled")
+	case !slices.Equal(d.SSH, []string{"2"}):
+		findings = append(findings, fmt.Sprintf("SSH accepts version %s, want 2 only", strings.Join(d.SSH, " and ")))
+	}
+	for _, a := range d.Access {
+		if a.Kind != Remote {
+			continue
+		}
+		if a.Protocols == nil {
+			findings = append(findings, a.Name+": accepted protocols are not set")
+			continue
+		}
+		for _, p := range a.Protocols {
+			if p != "ssh" {
+				findings = append(findings, a.Name+": accepts "+p)
+			}
+		}
+	}
+	return findings
+}
+
+var storageProblems = map[string]string{
+	Cleartext:  "is stored in cleartext",
+	Reversible: "is stored with reversible encryption",
+	MD5:        "is an MD5 hash",
+	Unknown:    "is stored in an unknown format",
+}
+
+func controlPasswords(d *Device, opts Options) []string {
+	if len(d.Users) == 0 && len(d.Enable) == 0 {
+		return []string{"no local user has a password"}
+	}
+	var findings []string
+	for _, u := range d.Enable {
+		if problem, ok := storageProblems[u.Storage]; ok {
+			findings = append(findings, fmt.Sprintf("%s %s", u.Name, problem))
+		}
+	}
+	for _, u := range d.Users {
+		if problem, ok := storageProblems[u.Storage]; ok {
+			findings = append(findings, fmt.Sprintf("user %s password %s", u.Name, problem))
+		}
+	}
+	return findings
+}
+
+func controlSyslog(d *Device, opts Options) []string {
+	for _, e := range d.Logging {
+		for _, siem := range opts.SIEM {
+			host, port, ok := strings.Cut(siem, ":")
+			if !ok {
+				port = "514"
+			}
+			if e.Host == host && e.Port == port {
+				return nil
+			}
+		}
+	}
+	return []string{fmt.Sprintf("no syslog host sends to %s", strings.Join(opts.SIEM, " or "))}
+}
+
+func controlLockout(d *Device, opts Options) []string {
+	if len(d.Lockout) == 0 {
+		return []string{"repeated login failures are neither locked out nor slowed down"}
+	}
+	return nil
+}
+
+func controlBackup(d *Device, opts Options) []string {
+	if len(d.Backup) == 0 {
+		return []string{"the configuration is not copied anywhere automatically"}
+	}
+	return nil
+}
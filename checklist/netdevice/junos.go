@@ -0,0 +1,219 @@
+package netdevice
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"checklist/junos"
+)
+
+// FromJunos reduces a JunOS configuration, after groups are applied and
+// inactive statements dropped.
+func FromJunos(config *junos.Config) *Device {
+	root := config.Resolve()
+	system := root.Child("system")
+	d := &Device{Path: config.Path, Vendor: Juniper, OS: "junos", Hostname: system.Value("host-name")}
+	d.Access = junosAccess(root)
+	if ssh := system.Find("services", "ssh"); ssh != nil {
+		d.SSH = []string{"2"}
+		if versions := ssh.Values("protocol-version"); len(versions) > 0 {
+			d.SSH = nil
+			for _, v := range versions {
+				d.SSH = append(d.SSH, strings.TrimPrefix(v, "v"))
+			}
+		}
+	}
+	for _, host := range nodes(system.Find("syslog", "host")) {
+		if !junosLogs(host) {
+			continue
+		}
+		port := host.Value("port")
+		if port == "" {
+			port = "514"
+		}
+		d.Logging = append(d.Logging, Endpoint{Host: host.Name, Port: port})
+	}
+	d.NTP = system.Values("ntp", "server")
+	d.SNMP = junosSNMP(root)
+	for _, user := range nodes(system.Find("login", "user")) {
+		if hash := user.Value("authentication", "encrypted-password"); hash != "" {
+			d.Users = append(d.Users, User{Name: user.Name, Storage: junosStorage(hash)})
+		}
+	}
+	if hash := system.Value("root-authentication", "encrypted-password"); hash != "" {
+		d.Users = append(d.Users, User{Name: "root", Storage: junosStorage(hash)})
+	}
+	retry := system.Find("login", "retry-options")
+	for _, option := range []string{"tries-before-disconnect", "lockout-period", "backoff-threshold"} {
+		if v := retry.Value(option); v != "" {
+			d.Lockout = append(d.Lockout, "retry-options "+option+" "+v)
+		}
+	}
+	archival := system.Find("archival", "configuration")
+	if archival.Has("transfer-on-commit") || archival.Has("transfer-interval") {
+		d.Backup = archival.Values("archive-sites")
+	}
+	return d
+}
+
+// junosAccess lists the console and auxiliary ports and the login
+// services. JunOS sets idle timeouts per login class rather than per
+// access, so every access gets the longest one.
+func junosAccess(root *junos.Node) []Access {
+	timeout := 0
+	classes := nodes(root.Find("system", "login", "class"))
+	var modes []string
+	for _, class := range classes {
+		modes = append(modes, "system login class "+class.Name)
+		minutes, err := strconv.Atoi(class.Value("idle-timeout"))
+		if err != nil || timeout == Unset {
+			timeout = Unset
+			continue
+		}
+		timeout = max(timeout, minutes*60)
+	}
+	if len(classes) == 0 {
+		timeout = Unset
+	}
+	login := junosLogin(root)
+
+	var accesses []Access
+	for _, port := range []struct{ name, kind string }{{"console", Console}, {"auxiliary", Aux}} {
+		accesses = append(accesses, Access{Name: "system ports " + port.name, Kind: port.kind, Timeout: timeout, Login: login, Modes: modes})
+	}
+	services := junos.ManagementServices(root)
+	for _, name := range []string{"ssh", "telnet", "http", "https", "netconf"} {
+		port, ok := services[name]
+		if !ok {
+			continue
+		}
+		a := Access{Name: "system services " + name, Kind: Remote, Timeout: timeout, Protocols: []string{name}, Login: login, Modes: modes, Port: port}
+		if name == "netconf" {
+			a.Protocols = []string{"ssh"}
+		}
+		if !junos.Protected(root, port) {
+			a.Exposure = []string{fmt.Sprintf("port %d is reachable from any source", port)}
+		}
+		accesses = append(accesses, a)
+	}
+	return accesses
+}
+
+// junosLogin maps the authentication order, whose default is local
+// passwords only. Servers that are not configured are skipped.
+func junosLogin(root *junos.Node) []string {
+	order := root.Values("system", "authentication-order")
+	if len(order) == 0 {
+		return []string{Local}
+	}
+	var methods []string
+	for _, m := range order {
+		switch m {
+		case "tacplus":
+			if root.Has("system", "tacplus-server") {
+				methods = append(methods, TACACS)
+			}
+		case "radius":
+			if root.Has("system", "radius-server") {
+				methods = append(methods, RADIUS)
+			}
+		case "password":
+			methods = append(methods, Local)
+		}
+	}
+	return methods
+}
+
+var junosLevels = []string{"info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// junosLogs reports whether a syslog host receives any facility at info
+// or above.
+func junosLogs(host *junos.Node) bool {
+	for _, facility := range host.Children {
+		if len(facility.Children) == 1 && len(facility.Children[0].Children) == 0 && slices.Contains(junosLevels, facility.Children[0].Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func junosSNMP(root *junos.Node) SNMP {
+	snmp := root.Child("snmp")
+	if snmp == nil {
+		return SNMP{}
+	}
+	s := SNMP{Enabled: true}
+	lists := snmp.Child("client-list")
+	for _, c := range nodes(snmp.Find("community")) {
+		community := Community{
+			Where: fmt.Sprintf("community on line %d", c.Line),
+			Name:  c.Name,
+			Write: c.Value("authorization") == "read-write",
+		}
+		clients, list := c.Child("clients"), c.Value("client-list-name")
+		switch {
+		case clients == nil && list == "":
+			community.Exposure = []string{"no clients or client-list-name, all sources permitted"}
+		case clients != nil && !restricts(clients):
+			community.Exposure = []string{"clients do not end with 0.0.0.0/0 restrict"}
+		case list != "" && lists.Child(list) == nil:
+			community.Exposure = []string{"client-list " + list + " is not defined"}
+		case list != "" && !restricts(lists.Child(list)):
+			community.Exposure = []string{"client-list " + list + " does not end with 0.0.0.0/0 restrict"}
+		}
+		s.Communities = append(s.Communities, community)
+	}
+	for _, user := range nodes(snmp.Find("v3", "usm", "local-engine", "user")) {
+		u := SNMPUser{Name: user.Name}
+		for _, c := range user.Children {
+			switch {
+			case strings.HasPrefix(c.Name, "authentication-") && c.Name != "authentication-none":
+				u.Auth = protocol(strings.TrimPrefix(c.Name, "authentication-"), "sha", "md5")
+			case strings.HasPrefix(c.Name, "privacy-") && c.Name != "privacy-none":
+				u.Priv = protocol(strings.TrimPrefix(c.Name, "privacy-"), "aes", "des", "3des")
+			}
+		}
+		s.Users = append(s.Users, u)
+	}
+	for _, group := range nodes(snmp.Find("trap-group")) {
+		s.Hosts = append(s.Hosts, group.Values("targets")...)
+	}
+	for _, target := range nodes(snmp.Find("v3", "target-address")) {
+		if addr := target.Value("address"); addr != "" {
+			s.Hosts = append(s.Hosts, addr)
+		}
+	}
+	if snmp.Has("v3") && !junos.Protected(root, 161) {
+		s.Exposure = append(s.Exposure, "SNMPv3 (port 161) is reachable from any source")
+	}
+	return s
+}
+
+// nodes returns the children of n, which may be missing.
+func nodes(n *junos.Node) []*junos.Node {
+	if n == nil {
+		return nil
+	}
+	return n.Children
+}
+
+// restricts reports whether a client list rejects every source not
+// listed in it.
+func restricts(list *junos.Node) bool {
+	return list.Has("0.0.0.0/0", "restrict") || list.Has("default", "restrict")
+}
+
+func junosStorage(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$1$"):
+		return MD5
+	case strings.HasPrefix(hash, "$5$"), strings.HasPrefix(hash, "$6$"), strings.HasPrefix(hash, "$sha1$"):
+		return SHA
+	case strings.HasPrefix(hash, "$9$"):
+		// $9$ is the reversible JunOS encoding, not a hash.
+		return Reversible
+	}
+	return Unknown
+}
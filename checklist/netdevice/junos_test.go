@@ -0,0 +1,32 @@
+package netdevice
+
+import "testing"
+
+func TestJunos(t *testing.T) {
+	d := load(t, "jsw")
+	checkField(t, "vendor", d.Vendor, Juniper)
+	checkField(t, "os", d.OS, "junos")
+	checkField(t, "hostname", d.Hostname, "jsw")
+	checkAccess(t, d, []string{
+		"system ports console|console|300||radius,local|",
+		"system ports auxiliary|aux|300||radius,local|",
+		"system services ssh|remote|300|ssh|radius,local|port 22 is reachable from any source",
+		"system services netconf|remote|300|ssh|radius,local|port 830 is reachable from any source",
+	})
+	for _, a := range d.Access {
+		checkField(t, a.Name+" modes", a.Modes, []string{
+			"system login class netadmin", "system login class operation", "system login class super-user-local",
+		})
+	}
+	checkField(t, "ports", []int{d.Access[2].Port, d.Access[3].Port}, []int{22, 830})
+	checkField(t, "ssh", d.SSH, []string{"2"})
+	checkField(t, "logging", d.Logging, []Endpoint{{"10.255.100.30", "514"}, {"10.255.100.50", "1514"}})
+	checkField(t, "ntp", d.NTP, []string{"103.184.124.254", "17.253.116.253", "62.228.228.8"})
+	checkField(t, "snmp enabled", d.SNMP.Enabled, true)
+	checkField(t, "snmp users", d.SNMP.Users, []SNMPUser{{"secure-snmp", "sha", "aes"}})
+	checkField(t, "snmp exposure", d.SNMP.Exposure, []string{"SNMPv3 (port 161) is reachable from any source"})
+	checkField(t, "snmp hosts", len(d.SNMP.Hosts), 0)
+	checkField(t, "users", d.Users, []User{{"sysdevad", SHA}, {"test01", SHA}, {"root", SHA}})
+	checkField(t, "lockout", len(d.Lockout), 0)
+	checkField(t, "backup", d.Backup, []string{"ftp://10.255.100.100", "file:///var/tmp/config-backup/"})
+}
@@ -0,0 +1,117 @@
+package netdevice
+
+const (
+	Cisco   = "cisco"
+	Juniper = "juniper"
+)
+
+// Kinds of access.
+const (
+	Console = "console"
+	Aux     = "aux"
+	Remote  = "remote"
+)
+
+// Unset is the Timeout of an access that has none configured.
+const Unset = -1
+
+// Login methods, in the order a device tries them.
+const (
+	TACACS       = "tacacs"
+	RADIUS       = "radius"
+	Local        = "local"
+	LinePassword = "line"
+	Enable       = "enable"
+	None         = "none"
+)
+
+// Password storage, from weakest to strongest.
+const (
+	Cleartext  = "cleartext"
+	Reversible = "reversible"
+	MD5        = "md5"
+	SHA        = "sha"
+	Scrypt     = "scrypt"
+	Unknown    = "unknown"
+)
+
+// Device is a configuration reduced to what the controls look at, the same
+// whatever the vendor. The per-vendor parsers fill it from a Cisco
+// configuration or the resolved JunOS hierarchy. Exposure holds what
+// device-wide filters on remote access, such as an SSH server ACL, let
+// through. Enable holds the Cisco enable password and secret, named by
+// their keyword; they are not accounts, so a user called enable is kept
+// apart from them in Users.
+type Device struct {
+	Path     string
+	Vendor   string
+	OS       string
+	Hostname string
+	Access   []Access
+	Exposure []string
+	SSH      []string
+	Logging  []Endpoint
+	NTP      []string
+	SNMP     SNMP
+	Users    []User
+	Enable   []User
+	Lockout  []string
+	Backup   []string
+}
+
+// Access is a way to log in: a console or auxiliary port, or a group of
+// VTY lines or a service reached over the network. Timeout is in seconds,
+// 0 when sessions never time out. Protocols is nil when the device's
+// default applies. Exposure says why sources outside the management
+// networks can connect, and Login lists the authentication methods tried.
+// Modes are the statements its settings go under: the Cisco line or line
+// template, or the JunOS login classes. Port is the TCP port of a JunOS
+// service.
+type Access struct {
+	Name      string
+	Kind      string
+	Timeout   int
+	Protocols []string
+	Exposure  []string
+	Login     []string
+	Modes     []string
+	Port      int
+}
+
+// Endpoint is a host that receives syslog.
+type Endpoint struct {
+	Host string
+	Port string
+}
+
+// SNMP holds the agent settings. Hosts are where traps are sent.
+type SNMP struct {
+	Enabled     bool
+	Communities []Community
+	Users       []SNMPUser
+	Hosts       []string
+	Exposure    []string
+}
+
+// Community is an SNMPv1/v2c community. Where names it without showing
+// the string, which is a secret.
+type Community struct {
+	Where    string
+	Name     string
+	Write    bool
+	Exposure []string
+}
+
+// SNMPUser is an SNMPv3 user with its authentication and privacy
+// protocols, such as sha and aes, empty when not used.
+type SNMPUser struct {
+	Name string
+	Auth string
+	Priv string
+}
+
+// User is a local account and how its password is stored.
+type User struct {
+	Name    string
+	Storage string
+}
@@ -0,0 +1,633 @@
+package netdevice
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"checklist/check"
+	"checklist/cisco"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Variables are the site values that fill the placeholders of the
+// remediation, as <siem> or <ntp>. A value may be a list; a line with a
+// list placeholder is repeated once for each value. The file looks like:
+//
+//	siem: [192.168.100.104:514]
+//	ntp: [10.0.0.1, 10.0.0.2]
+//	management: [10.10.0.0/24]
+//	tacacs: [10.0.0.5]
+//	snmp-host: 10.0.0.9
+//	backup: scp://backup.example.net/configs/
+//
+// siem also gives <siem-host> and <siem-port>, and management gives
+// <management-address>, <management-mask> and <management-wildcard>.
+// Secrets such as <tacacs-key> are best left out and filled in review.
+type Variables map[string][]string
+
+// LoadVariables reads a variables file.
+func LoadVariables(path string) (Variables, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	vars := make(Variables)
+	for name, value := range raw {
+		switch v := value.(type) {
+		case []any:
+			for _, item := range v {
+				vars[name] = append(vars[name], fmt.Sprint(item))
+			}
+		case nil:
+		default:
+			vars[name] = []string{fmt.Sprint(v)}
+		}
+	}
+	return vars, nil
+}
+
+// Remediation is the configuration that fixes a failing control. Config
+// is in the device's own syntax, set commands for JunOS, and may hold
+// placeholders.
+type Remediation struct {
+	ID       string
+	Title    string
+	Findings []string
+	Config   []string
+}
+
+var fixes = map[string]func(d *Device, opts Options) []string{
+	"01": fixHostName,
+	"03": fixTimeout,
+	"04": fixRemoteACL,
+	"08": fixRemoteAuth,
+	"09": fixNTP,
+	"10": fixSNMP,
+	"11": fixSSH,
+	"12": fixPasswords,
+	"13": fixSyslog,
+	"14": fixLockout,
+	"15": fixBackup,
+}
+
+// Remediate returns the fix of every failing control.
+func Remediate(d *Device, opts Options) []Remediation {
+	opts.SIEM = siem(d, opts)
+	var remediations []Remediation
+	for i, r := range Audit(d, opts) {
+		if r.Pass() {
+			continue
+		}
+		fix := fixes[Controls[i].Number](d, opts)
+		remediations = append(remediations, Remediation{ID: r.ID, Title: r.Title, Findings: r.Findings, Config: fix})
+	}
+	return remediations
+}
+
+// GetRemediation prints a patch for each configuration: the fix of each
+// failing control under a comment naming it and its findings, with the
+// placeholders vars has filled in. The SIEM the syslog control expects
+// is vars' siem, or the site collectors of the OS.
+func GetRemediation(paths []string, osName string, vars Variables) (string, error) {
+	var result string
+	for _, path := range paths {
+		d, err := Load(path, osName)
+		if err != nil {
+			return "", err
+		}
+		opts := Options{SIEM: vars["siem"]}
+		comment := "!"
+		if d.Vendor == Juniper {
+			comment = "#"
+		}
+		values := vars.derive(siem(d, opts))
+		missing := make(map[string]bool)
+		// Fixes share statements, such as the management ACL; each is
+		// printed once.
+		printed := make(map[string]bool)
+		remediations := Remediate(d, opts)
+		result += fmt.Sprintf("****%s (%s)****\n", d.Path, d.OS)
+		for _, r := range remediations {
+			result += fmt.Sprintf("%s %s %s\n", comment, r.ID, r.Title)
+			for _, f := range r.Findings {
+				result += fmt.Sprintf("%s   %s\n", comment, f)
+			}
+			for _, block := range blocks(r.Config) {
+				key := strings.Join(block, "\n")
+				if printed[key] {
+					continue
+				}
+				printed[key] = true
+				for _, line := range block {
+					if strings.HasPrefix(line, "#") {
+						// Notes are written with #, whatever the vendor.
+						result += comment + line[1:] + "\n"
+						continue
+					}
+					for _, l := range fill(line, values, missing) {
+						result += l + "\n"
+					}
+				}
+			}
+		}
+		var names []string
+		for name := range missing {
+			names = append(names, "<"+name+">")
+		}
+		slices.Sort(names)
+		if len(names) == 0 {
+			names = []string{"none"}
+		}
+		result += fmt.Sprintf("failed: %d, placeholders to fill: %s\n", len(remediations), strings.Join(names, ", "))
+	}
+	return result, nil
+}
+
+// derive adds the values computed from others, with siem being the
+// collectors the device is checked against.
+func (v Variables) derive(siem []string) Variables {
+	values := make(Variables)
+	for name, value := range v {
+		values[name] = value
+	}
+	values["siem"] = siem
+	values["siem-host"], values["siem-port"] = nil, nil
+	for _, s := range siem {
+		host, port, ok := strings.Cut(s, ":")
+		if !ok {
+			port = "514"
+		}
+		values["siem-host"] = append(values["siem-host"], host)
+		values["siem-port"] = append(values["siem-port"], port)
+	}
+	for _, network := range v["management"] {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil || !prefix.Addr().Is4() {
+			continue
+		}
+		mask := ^uint32(0) << (32 - prefix.Bits())
+		if prefix.Bits() == 0 {
+			mask = 0
+		}
+		values["management-address"] = append(values["management-address"], prefix.Masked().Addr().String())
+		values["management-mask"] = append(values["management-mask"], ipv4(mask))
+		values["management-wildcard"] = append(values["management-wildcard"], ipv4(^mask))
+	}
+	return values
+}
+
+func ipv4(n uint32) string {
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}).String()
+}
+
+var placeholder = regexp.MustCompile(`<([A-Za-z0-9._-]+)>`)
+
+// fill replaces the placeholders of line that have values, repeating the
+// line when some have several: the nth copy takes the nth value of each.
+// Placeholders without a value are kept and added to missing.
+func fill(line string, values Variables, missing map[string]bool) []string {
+	n := 1
+	for _, m := range placeholder.FindAllStringSubmatch(line, -1) {
+		if len(values[m[1]]) == 0 {
+			missing[m[1]] = true
+			continue
+		}
+		n = max(n, len(values[m[1]]))
+	}
+	var lines []string
+	for i := range n {
+		lines = append(lines, placeholder.ReplaceAllStringFunc(line, func(p string) string {
+			value := values[p[1:len(p)-1]]
+			if len(value) == 0 {
+				return p
+			}
+			return value[min(i, len(value)-1)]
+		}))
+	}
+	return lines
+}
+
+// blocks splits config into top-level statements, each with the lines
+// entered in its mode.
+func blocks(config []string) [][]string {
+	var out [][]string
+	for _, line := range config {
+		if strings.HasPrefix(line, " ") && len(out) > 0 {
+			out[len(out)-1] = append(out[len(out)-1], line)
+			continue
+		}
+		out = append(out, []string{line})
+	}
+	return out
+}
+
+// under returns each mode once, followed by the statements to enter in
+// it.
+func under(modes []string, statements ...string) []string {
+	var config []string
+	for _, mode := range modes {
+		if slices.Contains(config, mode) {
+			continue
+		}
+		config = append(config, mode)
+		config = append(config, statements...)
+	}
+	return config
+}
+
+// modes returns the modes of the accesses that fail.
+func modes(accesses []Access, fails func(Access) bool) []string {
+	var out []string
+	for _, a := range accesses {
+		if fails(a) {
+			out = append(out, a.Modes...)
+		}
+	}
+	return out
+}
+
+func fixHostName(d *Device, opts Options) []string {
+	if d.Vendor == Juniper {
+		return []string{"set system host-name <hostname>"}
+	}
+	return []string{"hostname <hostname>"}
+}
+
+func fixTimeout(d *Device, opts Options) []string {
+	failing := modes(d.Access, func(a Access) bool {
+		return a.Timeout == Unset && a.Kind != Aux || a.Timeout == 0 || a.Timeout > 300
+	})
+	switch d.OS {
+	case "junos":
+		if len(failing) == 0 {
+			return []string{"set system login class <class> idle-timeout 5"}
+		}
+		var config []string
+		for _, mode := range under(failing) {
+			config = append(config, "set "+mode+" idle-timeout 5")
+		}
+		return config
+	case cisco.NXOS:
+		return under(failing, " exec-timeout 5")
+	}
+	return under(failing, " exec-timeout 5 0")
+}
+
+// managementACL is the filter that only lets the management networks in,
+// named MGMT-ACCESS.
+func managementACL(osName string) []string {
+	switch osName {
+	case cisco.IOSXR:
+		return []string{"ipv4 access-list MGMT-ACCESS", " permit ipv4 <management> any", " deny ipv4 any any log"}
+	case cisco.NXOS:
+		return []string{"ip access-list MGMT-ACCESS", " permit ip <management> any", " deny ip any any log"}
+	}
+	return []string{"ip access-list standard MGMT-ACCESS", " permit <management-address> <management-wildcard>", " deny any log"}
+}
+
+// junosFilter discards traffic to ports from outside the management
+// networks and accepts the rest, and applies the filter to lo0. Its terms
+// go before the final accept, which an earlier fix may have set.
+func junosFilter(name, protocol string, ports []int) []string {
+	f := "set firewall family inet filter PROTECT-MGMT term " + name
+	config := []string{f + "-allow from source-address <management>", f + "-allow from protocol " + protocol}
+	for _, p := range ports {
+		config = append(config, fmt.Sprintf("%s-allow from destination-port %d", f, p))
+	}
+	config = append(config, f+"-allow then accept", f+"-deny from protocol "+protocol)
+	for _, p := range ports {
+		config = append(config, fmt.Sprintf("%s-deny from destination-port %d", f, p))
+	}
+	return append(config,
+		f+"-deny then discard",
+		"set firewall family inet filter PROTECT-MGMT term other then accept",
+		"insert firewall family inet filter PROTECT-MGMT term other after term "+name+"-deny",
+		"set interfaces lo0 unit 0 family inet filter input-list PROTECT-MGMT",
+		"# if lo0 has an input filter rather than an input-list, move it into the list",
+	)
+}
+
+func fixRemoteACL(d *Device, opts Options) []string {
+	exposed := func(a Access) bool { return a.Kind == Remote && len(a.Exposure) > 0 }
+	var config []string
+	if len(d.Exposure) > 0 {
+		config = append(config, "# restrict the device-wide filters listed above to the management networks by hand")
+	}
+	if !slices.ContainsFunc(d.Access, exposed) {
+		return config
+	}
+	switch d.OS {
+	case "junos":
+		var ports []int
+		for _, a := range d.Access {
+			if exposed(a) && !slices.Contains(ports, a.Port) {
+				ports = append(ports, a.Port)
+			}
+		}
+		return append(config, junosFilter("mgmt", "tcp", ports)...)
+	case cisco.IOSXR:
+		config = append(config, managementACL(d.OS)...)
+		return append(config, under(modes(d.Access, exposed), " access-class ingress MGMT-ACCESS")...)
+	}
+	config = append(config, managementACL(d.OS)...)
+	return append(config, under(modes(d.Access, exposed), " access-class MGMT-ACCESS in")...)
+}
+
+func fixRemoteAuth(d *Device, opts Options) []string {
+	switch d.OS {
+	case "junos":
+		return []string{
+			"set system tacplus-server <tacacs> secret \"<tacacs-key>\"",
+			"delete system authentication-order",
+			"set system authentication-order tacplus",
+			"set system authentication-order password",
+		}
+	case cisco.NXOS:
+		return []string{
+			"feature tacacs+",
+			"tacacs-server key <tacacs-key>",
+			"tacacs-server host <tacacs>",
+			"aaa group server tacacs+ TACACS",
+			" server <tacacs>",
+			"aaa authentication login default group TACACS",
+		}
+	}
+	failing := modes(d.Access, func(a Access) bool {
+		return a.Kind == Remote && (!slices.Contains(a.Login, TACACS) && !slices.Contains(a.Login, RADIUS) || slices.Contains(a.Login, None))
+	})
+	config := []string{"tacacs-server host <tacacs> port 49", "tacacs-server key <tacacs-key>"}
+	if d.OS == cisco.IOSXE {
+		config = []string{"aaa new-model", "tacacs-server host <tacacs> key <tacacs-key>"}
+	}
+	config = append(config, "aaa authentication login default group tacacs+ local")
+	return append(config, under(failing, " login authentication default")...)
+}
+
+func fixNTP(d *Device, opts Options) []string {
+	switch d.OS {
+	case "junos":
+		return []string{"set system ntp server <ntp>"}
+	case cisco.IOSXR:
+		return []string{"ntp", " server <ntp>"}
+	}
+	return []string{"ntp server <ntp>"}
+}
+
+func fixSNMP(d *Device, opts Options) []string {
+	s := d.SNMP
+	var (
+		config    []string
+		community bool
+	)
+	for _, c := range s.Communities {
+		if slices.Contains(check.WellKnownCommunities, strings.ToLower(c.Name)) || c.Write || len(c.Exposure) > 0 {
+			config = append(config, "# remove the "+c.Where)
+			community = true
+		}
+	}
+	if community {
+		switch d.OS {
+		case "junos":
+			config = append(config,
+				"set snmp community <snmp-community> authorization read-only",
+				"set snmp community <snmp-community> clients <management>",
+				"set snmp community <snmp-community> clients 0.0.0.0/0 restrict",
+			)
+		case cisco.IOSXR:
+			config = append(config, managementACL(d.OS)...)
+			config = append(config, "snmp-server community <snmp-community> RO IPv4 MGMT-ACCESS")
+		case cisco.NXOS:
+			config = append(config, managementACL(d.OS)...)
+			config = append(config,
+				"snmp-server community <snmp-community> group network-operator",
+				"snmp-server community <snmp-community> use-ipv4acl MGMT-ACCESS",
+			)
+		default:
+			config = append(config, managementACL(d.OS)...)
+			config = append(config, "snmp-server community <snmp-community> RO MGMT-ACCESS")
+		}
+	}
+	for _, u := range s.Users {
+		if u.Auth == "sha" && u.Priv == "aes" {
+			continue
+		}
+		keys := "<" + u.Name + "-auth-key> priv aes 128 <" + u.Name + "-priv-key>"
+		switch d.OS {
+		case "junos":
+			user := "set snmp v3 usm local-engine user " + u.Name
+			config = append(config,
+				"delete snmp v3 usm local-engine user "+u.Name,
+				user+" authentication-sha authentication-password \"<"+u.Name+"-auth-key>\"",
+				user+" privacy-aes128 privacy-password \"<"+u.Name+"-priv-key>\"",
+			)
+		case cisco.NXOS:
+			config = append(config, "snmp-server user "+u.Name+" auth sha <"+u.Name+"-auth-key> priv aes-128 <"+u.Name+"-priv-key>")
+		default:
+			config = append(config, "snmp-server user "+u.Name+" <snmp-group> v3 auth sha "+keys)
+		}
+	}
+	if d.OS == "junos" && len(s.Exposure) > 0 {
+		config = append(config, junosFilter("snmp", "udp", []int{161})...)
+	} else if len(s.Exposure) > 0 {
+		config = append(config, "# restrict the SNMP filters listed above to the management networks by hand")
+	}
+	if len(s.Hosts) == 0 {
+		switch d.OS {
+		case "junos":
+			config = append(config, "set snmp trap-group TRAPS targets <snmp-host>")
+		case cisco.IOSXE:
+			config = append(config, "snmp-server host <snmp-host> version 3 priv <snmp-user>")
+		default:
+			config = append(config, "snmp-server host <snmp-host> traps version 3 priv <snmp-user>")
+		}
+	}
+	return config
+}
+
+var junosServices = map[string]string{
+	"telnet": "system services telnet",
+	"http":   "system services web-management http",
+	"https":  "system services web-management https",
+}
+
+func fixSSH(d *Device, opts Options) []string {
+	var config []string
+	if !slices.Equal(d.SSH, []string{"2"}) {
+		switch d.OS {
+		case "junos":
+			if d.SSH != nil {
+				config = append(config, "delete system services ssh protocol-version")
+			}
+			config = append(config, "set system services ssh protocol-version v2")
+		case cisco.IOSXR:
+			if slices.Contains(d.SSH, "1") {
+				config = append(config, "no ssh server v1")
+			}
+			config = append(config, "ssh server v2")
+		case cisco.NXOS:
+			config = append(config, "feature ssh")
+		default:
+			config = append(config, "ip ssh version 2")
+		}
+	}
+	var failing []Access
+	for _, a := range d.Access {
+		if a.Kind == Remote && (a.Protocols == nil || slices.ContainsFunc(a.Protocols, func(p string) bool { return p != "ssh" })) {
+			failing = append(failing, a)
+		}
+	}
+	switch d.OS {
+	case "junos":
+		for _, a := range failing {
+			for _, p := range a.Protocols {
+				if service, ok := junosServices[p]; ok {
+					config = append(config, "delete "+service)
+				}
+			}
+		}
+	case cisco.NXOS:
+		if slices.ContainsFunc(failing, func(a Access) bool { return slices.Contains(a.Protocols, "telnet") }) {
+			config = append(config, "no feature telnet")
+		}
+	default:
+		config = append(config, under(modes(failing, func(Access) bool { return true }), " transport input ssh")...)
+	}
+	return config
+}
+
+func fixPasswords(d *Device, opts Options) []string {
+	if len(d.Users) == 0 && len(d.Enable) == 0 {
+		switch d.OS {
+		case "junos":
+			return []string{"set system root-authentication encrypted-password \"<root-password-hash>\""}
+		case cisco.IOSXR:
+			return []string{"username <admin-user>", " group root-lr", " secret <admin-password>"}
+		case cisco.NXOS:
+			return []string{"username <admin-user> password <admin-password> role network-admin"}
+		}
+		return []string{"username <admin-user> privilege 15 algorithm-type scrypt secret <admin-password>"}
+	}
+	config := fixEnable(d.Enable)
+	for _, u := range d.Users {
+		if _, weak := storageProblems[u.Storage]; !weak {
+			continue
+		}
+		password := "<" + u.Name + "-password>"
+		switch {
+		case d.OS == "junos" && u.Name == "root":
+			config = append(config, "set system root-authentication encrypted-password \"<root-password-hash>\"")
+		case d.OS == "junos":
+			config = append(config, "set system login user "+u.Name+" authentication encrypted-password \"<"+u.Name+"-password-hash>\"")
+		case d.OS == cisco.IOSXR:
+			config = append(config, "username "+u.Name, " no password", " secret "+password)
+		case d.OS == cisco.NXOS:
+			config = append(config, "username "+u.Name+" password "+password)
+		default:
+			config = append(config, "username "+u.Name+" algorithm-type scrypt secret "+password)
+		}
+	}
+	if d.OS == "junos" && len(config) > 0 {
+		config = append(config, "# make the hashes with openssl passwd -6")
+	}
+	return config
+}
+
+// fixEnable removes a weak enable password and replaces a weak enable
+// secret. A strong secret already overrides the password, so then the
+// password only has to go.
+func fixEnable(enable []User) []string {
+	var (
+		config         []string
+		password, weak bool
+		strongSecret   bool
+	)
+	for _, u := range enable {
+		_, bad := storageProblems[u.Storage]
+		switch {
+		case u.Name == "enable password":
+			password = true
+			weak = weak || bad
+		case bad:
+			weak = true
+		default:
+			strongSecret = true
+		}
+	}
+	if !weak {
+		return nil
+	}
+	if password {
+		config = append(config, "no enable password")
+	}
+	if !strongSecret {
+		config = append(config, "enable algorithm-type scrypt secret <enable-secret>")
+	}
+	return config
+}
+
+func fixSyslog(d *Device, opts Options) []string {
+	switch d.OS {
+	case "junos":
+		return []string{
+			"set system syslog host <siem-host> any info",
+			"set system syslog host <siem-host> port <siem-port>",
+		}
+	case cisco.IOSXR:
+		return []string{"logging <siem-host> port <siem-port>"}
+	case cisco.NXOS:
+		return []string{"logging server <siem-host> 6 port <siem-port>"}
+	}
+	return []string{"logging host <siem-host> transport udp port <siem-port>"}
+}
+
+func fixLockout(d *Device, opts Options) []string {
+	switch d.OS {
+	case "junos":
+		return []string{
+			"set system login retry-options tries-before-disconnect 5",
+			"set system login retry-options lockout-period 15",
+		}
+	case cisco.IOSXR:
+		return []string{
+			"aaa password-policy LOCKOUT",
+			" authen-max-attempts 5",
+			" lockout-time minutes 15",
+			"# add password-policy LOCKOUT to the password of each local user",
+		}
+	case cisco.NXOS:
+		return []string{"aaa authentication rejected 5 in 120 ban 300"}
+	}
+	return []string{"login block-for 300 attempts 5 within 120"}
+}
+
+func fixBackup(d *Device, opts Options) []string {
+	switch d.OS {
+	case "junos":
+		return []string{
+			"set system archival configuration transfer-on-commit",
+			"set system archival configuration archive-sites <backup>",
+		}
+	case cisco.IOSXR:
+		return []string{"configuration commit auto-save filename <backup>"}
+	case cisco.NXOS:
+		return []string{
+			"feature scheduler",
+			"scheduler job name BACKUP",
+			" copy running-config <backup>$(SWITCHNAME)-cfg.$(TIMESTAMP)",
+			"end-job",
+			"scheduler schedule name BACKUP-DAILY",
+			" job name BACKUP",
+			" time daily 23:00",
+		}
+	}
+	return []string{"archive", " path <backup>$h-$t", " write-memory"}
+}
@@ -0,0 +1,123 @@
+package netdevice
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// samples are the configurations shipped with the shell checks, one per OS.
+var samples = map[string]string{
+	"iosxe": "../../iosxe/iosxe.cfg",
+	"cat8k": "../../iosxe/cat8k.cfg",
+	"asr9k": "../../iosxr/asr9k.cfg",
+	"n9k":   "../../nxos/n9k.cfg",
+	"jsw":   "../../junos/jsw.cfg",
+}
+
+// TestRemediationGolden compares the patch for each sample with the one in
+// testdata/remediate, without variables and with testdata/vars.yaml. Run
+// go test -update to rewrite them after an intended change.
+func TestRemediationGolden(t *testing.T) {
+	vars, err := LoadVariables("testdata/vars.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range samples {
+		for suffix, v := range map[string]Variables{"": nil, "-vars": vars} {
+			got, err := GetRemediation([]string{path}, "", v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "remediate", name+suffix+".txt")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("%s: remediation differs from %s:\n%s", name+suffix, golden, got)
+			}
+		}
+	}
+}
+
+func TestLoadVariables(t *testing.T) {
+	vars, err := LoadVariables("testdata/vars.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := vars["ntp"], []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ntp: got %q, want %q", got, want)
+	}
+	if got, want := vars["snmp-host"], []string{"10.0.0.9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("snmp-host: got %q, want %q", got, want)
+	}
+	values := vars.derive([]string{"192.168.89.10:1514", "10.1.1.1"})
+	for name, want := range map[string]string{
+		"siem-host":           "192.168.89.10 10.1.1.1",
+		"siem-port":           "1514 514",
+		"management-address":  "10.10.0.0",
+		"management-mask":     "255.255.255.0",
+		"management-wildcard": "0.0.0.255",
+	} {
+		if got := strings.Join(values[name], " "); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	values := Variables{"ntp": {"10.0.0.1", "10.0.0.2"}, "siem-host": {"a", "b"}, "siem-port": {"514", "1514"}}
+	missing := make(map[string]bool)
+	for _, tc := range []struct {
+		line string
+		want []string
+	}{
+		{"ntp server <ntp>", []string{"ntp server 10.0.0.1", "ntp server 10.0.0.2"}},
+		{"logging <siem-host> port <siem-port>", []string{"logging a port 514", "logging b port 1514"}},
+		{"tacacs-server host <tacacs> key <tacacs-key>", []string{"tacacs-server host <tacacs> key <tacacs-key>"}},
+	} {
+		if got := fill(tc.line, values, missing); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.line, got, tc.want)
+		}
+	}
+	if !missing["tacacs"] || !missing["tacacs-key"] || len(missing) != 2 {
+		t.Errorf("missing: got %v", missing)
+	}
+}
+
+func TestFixEnable(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		enable []User
+		want   []string
+	}{
+		{"strong secret", []User{{"enable secret", Scrypt}, {"enable password", Reversible}},
+			[]string{"no enable password"}},
+		{"password only", []User{{"enable password", Cleartext}},
+			[]string{"no enable password", "enable algorithm-type scrypt secret <enable-secret>"}},
+		{"md5 secret", []User{{"enable secret", MD5}},
+			[]string{"enable algorithm-type scrypt secret <enable-secret>"}},
+		{"secret only", []User{{"enable secret", Scrypt}}, nil},
+	} {
+		if got := fixEnable(tc.enable); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	// A user called enable is an account like any other.
+	d := &Device{OS: "iosxe", Users: []User{{"enable", Reversible}}}
+	if got, want := fixPasswords(d, Options{}), []string{"username enable algorithm-type scrypt secret <enable-password>"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("username enable: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"checklist/platform"
+
+	"github.com/spf13/cobra"
+)
+
+var platformCmd = &cobra.Command{
+	Use:   "platform",
+	Short: "Show the detected distribution, init system and virtualization",
+	Args:  cobra.NoArgs,
+	RunE:  runPlatform,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	rootCmd.AddCommand(platformCmd)
+}
+
+func runPlatform(cmd *cobra.Command, args []string) error {
+	result, err := platform.GetPlatform()
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
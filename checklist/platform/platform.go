@@ -0,0 +1,65 @@
+package platform
+
+const (
+	FamilyDebian = "debian"
+	FamilyRHEL   = "rhel"
+	FamilySUSE   = "suse"
+
+	InitSystemd = "systemd"
+	InitOpenRC  = "openrc"
+	InitUpstart = "upstart"
+	InitSysV    = "sysvinit"
+)
+
+// series maps a distribution ID to its script directory and ID pattern.
+var series = map[string]Series{
+	"ubuntu": {"ubuntu", "1xxx"},
+	"debian": {"debian", "11xxx"},
+	"rhel":   {"redhat", "2xxx"},
+	"rocky":  {"rockey", "3xxx"},
+	"centos": {"centos", "5xxx"},
+	"sles":   {"suse", "2xx"},
+	"sled":   {"suse", "2xx"},
+	"ol":     {"oraclelinux", "1xx"},
+}
+
+// familySeries is used for derivatives with no series of their own.
+var familySeries = map[string]Series{
+	FamilyDebian: series["debian"],
+	FamilyRHEL:   series["rhel"],
+	FamilySUSE:   series["sles"],
+}
+
+// Series is the script directory and ID numbering used for a distribution.
+type Series struct {
+	Distro  string
+	Pattern string
+}
+
+// Info describes the running operating system.
+type Info struct {
+	ID             string
+	Like           []string
+	Name           string
+	Version        string
+	Family         string
+	InitSystem     string
+	Container      string
+	Virtualization string
+	Source         string
+}
+
+// Series returns the script series matching the distribution, falling back
+// to the one of its family.
+func (i Info) Series() (Series, bool) {
+	if s, ok := series[i.ID]; ok {
+		return s, true
+	}
+	for _, id := range i.Like {
+		if s, ok := series[id]; ok {
+			return s, true
+		}
+	}
+	s, ok := familySeries[i.Family]
+	return s, ok
+}
@@ -0,0 +1,9 @@
+package platform
+
+func GetPlatform() (string, error) {
+	return "", nil
+}
+
+func Detect() Info {
+	return Info{}
+}
@@ -0,0 +1,284 @@
+package platform
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// families maps os-release ID and ID_LIKE values to a package family.
+var families = map[string]string{
+	"debian":    FamilyDebian,
+	"ubuntu":    FamilyDebian,
+	"rhel":      FamilyRHEL,
+	"centos":    FamilyRHEL,
+	"fedora":    FamilyRHEL,
+	"rocky":     FamilyRHEL,
+	"almalinux": FamilyRHEL,
+	"ol":        FamilyRHEL,
+	"suse":      FamilySUSE,
+	"opensuse":  FamilySUSE,
+	"sles":      FamilySUSE,
+}
+
+// legacyReleases are read when os-release is missing, as on RHEL 6 and
+// SLES 11. Each pattern captures the version.
+var legacyReleases = []struct {
+	path    string
+	pattern *regexp.Regexp
+	ids     []struct{ match, id string }
+}{
+	{
+		path:    "/etc/redhat-release",
+		pattern: regexp.MustCompile(`release\s+([0-9][0-9.]*)`),
+		ids: []struct{ match, id string }{
+			{"centos", "centos"},
+			{"rocky", "rocky"},
+			{"oracle", "ol"},
+			{"red hat", "rhel"},
+			{"fedora", "fedora"},
+		},
+	},
+	{
+		path:    "/etc/SuSE-release",
+		pattern: regexp.MustCompile(`(?m)^VERSION\s*=\s*([0-9.]+)`),
+		ids: []struct{ match, id string }{
+			{"opensuse", "opensuse"},
+			{"suse", "sles"},
+		},
+	},
+}
+
+// hypervisors maps DMI vendor and product strings to a virtualization type.
+var hypervisors = []struct{ match, name string }{
+	{"kvm", "kvm"},
+	{"qemu", "qemu"},
+	{"vmware", "vmware"},
+	{"virtualbox", "virtualbox"},
+	{"innotek", "virtualbox"},
+	{"xen", "xen"},
+	{"microsoft corporation", "hyperv"},
+	{"amazon ec2", "amazon"},
+	{"google", "google"},
+	{"openstack", "openstack"},
+	{"parallels", "parallels"},
+}
+
+func GetPlatform() (string, error) {
+	info := Detect()
+	var result string
+	result += fmt.Sprintf("id: %s\n", valueOr(info.ID, "<unknown>"))
+	result += fmt.Sprintf("name: %s\n", valueOr(info.Name, "<unknown>"))
+	result += fmt.Sprintf("version: %s\n", valueOr(info.Version, "<unknown>"))
+	if len(info.Like) > 0 {
+		result += fmt.Sprintf("like: %s\n", strings.Join(info.Like, " "))
+	}
+	result += fmt.Sprintf("family: %s\n", valueOr(info.Family, "<unknown>"))
+	result += fmt.Sprintf("init: %s\n", valueOr(info.InitSystem, "<unknown>"))
+	result += fmt.Sprintf("container: %s\n", valueOr(info.Container, "none"))
+	result += fmt.Sprintf("virtualization: %s\n", valueOr(info.Virtualization, "none"))
+	result += fmt.Sprintf("source: %s\n", valueOr(info.Source, "<none>"))
+	if s, ok := info.Series(); ok {
+		result += fmt.Sprintf("series: %s (%s)\n", s.Pattern, s.Distro)
+	} else {
+		result += "series: <none>\n"
+	}
+	return result, nil
+}
+
+// Detect identifies the distribution, init system and any container or
+// hypervisor the host runs in. Fields that cannot be determined are empty.
+func Detect() Info {
+	var info Info
+	for _, path := range osReleasePaths {
+		if fields, err := ParseOSRelease(path); err == nil {
+			info.ID = fields["ID"]
+			info.Like = strings.Fields(fields["ID_LIKE"])
+			info.Name = fields["NAME"]
+			info.Version = fields["VERSION_ID"]
+			info.Source = path
+			break
+		}
+	}
+	if info.ID == "" {
+		info.ID, info.Name, info.Version, info.Source = legacyRelease()
+	}
+	info.Family = family(info)
+	info.InitSystem = initSystem()
+	info.Container = container()
+	info.Virtualization = virtualization()
+	return info
+}
+
+// ParseOSRelease reads the KEY=value pairs of an os-release file, removing
+// shell quoting.
+func ParseOSRelease(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		fields[key] = unquote(value)
+	}
+	return fields, scanner.Err()
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+func legacyRelease() (id, name, version, source string) {
+	for _, lr := range legacyReleases {
+		content, err := os.ReadFile(lr.path)
+		if err != nil {
+			continue
+		}
+		first, _, _ := strings.Cut(string(content), "\n")
+		name = strings.TrimSpace(first)
+		lower := strings.ToLower(name)
+		for _, candidate := range lr.ids {
+			if strings.Contains(lower, candidate.match) {
+				id = candidate.id
+				break
+			}
+		}
+		if m := lr.pattern.FindStringSubmatch(string(content)); m != nil {
+			version = m[1]
+		}
+		return id, name, version, lr.path
+	}
+	return "", "", "", ""
+}
+
+func family(info Info) string {
+	for _, id := range append([]string{info.ID}, info.Like...) {
+		if f, ok := families[id]; ok {
+			return f
+		}
+	}
+	switch {
+	case exists("/var/lib/dpkg/status"):
+		return FamilyDebian
+	case exists("/var/lib/rpm"), exists("/usr/lib/sysimage/rpm"):
+		return FamilyRHEL
+	}
+	return ""
+}
+
+func initSystem() string {
+	if exists("/run/systemd/system") {
+		return InitSystemd
+	}
+	if exists("/run/openrc") {
+		return InitOpenRC
+	}
+	comm, err := os.ReadFile("/proc/1/comm")
+	if err != nil {
+		return ""
+	}
+	switch name := strings.TrimSpace(string(comm)); name {
+	case "systemd":
+		return InitSystemd
+	case "openrc-init":
+		return InitOpenRC
+	case "init":
+		if exists("/sbin/initctl") && exists("/etc/init") {
+			return InitUpstart
+		}
+		return InitSysV
+	default:
+		// Containers usually run the application itself as PID 1.
+		return name
+	}
+}
+
+func container() string {
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, kv := range strings.Split(string(environ), "\x00") {
+			if value, ok := strings.CutPrefix(kv, "container="); ok && value != "" {
+				return value
+			}
+		}
+	}
+	if content, err := os.ReadFile("/run/systemd/container"); err == nil {
+		return strings.TrimSpace(string(content))
+	}
+	switch {
+	case exists("/.dockerenv"):
+		return "docker"
+	case exists("/run/.containerenv"):
+		return "podman"
+	}
+	if cgroup, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		for _, name := range []string{"kubepods", "docker", "containerd", "lxc"} {
+			if strings.Contains(string(cgroup), name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func virtualization() string {
+	var dmi []string
+	for _, path := range []string{"/sys/class/dmi/id/sys_vendor", "/sys/class/dmi/id/product_name"} {
+		if content, err := os.ReadFile(path); err == nil {
+			dmi = append(dmi, strings.ToLower(strings.TrimSpace(string(content))))
+		}
+	}
+	for _, h := range hypervisors {
+		if slices.ContainsFunc(dmi, func(s string) bool { return strings.Contains(s, h.match) }) {
+			return h.name
+		}
+	}
+	if content, err := os.ReadFile("/sys/hypervisor/type"); err == nil {
+		return strings.TrimSpace(string(content))
+	}
+	if cpuinfo, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(cpuinfo), "\n") {
+			if strings.HasPrefix(line, "flags") && slices.Contains(strings.Fields(line), "hypervisor") {
+				return "unknown"
+			}
+		}
+	}
+	return ""
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
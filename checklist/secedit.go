@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"checklist/secedit"
+
+	"github.com/spf13/cobra"
+)
+
+var seceditNames string
+
+var seceditCmd = &cobra.Command{
+	Use:   "secedit <export.inf>...",
+	Short: "Run the password, lockout and user right checks against secedit /export files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSecedit,
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	seceditCmd.Flags().StringVar(&seceditNames, "sids", "", "YAML file naming the SIDs of local and domain accounts")
+	rootCmd.AddCommand(seceditCmd)
+}
+
+func runSecedit(cmd *cobra.Command, args []string) error {
+	var names map[string]string
+	if seceditNames != "" {
+		var err error
+		if names, err = secedit.LoadNames(seceditNames); err != nil {
+			return err
+		}
+	}
+	result, err := secedit.GetChecks(args, names)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
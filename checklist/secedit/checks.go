@@ -0,0 +1,136 @@
+package secedit
+
+import (
+	"fmt"
+	"strings"
+
+	"checklist/check"
+)
+
+// Check is a Windows check that one export is enough for. The IDs are
+// those of the scripts in windows/, which read net accounts, secedit or
+// the registry on the host.
+type Check struct {
+	ID    string
+	Title string
+	run   func(p *Policy) []string
+}
+
+var Checks = []Check{
+	{"4001", "Minimum password length is 15 or more", checkMinimumLength},
+	{"4002", "Passwords must meet complexity requirements", checkComplexity},
+	{"4003", "Password history remembers 24 or more passwords", checkHistory},
+	{"4004", "Passwords are not stored with reversible encryption", checkReversible},
+	{"4005", "Locked out accounts stay locked for 15 minutes or more", checkLockoutDuration},
+	{"4006", "Accounts lock out after 1 to 5 failed logons", checkLockoutThreshold},
+	{"4007", "Act as part of the operating system is assigned to no one", checkTcb},
+	{"4008", "A logon banner is shown", checkBanner},
+	{"4009", "The Guest account is disabled", checkGuest},
+}
+
+// Audit runs every check against the policy.
+func Audit(p *Policy) []check.Result {
+	var results []check.Result
+	for _, c := range Checks {
+		results = append(results, check.Result{ID: c.ID, Title: c.Title, Findings: c.run(p)})
+	}
+	return results
+}
+
+func GetChecks(paths []string, names map[string]string) (string, error) {
+	var result string
+	for _, path := range paths {
+		p, err := Parse(path, names)
+		if err != nil {
+			return "", err
+		}
+		result += check.Format(p.Path, Audit(p))
+	}
+	return result, nil
+}
+
+// atLeast reports a setting that is not defined or below min.
+func atLeast(s Setting, name string, min int) []string {
+	switch {
+	case !s.Set:
+		return []string{name + " is not defined"}
+	case s.Value < min:
+		return []string{fmt.Sprintf("%s is %d, want %d or more", name, s.Value, min)}
+	}
+	return nil
+}
+
+// equals reports a setting that is not defined or not want.
+func equals(s Setting, name string, want int) []string {
+	switch {
+	case !s.Set:
+		return []string{name + " is not defined"}
+	case s.Value != want:
+		return []string{fmt.Sprintf("%s is %d, want %d", name, s.Value, want)}
+	}
+	return nil
+}
+
+func checkMinimumLength(p *Policy) []string {
+	return atLeast(p.Access.MinimumPasswordLength, "MinimumPasswordLength", 15)
+}
+
+func checkComplexity(p *Policy) []string {
+	return equals(p.Access.PasswordComplexity, "PasswordComplexity", 1)
+}
+
+func checkHistory(p *Policy) []string {
+	return atLeast(p.Access.PasswordHistorySize, "PasswordHistorySize", 24)
+}
+
+func checkReversible(p *Policy) []string {
+	return equals(p.Access.ClearTextPassword, "ClearTextPassword", 0)
+}
+
+func checkLockoutDuration(p *Policy) []string {
+	if d := p.Access.LockoutDuration; d.Set && d.Value == -1 {
+		// Accounts stay locked until an administrator unlocks them.
+		return nil
+	}
+	return atLeast(p.Access.LockoutDuration, "LockoutDuration", 15)
+}
+
+func checkLockoutThreshold(p *Policy) []string {
+	n := p.Access.LockoutBadCount
+	switch {
+	case !n.Set:
+		return []string{"LockoutBadCount is not defined"}
+	case n.Value == 0:
+		return []string{"LockoutBadCount is 0, accounts never lock out"}
+	case n.Value > 5:
+		return []string{fmt.Sprintf("LockoutBadCount is %d, want 1 to 5", n.Value)}
+	}
+	return nil
+}
+
+// checkTcb passes when SeTcbPrivilege is missing or empty, since secedit
+// leaves out rights that are assigned to no one.
+func checkTcb(p *Policy) []string {
+	var findings []string
+	for _, a := range p.Rights["SeTcbPrivilege"] {
+		findings = append(findings, "SeTcbPrivilege is assigned to "+a.String())
+	}
+	return findings
+}
+
+const systemPolicies = `MACHINE\Software\Microsoft\Windows\CurrentVersion\Policies\System\`
+
+func checkBanner(p *Policy) []string {
+	var findings []string
+	for _, name := range []string{"LegalNoticeCaption", "LegalNoticeText"} {
+		v := p.Registry[systemPolicies+name]
+		if strings.TrimSpace(strings.Join(v.Data, "")) == "" {
+			findings = append(findings, name+" is empty")
+		}
+	}
+	return findings
+}
+
+func checkGuest(p *Policy) []string {
+	return equals(p.Access.EnableGuestAccount, "EnableGuestAccount", 0)
+}
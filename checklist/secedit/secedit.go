@@ -0,0 +1,391 @@
+package secedit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Setting is a numeric System Access value. Set is false when the export
+// leaves it out, as it does for policies that were never defined.
+type Setting struct {
+	Value int
+	Set   bool
+}
+
+// SystemAccess holds the password, lockout and account settings. A
+// MaximumPasswordAge or LockoutDuration of -1 means never.
+type SystemAccess struct {
+	MinimumPasswordAge     Setting
+	MaximumPasswordAge     Setting
+	MinimumPasswordLength  Setting
+	PasswordComplexity     Setting
+	PasswordHistorySize    Setting
+	LockoutBadCount        Setting
+	ResetLockoutCount      Setting
+	LockoutDuration        Setting
+	ClearTextPassword      Setting
+	RequireLogonToChange   Setting
+	ForceLogoffWhenExpired Setting
+	LSAAnonymousNameLookup Setting
+	EnableAdminAccount     Setting
+	EnableGuestAccount     Setting
+	NewAdministratorName   string
+	NewGuestName           string
+}
+
+func (a *SystemAccess) settings() map[string]*Setting {
+	return map[string]*Setting{
+		"MinimumPasswordAge":           &a.MinimumPasswordAge,
+		"MaximumPasswordAge":           &a.MaximumPasswordAge,
+		"MinimumPasswordLength":        &a.MinimumPasswordLength,
+		"PasswordComplexity":           &a.PasswordComplexity,
+		"PasswordHistorySize":          &a.PasswordHistorySize,
+		"LockoutBadCount":              &a.LockoutBadCount,
+		"ResetLockoutCount":            &a.ResetLockoutCount,
+		"LockoutDuration":              &a.LockoutDuration,
+		"ClearTextPassword":            &a.ClearTextPassword,
+		"RequireLogonToChangePassword": &a.RequireLogonToChange,
+		"ForceLogoffWhenHourExpire":    &a.ForceLogoffWhenExpired,
+		"LSAAnonymousNameLookup":       &a.LSAAnonymousNameLookup,
+		"EnableAdminAccount":           &a.EnableAdminAccount,
+		"EnableGuestAccount":           &a.EnableGuestAccount,
+	}
+}
+
+// EventAudit is a legacy audit policy category, such as AuditLogonEvents.
+type EventAudit struct {
+	Success bool
+	Failure bool
+}
+
+// Account is a user or group a right is assigned to. Name is empty when
+// the SID is not in the table.
+type Account struct {
+	SID  string
+	Name string
+}
+
+func (a Account) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.SID
+}
+
+// Registry value types, as secedit numbers them.
+const (
+	String       = 1
+	ExpandString = 2
+	Binary       = 3
+	DWord        = 4
+	MultiString  = 7
+)
+
+// RegistryValue is a security option stored in the registry. Data holds
+// one string, or one per line for a multi-string.
+type RegistryValue struct {
+	Type int
+	Data []string
+}
+
+// Int returns a DWORD value.
+func (v RegistryValue) Int() (int, bool) {
+	if v.Type != DWord || len(v.Data) != 1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v.Data[0])
+	return n, err == nil
+}
+
+// Policy is a secedit /export file. Rights maps a privilege or logon
+// right, such as SeTcbPrivilege, to its accounts; Registry is keyed by the
+// value's path, as MACHINE\Software\...\LegalNoticeText.
+type Policy struct {
+	Path     string
+	Access   SystemAccess
+	Audit    map[string]EventAudit
+	Rights   map[string][]Account
+	Registry map[string]RegistryValue
+}
+
+// WellKnownSIDs names the built-in accounts and groups. Domain accounts
+// are named by their relative ID, see domainRIDs.
+var WellKnownSIDs = map[string]string{
+	"S-1-0-0":      "Nobody",
+	"S-1-1-0":      "Everyone",
+	"S-1-2-0":      "LOCAL",
+	"S-1-2-1":      "CONSOLE LOGON",
+	"S-1-3-0":      "CREATOR OWNER",
+	"S-1-3-1":      "CREATOR GROUP",
+	"S-1-5-1":      "DIALUP",
+	"S-1-5-2":      "NETWORK",
+	"S-1-5-3":      "BATCH",
+	"S-1-5-4":      "INTERACTIVE",
+	"S-1-5-6":      "SERVICE",
+	"S-1-5-7":      "ANONYMOUS LOGON",
+	"S-1-5-9":      "ENTERPRISE DOMAIN CONTROLLERS",
+	"S-1-5-10":     "SELF",
+	"S-1-5-11":     "Authenticated Users",
+	"S-1-5-13":     "TERMINAL SERVER USER",
+	"S-1-5-14":     "REMOTE INTERACTIVE LOGON",
+	"S-1-5-17":     "IUSR",
+	"S-1-5-18":     "SYSTEM",
+	"S-1-5-19":     "LOCAL SERVICE",
+	"S-1-5-20":     "NETWORK SERVICE",
+	"S-1-5-32-544": "Administrators",
+	"S-1-5-32-545": "Users",
+	"S-1-5-32-546": "Guests",
+	"S-1-5-32-547": "Power Users",
+	"S-1-5-32-548": "Account Operators",
+	"S-1-5-32-549": "Server Operators",
+	"S-1-5-32-550": "Print Operators",
+	"S-1-5-32-551": "Backup Operators",
+	"S-1-5-32-552": "Replicator",
+	"S-1-5-32-554": "Pre-Windows 2000 Compatible Access",
+	"S-1-5-32-555": "Remote Desktop Users",
+	"S-1-5-32-556": "Network Configuration Operators",
+	"S-1-5-32-558": "Performance Monitor Users",
+	"S-1-5-32-559": "Performance Log Users",
+	"S-1-5-32-562": "Distributed COM Users",
+	"S-1-5-32-568": "IIS_IUSRS",
+	"S-1-5-32-569": "Cryptographic Operators",
+	"S-1-5-32-573": "Event Log Readers",
+	"S-1-5-32-578": "Hyper-V Administrators",
+	"S-1-5-32-580": "Remote Management Users",
+	"S-1-5-80-0":   "All Services",
+	"S-1-5-83-0":   "Virtual Machines",
+	"S-1-5-90-0":   "Window Manager Group",
+	"S-1-5-113":    "Local account",
+	"S-1-5-114":    "Local account and member of Administrators group",
+}
+
+var domainRIDs = map[string]string{
+	"500": "Administrator",
+	"501": "Guest",
+	"502": "krbtgt",
+	"512": "Domain Admins",
+	"513": "Domain Users",
+	"514": "Domain Guests",
+	"515": "Domain Computers",
+	"516": "Domain Controllers",
+	"518": "Schema Admins",
+	"519": "Enterprise Admins",
+}
+
+// LoadNames reads a table of SIDs and the names of the host's or
+// domain's own accounts, as in:
+//
+//	S-1-5-21-3623811015-3361044348-30300820-1013: svc-backup
+func LoadNames(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names map[string]string
+	if err := yaml.Unmarshal(content, &names); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return names, nil
+}
+
+// resolve names sid from names, then the well-known SIDs.
+func resolve(sid string, names map[string]string) string {
+	if name, ok := names[sid]; ok {
+		return name
+	}
+	if name, ok := WellKnownSIDs[sid]; ok {
+		return name
+	}
+	if strings.HasPrefix(sid, "S-1-5-21-") {
+		return domainRIDs[sid[strings.LastIndex(sid, "-")+1:]]
+	}
+	return ""
+}
+
+// Parse reads an export, which secedit writes in UTF-16. names adds SIDs
+// to the well-known ones and may be nil.
+func Parse(path string, names map[string]string) (*Policy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p, err := Read(text, names)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// decode returns the text of an export. Without a byte order mark, UTF-16
+// is recognized by the zero high byte of the leading '['.
+func decode(content []byte) (string, error) {
+	var bigEndian bool
+	switch {
+	case bytes.HasPrefix(content, []byte{0xff, 0xfe}):
+		content = content[2:]
+	case bytes.HasPrefix(content, []byte{0xfe, 0xff}):
+		content, bigEndian = content[2:], true
+	case bytes.HasPrefix(content, []byte{0xef, 0xbb, 0xbf}):
+		return string(content[3:]), nil
+	case len(content) >= 2 && content[0] != 0 && content[1] == 0:
+	case len(content) >= 2 && content[0] == 0 && content[1] != 0:
+		bigEndian = true
+	default:
+		return string(content), nil
+	}
+	if len(content)%2 != 0 {
+		return "", errors.New("UTF-16 text has an odd number of bytes")
+	}
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+		} else {
+			units[i] = uint16(content[2*i+1])<<8 | uint16(content[2*i])
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// Read parses the text of an export. Sections other than System Access,
+// Event Audit, Privilege Rights and Registry Values are skipped.
+func Read(text string, names map[string]string) (*Policy, error) {
+	p := &Policy{
+		Audit:    make(map[string]EventAudit),
+		Rights:   make(map[string][]Account),
+		Registry: make(map[string]RegistryValue),
+	}
+	settings := p.Access.settings()
+	var (
+		section string
+		number  int
+		errs    []error
+	)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch section {
+		case "System Access", "Event Audit", "Privilege Rights", "Registry Values":
+			if !ok {
+				errs = append(errs, fmt.Errorf("line %d: %q is not a key = value pair", number, line))
+				continue
+			}
+		default:
+			continue
+		}
+		var err error
+		switch section {
+		case "System Access":
+			switch key {
+			case "NewAdministratorName":
+				p.Access.NewAdministratorName = strings.Trim(value, `"`)
+			case "NewGuestName":
+				p.Access.NewGuestName = strings.Trim(value, `"`)
+			default:
+				s, known := settings[key]
+				if !known {
+					continue
+				}
+				if s.Value, err = strconv.Atoi(value); err == nil {
+					s.Set = true
+				}
+			}
+		case "Event Audit":
+			var n int
+			if n, err = strconv.Atoi(value); err == nil && (n < 0 || n > 3) {
+				err = fmt.Errorf("audit value %d is not 0 to 3", n)
+			}
+			p.Audit[key] = EventAudit{Success: n&1 != 0, Failure: n&2 != 0}
+		case "Privilege Rights":
+			var accounts []Account
+			for _, a := range strings.Split(value, ",") {
+				if a = strings.TrimSpace(a); a == "" {
+					continue
+				}
+				if sid, isSID := strings.CutPrefix(a, "*"); isSID {
+					accounts = append(accounts, Account{SID: sid, Name: resolve(sid, names)})
+				} else {
+					accounts = append(accounts, Account{Name: a})
+				}
+			}
+			p.Rights[key] = accounts
+		case "Registry Values":
+			p.Registry[key], err = registryValue(value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %s: %w", number, key, err))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// registryValue parses "type,data". Strings may be quoted, and a
+// multi-string has one comma-separated field per line.
+func registryValue(value string) (RegistryValue, error) {
+	typ, data, _ := strings.Cut(value, ",")
+	n, err := strconv.Atoi(typ)
+	if err != nil {
+		return RegistryValue{}, fmt.Errorf("registry type %q is not a number", typ)
+	}
+	v := RegistryValue{Type: n}
+	if n != MultiString {
+		v.Data = []string{unquote(data)}
+		return v, nil
+	}
+	var (
+		field  strings.Builder
+		quoted bool
+	)
+	for _, r := range data {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			v.Data = append(v.Data, field.String())
+			field.Reset()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 || len(v.Data) > 0 {
+		v.Data = append(v.Data, field.String())
+	}
+	return v, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
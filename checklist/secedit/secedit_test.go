@@ -0,0 +1,178 @@
+package secedit
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseUTF16(t *testing.T) {
+	p, err := Parse("testdata/hardened.inf", map[string]string{
+		"S-1-5-21-3623811015-3361044348-30300820-1013": "svc-backup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := p.Access
+	for _, s := range []struct {
+		name      string
+		got, want Setting
+	}{
+		{"MinimumPasswordLength", a.MinimumPasswordLength, Setting{15, true}},
+		{"PasswordHistorySize", a.PasswordHistorySize, Setting{24, true}},
+		{"LockoutBadCount", a.LockoutBadCount, Setting{5, true}},
+		{"LockoutDuration", a.LockoutDuration, Setting{15, true}},
+		{"EnableGuestAccount", a.EnableGuestAccount, Setting{0, true}},
+		{"ClearTextPassword", a.ClearTextPassword, Setting{0, true}},
+	} {
+		if s.got != s.want {
+			t.Errorf("%s: got %+v, want %+v", s.name, s.got, s.want)
+		}
+	}
+	if a.NewAdministratorName != "lcladm" {
+		t.Errorf("NewAdministratorName: got %q", a.NewAdministratorName)
+	}
+
+	if got, want := p.Audit["AuditPrivilegeUse"], (EventAudit{Failure: true}); got != want {
+		t.Errorf("AuditPrivilegeUse: got %+v, want %+v", got, want)
+	}
+	if got, want := p.Audit["AuditLogonEvents"], (EventAudit{Success: true, Failure: true}); got != want {
+		t.Errorf("AuditLogonEvents: got %+v, want %+v", got, want)
+	}
+
+	wantRights := map[string][]Account{
+		"SeBackupPrivilege": {
+			{SID: "S-1-5-32-544", Name: "Administrators"},
+			{SID: "S-1-5-21-3623811015-3361044348-30300820-1013", Name: "svc-backup"},
+		},
+		"SeDenyNetworkLogonRight": {
+			{SID: "S-1-5-32-546", Name: "Guests"},
+			{SID: "S-1-5-114", Name: "Local account and member of Administrators group"},
+		},
+		"SeDenyInteractiveLogonRight": {{Name: "Guest"}},
+	}
+	for right, want := range wantRights {
+		if got := p.Rights[right]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", right, got, want)
+		}
+	}
+	if _, ok := p.Rights["SeTcbPrivilege"]; ok {
+		t.Error("SeTcbPrivilege is not in the export but was parsed")
+	}
+
+	text := p.Registry[systemPolicies+"LegalNoticeText"]
+	want := RegistryValue{Type: MultiString, Data: []string{
+		"This system is for authorized users only.",
+		"Activity is monitored, and misuse is reported.",
+	}}
+	if !reflect.DeepEqual(text, want) {
+		t.Errorf("LegalNoticeText: got %+v, want %+v", text, want)
+	}
+	if n, ok := p.Registry[`MACHINE\System\CurrentControlSet\Control\Lsa\NoLMHash`].Int(); !ok || n != 1 {
+		t.Errorf("NoLMHash: got %d, %v", n, ok)
+	}
+	if got := p.Registry[systemPolicies+"LegalNoticeCaption"].Data; !reflect.DeepEqual(got, []string{"Authorized use only"}) {
+		t.Errorf("LegalNoticeCaption: got %q", got)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	const text = "[System Access]\r\nMinimumPasswordLength = 14\r\n"
+	units := utf16.Encode([]rune(text))
+	le, be := []byte{0xff, 0xfe}, []byte{0xfe, 0xff}
+	var bare []byte
+	for _, u := range units {
+		le = append(le, byte(u), byte(u>>8))
+		be = append(be, byte(u>>8), byte(u))
+		bare = append(bare, byte(u), byte(u>>8))
+	}
+	for name, content := range map[string][]byte{
+		"UTF-16LE":         le,
+		"UTF-16BE":         be,
+		"UTF-16LE, no BOM": bare,
+		"UTF-8 with BOM":   append([]byte{0xef, 0xbb, 0xbf}, text...),
+		"ANSI":             []byte(text),
+	} {
+		got, err := decode(content)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got != text {
+			t.Errorf("%s: got %q", name, got)
+		}
+	}
+	if _, err := decode([]byte{0xff, 0xfe, '['}); err == nil {
+		t.Error("odd UTF-16 length: no error")
+	}
+}
+
+func results(t *testing.T, path string) map[string][]string {
+	t.Helper()
+	p, err := Parse(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := make(map[string][]string)
+	for _, r := range Audit(p) {
+		findings[r.ID] = r.Findings
+	}
+	return findings
+}
+
+func TestHardenedPasses(t *testing.T) {
+	for id, findings := range results(t, "testdata/hardened.inf") {
+		if len(findings) > 0 {
+			t.Errorf("%s: %v", id, findings)
+		}
+	}
+}
+
+func TestDefaultFails(t *testing.T) {
+	want := map[string][]string{
+		"4001": {"MinimumPasswordLength is 0, want 15 or more"},
+		"4002": {"PasswordComplexity is 0, want 1"},
+		"4003": {"PasswordHistorySize is 0, want 24 or more"},
+		"4004": {"ClearTextPassword is 1, want 0"},
+		"4005": {"LockoutDuration is not defined"},
+		"4006": {"LockoutBadCount is 0, accounts never lock out"},
+		"4007": {"SeTcbPrivilege is assigned to Users", "SeTcbPrivilege is assigned to svc-legacy"},
+		"4008": {"LegalNoticeCaption is empty", "LegalNoticeText is empty"},
+		"4009": {"EnableGuestAccount is 1, want 0"},
+	}
+	if got := results(t, "testdata/default.inf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLockoutUntilUnlocked(t *testing.T) {
+	p, err := Read("[System Access]\nLockoutDuration = -1\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := checkLockoutDuration(p); findings != nil {
+		t.Errorf("got %v", findings)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, text := range []string{
+		"[System Access]\nMinimumPasswordLength\n",
+		"[Event Audit]\nAuditLogonEvents = 4\n",
+		"[Event Audit]\nAuditLogonEvents = yes\n",
+		"[Registry Values]\nMACHINE\\Software\\X=dword,1\n",
+	} {
+		if _, err := Read(text, nil); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+	// Sections the model does not cover are skipped, malformed or not.
+	if _, err := Read("[Version]\nsignature\n[Unicode]\nUnicode=yes\n", nil); err != nil {
+		t.Errorf("unknown sections: %v", err)
+	}
+	_, err := Read("[System Access]\nA\nB\n", nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("errors do not name every bad line: %v", err)
+	}
+}
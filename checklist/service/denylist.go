@@ -0,0 +1,73 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"checklist/platform"
+)
+
+// Distribution families used as keys of Service.Packages.
+const (
+	FamilyDebian = platform.FamilyDebian
+	FamilyRHEL   = platform.FamilyRHEL
+	FamilySUSE   = platform.FamilySUSE
+)
+
+// Service is one unwanted service: the packages that provide it on each
+// distribution family and the systemd units that start it.
+type Service struct {
+	Name     string              `json:"name"`
+	Packages map[string][]string `json:"packages,omitempty"`
+	Units    []string            `json:"units,omitempty"`
+}
+
+// Status is what was found for one service on this host.
+type Status struct {
+	Name      string
+	Packages  []string
+	Installed []string
+	Units     []string
+	Enabled   bool
+	Active    bool
+}
+
+// Compliant reports whether nothing of the service is present.
+func (s Status) Compliant() bool {
+	return len(s.Installed) == 0 && len(s.Units) == 0 && !s.Enabled && !s.Active
+}
+
+//go:embed denylist.json
+var defaultDenylist []byte
+
+// DefaultDenylist returns the services CIS recommends removing.
+func DefaultDenylist() []Service {
+	denylist, err := parseDenylist(defaultDenylist)
+	if err != nil {
+		panic(err)
+	}
+	return denylist
+}
+
+// LoadDenylist reads a JSON array of services from path.
+func LoadDenylist(path string) ([]Service, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	denylist, err := parseDenylist(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse denylist %s: %w", path, err)
+	}
+	return denylist, nil
+}
+
+func parseDenylist(content []byte) ([]Service, error) {
+	var denylist []Service
+	if err := json.Unmarshal(content, &denylist); err != nil {
+		return nil, err
+	}
+	return denylist, nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"checklist/agent"
+	"checklist/platform"
+)
+
+const dpkgStatus = "/var/lib/dpkg/status"
+
+// dpkgAbsent are the dpkg states that leave no program files behind.
+var dpkgAbsent = []string{"not-installed", "config-files"}
+
+func GetServices(denylist []Service) (string, error) {
+	family := Family()
+	statuses, err := Inspect(denylist, family)
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("family: %s\n", family)
+	for _, st := range statuses {
+		result += fmt.Sprintf("****%s****\n", st.Name)
+		for _, pkg := range st.Packages {
+			state := "not installed"
+			if slices.Contains(st.Installed, pkg) {
+				state = "installed"
+			}
+			result += fmt.Sprintf("package %s: %s\n", pkg, state)
+		}
+		if len(st.Units) > 0 {
+			result += fmt.Sprintf("units: %s\n", strings.Join(st.Units, ", "))
+		}
+		result += fmt.Sprintf("enabled: %t\n", st.Enabled)
+		result += fmt.Sprintf("active: %t\n", st.Active)
+		if st.Compliant() {
+			result += fmt.Sprintf("+%s is not present\n", st.Name)
+		} else {
+			result += fmt.Sprintf("-%s is present\n", st.Name)
+		}
+	}
+	return result, nil
+}
+
+// Inspect reports the package and unit state of every service in denylist,
+// looking packages up under family.
+func Inspect(denylist []Service, family string) ([]Status, error) {
+	var names []string
+	for _, s := range denylist {
+		names = append(names, s.Packages[family]...)
+	}
+	installed, err := installedPackages(family, names)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(denylist))
+	for _, s := range denylist {
+		st := Status{Name: s.Name, Packages: s.Packages[family]}
+		for _, pkg := range st.Packages {
+			if _, ok := installed[pkg]; ok {
+				st.Installed = append(st.Installed, pkg)
+			}
+		}
+		if len(s.Units) > 0 {
+			units := agent.Collect([]agent.Agent{{Name: s.Name, Linux: &agent.Platform{Services: s.Units}}})
+			if len(units) > 0 {
+				st.Units = units[0].Services
+				st.Enabled = units[0].Enabled
+				st.Active = units[0].Running
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Family is the package family of the running distribution, defaulting to
+// rpm when it cannot be determined.
+func Family() string {
+	if family := platform.Detect().Family; family != "" {
+		return family
+	}
+	return FamilyRHEL
+}
+
+func installedPackages(family string, names []string) (map[string]struct{}, error) {
+	if family == FamilyDebian {
+		return dpkgInstalled(dpkgStatus)
+	}
+	return rpmInstalled(names)
+}
+
+// dpkgInstalled reads the dpkg status database directly rather than running
+// dpkg -s once per package.
+func dpkgInstalled(path string) (map[string]struct{}, error) {
+	installed := make(map[string]struct{})
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return installed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkg, status string
+	record := func() {
+		fields := strings.Fields(status)
+		if pkg != "" && len(fields) == 3 && !slices.Contains(dpkgAbsent, fields[2]) {
+			installed[pkg] = struct{}{}
+		}
+		pkg, status = "", ""
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			record()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Package":
+			pkg = strings.TrimSpace(value)
+		case "Status":
+			status = strings.TrimSpace(value)
+		}
+	}
+	record()
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return installed, nil
+}
+
+// rpmInstalled queries the rpm database for names in one call. rpm exits
+// non-zero when any name is missing, so only its output is used.
+func rpmInstalled(names []string) (map[string]struct{}, error) {
+	installed := make(map[string]struct{})
+	if len(names) == 0 {
+		return installed, nil
+	}
+	if _, err := exec.LookPath("rpm"); err != nil {
+		return installed, nil
+	}
+	args := append([]string{"-q", "--queryformat", "%{NAME}\n"}, names...)
+	output, _ := exec.Command("rpm", args...).Output()
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "package ") {
+			continue
+		}
+		installed[line] = struct{}{}
+	}
+	return installed, nil
+}
@@ -0,0 +1,5 @@
+package sysctl
+
+func GetSysctl(parameters []string) (string, error) {
+	return "", nil
+}
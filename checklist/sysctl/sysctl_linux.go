@@ -0,0 +1,270 @@
+package sysctl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"checklist/dropin"
+)
+
+const procSys = "/proc/sys"
+
+// defaultParameters are the CIS network hardening settings and the value
+// each should have.
+var defaultParameters = []string{
+	"net.ipv4.ip_forward=0",
+	"net.ipv6.conf.all.forwarding=0",
+	"net.ipv4.conf.all.send_redirects=0",
+	"net.ipv4.conf.default.send_redirects=0",
+	"net.ipv4.conf.all.accept_source_route=0",
+	"net.ipv4.conf.default.accept_source_route=0",
+	"net.ipv6.conf.all.accept_source_route=0",
+	"net.ipv6.conf.default.accept_source_route=0",
+	"net.ipv4.conf.all.accept_redirects=0",
+	"net.ipv4.conf.default.accept_redirects=0",
+	"net.ipv6.conf.all.accept_redirects=0",
+	"net.ipv6.conf.default.accept_redirects=0",
+	"net.ipv4.conf.all.secure_redirects=0",
+	"net.ipv4.conf.default.secure_redirects=0",
+	"net.ipv4.conf.all.log_martians=1",
+	"net.ipv4.conf.default.log_martians=1",
+	"net.ipv4.icmp_echo_ignore_broadcasts=1",
+	"net.ipv4.icmp_ignore_bogus_error_responses=1",
+	"net.ipv4.conf.all.rp_filter=1",
+	"net.ipv4.conf.default.rp_filter=1",
+	"net.ipv4.tcp_syncookies=1",
+	"net.ipv6.conf.all.accept_ra=0",
+	"net.ipv6.conf.default.accept_ra=0",
+}
+
+// sysctlDirs are listed from lowest to highest precedence, as systemd-sysctl
+// reads them: a file name found in a later directory hides the same name in
+// earlier ones.
+var sysctlDirs = []string{
+	"/lib/sysctl.d",
+	"/usr/lib/sysctl.d",
+	"/usr/local/lib/sysctl.d",
+	"/run/sysctl.d",
+	"/etc/sysctl.d",
+}
+
+// sysctlConf is applied after every sysctl.d file, so it wins.
+const sysctlConf = "/etc/sysctl.conf"
+
+// Persisted is the value a key gets at boot and the line that sets it.
+type Persisted struct {
+	Value  string
+	Source string
+}
+
+// Parameter is the runtime and persisted state of one kernel parameter.
+type Parameter struct {
+	Key       string
+	Expected  string
+	Runtime   string
+	Persisted *Persisted
+}
+
+// Compliant reports whether both the live and the boot value are as expected.
+func (p Parameter) Compliant() bool {
+	return p.Runtime == p.Expected && p.Persisted != nil && p.Persisted.Value == p.Expected
+}
+
+// Mismatch reports whether the live value differs from what is persisted.
+func (p Parameter) Mismatch() bool {
+	return p.Persisted != nil && p.Runtime != "" && p.Runtime != p.Persisted.Value
+}
+
+type assignment struct {
+	pattern string
+	value   string
+	source  string
+}
+
+func GetSysctl(parameters []string) (string, error) {
+	if len(parameters) == 0 {
+		parameters = defaultParameters
+	}
+	params, err := Inspect(parameters)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	for _, p := range params {
+		mark := "-"
+		if p.Compliant() {
+			mark = "+"
+		}
+		runtime := p.Runtime
+		if runtime == "" {
+			runtime = "<unavailable>"
+		}
+		persisted := "<not set>"
+		if p.Persisted != nil {
+			persisted = fmt.Sprintf("%s (%s)", p.Persisted.Value, p.Persisted.Source)
+		}
+		result += fmt.Sprintf("%s%s expected=%s runtime=%s persisted=%s", mark, p.Key, p.Expected, runtime, persisted)
+		if p.Mismatch() {
+			result += " [runtime differs from persisted]"
+		}
+		result += "\n"
+	}
+	return result, nil
+}
+
+// Inspect expands the key=value expectations in parameters and reads the
+// runtime and persisted value of each resulting key.
+func Inspect(parameters []string) ([]Parameter, error) {
+	assignments, err := persistedAssignments()
+	if err != nil {
+		return nil, err
+	}
+
+	var params []Parameter
+	seen := make(map[string]struct{})
+	for _, spec := range parameters {
+		key, expected, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid sysctl expectation %q, want key=value", spec)
+		}
+		for _, k := range expand(normalizeKey(strings.TrimSpace(key))) {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			p := Parameter{Key: k, Expected: strings.TrimSpace(expected), Runtime: runtimeValue(k)}
+			p.Persisted = persistedValue(assignments, k)
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+// expand turns a key containing "*" into the keys present under /proc/sys.
+// Keys without wildcards are returned as is, even when absent.
+func expand(key string) []string {
+	if !strings.Contains(key, "*") {
+		return []string{key}
+	}
+	matches, _ := filepath.Glob(filepath.Join(procSys, swapSeparators(key)))
+	keys := make([]string, 0, len(matches))
+	for _, m := range matches {
+		rel, err := filepath.Rel(procSys, m)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, swapSeparators(rel))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func runtimeValue(key string) string {
+	content, err := os.ReadFile(filepath.Join(procSys, swapSeparators(key)))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(content)), " ")
+}
+
+// persistedValue applies assignments in order; the last one matching key wins.
+func persistedValue(assignments []assignment, key string) *Persisted {
+	var persisted *Persisted
+	for _, a := range assignments {
+		if a.pattern == key || matchKey(a.pattern, key) {
+			persisted = &Persisted{Value: a.value, Source: a.source}
+		}
+	}
+	return persisted
+}
+
+func matchKey(pattern, key string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return false
+	}
+	ok, _ := path.Match(swapSeparators(pattern), swapSeparators(key))
+	return ok
+}
+
+// persistedAssignments returns every assignment in the order they are applied
+// at boot: sysctl.d files sorted by name, then /etc/sysctl.conf.
+func persistedAssignments() ([]assignment, error) {
+	files := dropin.Files(sysctlDirs, ".conf")
+	if _, err := os.Stat(sysctlConf); err == nil {
+		files = append(files, sysctlConf)
+	}
+
+	var assignments []assignment
+	for _, file := range files {
+		parsed, err := parseSysctlFile(file)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, parsed...)
+	}
+	return assignments, nil
+}
+
+func parseSysctlFile(file string) ([]assignment, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		assignments []assignment
+		lineNo      int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		// A leading "-" only tells sysctl to ignore errors for the key.
+		key = normalizeKey(strings.TrimPrefix(strings.TrimSpace(key), "-"))
+		assignments = append(assignments, assignment{
+			pattern: key,
+			value:   strings.Join(strings.Fields(value), " "),
+			source:  fmt.Sprintf("%s:%d", file, lineNo),
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// normalizeKey accepts both net/ipv4/ip_forward and net.ipv4.ip_forward. As
+// in sysctl(8), a key containing a "/" is slash separated, so dots inside
+// interface names such as eth0.100 survive as "/" in the dotted form.
+func normalizeKey(key string) string {
+	if !strings.Contains(key, "/") {
+		return key
+	}
+	return swapSeparators(strings.Trim(key, "/"))
+}
+
+// swapSeparators converts between the dotted key and its /proc/sys path.
+func swapSeparators(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,5 @@
+package tcpwrappers
+
+func GetTCPWrappers() (string, error) {
+	return "", nil
+}
@@ -0,0 +1,303 @@
+package tcpwrappers
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+
+	"checklist/service"
+)
+
+const (
+	HostsAllow = "/etc/hosts.allow"
+	HostsDeny  = "/etc/hosts.deny"
+
+	wantMode = fs.FileMode(0o644)
+)
+
+var tcpd = service.Service{
+	Name: "tcpd",
+	Packages: map[string][]string{
+		service.FamilyDebian: {"tcpd"},
+		service.FamilyRHEL:   {"tcp_wrappers"},
+		service.FamilySUSE:   {"tcpd"},
+	},
+}
+
+// Rule is one "daemon_list : client_list [: option ...]" line.
+type Rule struct {
+	Daemons       []string
+	DaemonsExcept []string
+	Clients       []string
+	ClientsExcept []string
+	Options       []string
+	Source        string
+}
+
+// MatchesAll reports whether the rule applies to every daemon and client.
+func (r Rule) MatchesAll() bool {
+	return slices.Contains(r.Daemons, "ALL") && len(r.DaemonsExcept) == 0 &&
+		slices.Contains(r.Clients, "ALL") && len(r.ClientsExcept) == 0
+}
+
+// Denies reports whether the rule refuses access: every rule in hosts.deny
+// does, and a rule in hosts.allow does when its options end in DENY.
+func (r Rule) Denies(path string) bool {
+	if len(r.Options) > 0 {
+		switch strings.ToUpper(r.Options[len(r.Options)-1]) {
+		case "DENY":
+			return true
+		case "ALLOW":
+			return false
+		}
+	}
+	return path == HostsDeny
+}
+
+// File is the state and rules of one access control file.
+type File struct {
+	Path   string
+	Exists bool
+	Mode   fs.FileMode
+	UID    uint32
+	GID    uint32
+	Rules  []Rule
+}
+
+// PermissionsOK reports whether the file is 644 root:root.
+func (f File) PermissionsOK() bool {
+	return f.Exists && f.Mode == wantMode && f.UID == 0 && f.GID == 0
+}
+
+// Config is the TCP wrappers state of this host.
+type Config struct {
+	Installed   bool
+	Allow       File
+	Deny        File
+	DefaultDeny *Rule
+	// AllowAll is a hosts.allow rule that admits everything before the
+	// default deny is reached.
+	AllowAll *Rule
+}
+
+func GetTCPWrappers() (string, error) {
+	config, err := Inspect()
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	result += "****tcpd****\n"
+	if config.Installed {
+		result += "+tcpd is installed\n"
+	} else {
+		result += "-tcpd is not installed\n"
+	}
+	for _, f := range []File{config.Allow, config.Deny} {
+		result += fmt.Sprintf("****%s****\n", f.Path)
+		if !f.Exists {
+			result += "-file does not exist\n"
+			continue
+		}
+		mark := "-"
+		if f.PermissionsOK() {
+			mark = "+"
+		}
+		result += fmt.Sprintf("%smode=%o uid=%d gid=%d\n", mark, f.Mode, f.UID, f.GID)
+		for _, r := range f.Rules {
+			result += fmt.Sprintf("%s (%s)\n", formatRule(r), r.Source)
+		}
+		if f.Path == HostsAllow && len(f.Rules) == 0 {
+			result += "-no allow rules\n"
+		}
+	}
+	result += "****default deny****\n"
+	if config.DefaultDeny != nil {
+		result += fmt.Sprintf("+%s (%s)\n", formatRule(*config.DefaultDeny), config.DefaultDeny.Source)
+	} else {
+		result += "-no ALL: ALL deny rule\n"
+	}
+	if config.AllowAll != nil {
+		result += fmt.Sprintf("-%s in %s admits every client (%s)\n", formatRule(*config.AllowAll), HostsAllow, config.AllowAll.Source)
+	}
+	return result, nil
+}
+
+// Inspect reads hosts.allow and hosts.deny and evaluates them.
+func Inspect() (*Config, error) {
+	config := &Config{}
+	statuses, err := service.Inspect([]service.Service{tcpd}, service.Family())
+	if err != nil {
+		return nil, err
+	}
+	config.Installed = len(statuses) > 0 && len(statuses[0].Installed) > 0
+	if config.Allow, err = readFile(HostsAllow); err != nil {
+		return nil, err
+	}
+	if config.Deny, err = readFile(HostsDeny); err != nil {
+		return nil, err
+	}
+
+	// Access is decided by the first matching rule, hosts.allow first.
+	for _, f := range []File{config.Allow, config.Deny} {
+		for i := range f.Rules {
+			r := f.Rules[i]
+			if !r.MatchesAll() {
+				continue
+			}
+			if r.Denies(f.Path) {
+				config.DefaultDeny = &r
+			} else {
+				config.AllowAll = &r
+			}
+			break
+		}
+		if config.DefaultDeny != nil || config.AllowAll != nil {
+			break
+		}
+	}
+	return config, nil
+}
+
+func readFile(path string) (File, error) {
+	f := File{Path: path}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	f.Exists = true
+	f.Mode = info.Mode().Perm()
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		f.UID, f.GID = st.Uid, st.Gid
+	}
+	f.Rules, err = ParseRules(path)
+	return f, err
+}
+
+// ParseRules reads the access control rules from a hosts_access(5) file.
+func ParseRules(path string) ([]Rule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		rules   []Rule
+		lineNo  int
+		start   int
+		pending string
+	)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if pending == "" {
+			start = lineNo
+		}
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line = strings.TrimSpace(pending + line)
+		pending = ""
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if r, ok := parseRule(line); ok {
+			r.Source = fmt.Sprintf("%s:%d", path, start)
+			rules = append(rules, r)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func parseRule(line string) (Rule, bool) {
+	parts := splitFields(line)
+	if len(parts) < 2 {
+		return Rule{}, false
+	}
+	var r Rule
+	r.Daemons, r.DaemonsExcept = splitList(parts[0])
+	r.Clients, r.ClientsExcept = splitList(parts[1])
+	for _, opt := range parts[2:] {
+		r.Options = append(r.Options, strings.TrimSpace(opt))
+	}
+	return r, len(r.Daemons) > 0 && len(r.Clients) > 0
+}
+
+// splitFields splits on ":" except inside [] so IPv6 client patterns such as
+// [fe80::]/64 stay intact. A "\:" in an option is kept as a literal colon.
+func splitFields(line string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		depth   int
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line) && line[i+1] == ':':
+			current.WriteByte(':')
+			i++
+			continue
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case c == ':' && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	return append(parts, current.String())
+}
+
+// splitList separates a comma or space separated list into its patterns and
+// the patterns following EXCEPT.
+func splitList(list string) ([]string, []string) {
+	var include, except []string
+	inExcept := false
+	for _, item := range strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		if item == "EXCEPT" {
+			inExcept = true
+			continue
+		}
+		if inExcept {
+			except = append(except, item)
+		} else {
+			include = append(include, item)
+		}
+	}
+	return include, except
+}
+
+func formatRule(r Rule) string {
+	daemons := strings.Join(r.Daemons, ", ")
+	if len(r.DaemonsExcept) > 0 {
+		daemons += " EXCEPT " + strings.Join(r.DaemonsExcept, ", ")
+	}
+	clients := strings.Join(r.Clients, ", ")
+	if len(r.ClientsExcept) > 0 {
+		clients += " EXCEPT " + strings.Join(r.ClientsExcept, ", ")
+	}
+	rule := daemons + ": " + clients
+	for _, opt := range r.Options {
+		rule += ": " + opt
+	}
+	return rule
+}
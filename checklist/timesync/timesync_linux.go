@@ -0,0 +1,268 @@
+package timesync
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"checklist/agent"
+	"checklist/dropin"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	chrony    = "chrony"
+	ntpd      = "ntpd"
+	timesyncd = "systemd-timesyncd"
+)
+
+// daemons describes each time daemon in the agent catalog format so their
+// install, enable and run state is detected the same way as other agents.
+var daemons = []agent.Agent{
+	{
+		Name: chrony,
+		Linux: &agent.Platform{
+			Services:    []string{"chronyd.service", "chrony.service"},
+			Processes:   []string{"chronyd"},
+			ConfigPaths: []string{"/etc/chrony.conf", "/etc/chrony/chrony.conf"},
+		},
+	},
+	{
+		Name: ntpd,
+		Linux: &agent.Platform{
+			Services:    []string{"ntpd.service", "ntp.service", "ntpsec.service"},
+			Processes:   []string{"ntpd"},
+			ConfigPaths: []string{"/etc/ntp.conf", "/etc/ntpsec/ntp.conf"},
+		},
+	},
+	{
+		Name: timesyncd,
+		Linux: &agent.Platform{
+			Services: []string{"systemd-timesyncd.service"},
+			// comm is truncated to 15 characters.
+			Processes:   []string{"systemd-timesyn*"},
+			ConfigPaths: []string{"/etc/systemd/timesyncd.conf"},
+		},
+	},
+}
+
+var timesyncdDropInDirs = []string{
+	"/usr/lib/systemd/timesyncd.conf.d",
+	"/run/systemd/timesyncd.conf.d",
+	"/etc/systemd/timesyncd.conf.d",
+}
+
+// Source is one server, pool or peer a daemon is configured to use.
+type Source struct {
+	Kind   string
+	Host   string
+	Origin string
+}
+
+// Daemon is the state of one time daemon.
+type Daemon struct {
+	agent.Status
+	Sources []Source
+}
+
+func GetTimeSync() (string, error) {
+	var (
+		result  string
+		active  []string
+		sources int
+	)
+	for _, a := range daemons {
+		d := Daemon{Sources: configuredSources(a.Name, a.Linux.ConfigPaths)}
+		if statuses := agent.Collect([]agent.Agent{a}); len(statuses) > 0 {
+			d.Status = statuses[0]
+		}
+		result += fmt.Sprintf("****%s****\n", a.Name)
+		result += fmt.Sprintf("installed: %t\n", d.Installed)
+		result += fmt.Sprintf("running: %t\n", d.Running)
+		result += fmt.Sprintf("enabled: %t\n", d.Enabled)
+		for _, s := range d.Sources {
+			result += fmt.Sprintf("%s %s (%s)\n", s.Kind, s.Host, s.Origin)
+		}
+		if d.Running {
+			active = append(active, a.Name)
+			sources += len(d.Sources)
+		}
+	}
+
+	result += "****sync status****\n"
+	if slices.Contains(active, chrony) {
+		result += chronyTracking()
+	}
+	synced, err := kernelSynchronized()
+	if err != nil {
+		result += fmt.Sprintf("kernel clock: unknown (%v)\n", err)
+	} else {
+		result += fmt.Sprintf("kernel clock synchronized: %t\n", synced)
+	}
+
+	result += "****findings****\n"
+	switch {
+	case len(active) == 0:
+		result += "-no time daemon is running\n"
+	case len(active) > 1:
+		result += fmt.Sprintf("-multiple time daemons are running: %s\n", strings.Join(active, ", "))
+	}
+	if len(active) > 0 && sources == 0 {
+		result += "-no time source is configured for the running daemon\n"
+	}
+	return result, nil
+}
+
+func configuredSources(daemon string, paths []string) []Source {
+	if daemon == timesyncd {
+		return parseTimesyncd(append(slices.Clone(paths), dropin.Files(timesyncdDropInDirs, ".conf")...))
+	}
+	var sources []Source
+	seen := make(map[string]struct{})
+	for _, path := range paths {
+		sources = append(sources, parseNTPStyle(path, seen)...)
+	}
+	return sources
+}
+
+// parseNTPStyle reads the server, pool and peer directives shared by
+// chrony.conf and ntp.conf, following chrony's include, confdir and
+// sourcedir directives.
+func parseNTPStyle(path string, seen map[string]struct{}) []Source {
+	if _, ok := seen[path]; ok {
+		return nil
+	}
+	seen[path] = struct{}{}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var (
+		sources []Source
+		lineNo  int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		switch fields[0] {
+		case "server", "pool", "peer":
+			sources = append(sources, Source{
+				Kind:   fields[0],
+				Host:   fields[1],
+				Origin: fmt.Sprintf("%s:%d", path, lineNo),
+			})
+		case "include":
+			matches, _ := filepath.Glob(fields[1])
+			slices.Sort(matches)
+			for _, m := range matches {
+				sources = append(sources, parseNTPStyle(m, seen)...)
+			}
+		case "confdir", "sourcedir":
+			suffix := ".conf"
+			if fields[0] == "sourcedir" {
+				suffix = ".sources"
+			}
+			for _, dir := range fields[1:] {
+				matches, _ := filepath.Glob(filepath.Join(dir, "*"+suffix))
+				slices.Sort(matches)
+				for _, m := range matches {
+					sources = append(sources, parseNTPStyle(m, seen)...)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// parseTimesyncd returns the NTP= servers from the last file that sets them,
+// or FallbackNTP= when none does, matching timesyncd's behaviour.
+func parseTimesyncd(paths []string) []Source {
+	var primary, fallback []Source
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		var (
+			section string
+			lineNo  int
+		)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lineNo++
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+				continue
+			}
+			if strings.HasPrefix(line, "[") {
+				section = strings.Trim(line, "[]")
+				continue
+			}
+			key, value, ok := strings.Cut(line, "=")
+			if section != "Time" || !ok {
+				continue
+			}
+			var servers []Source
+			for _, host := range strings.Fields(value) {
+				servers = append(servers, Source{
+					Kind:   strings.TrimSpace(key),
+					Host:   host,
+					Origin: fmt.Sprintf("%s:%d", path, lineNo),
+				})
+			}
+			switch strings.TrimSpace(key) {
+			case "NTP":
+				primary = servers
+			case "FallbackNTP":
+				fallback = servers
+			}
+		}
+		f.Close()
+	}
+	if len(primary) > 0 {
+		return primary
+	}
+	return fallback
+}
+
+// chronyTracking reports the reference and leap status from chronyc.
+func chronyTracking() string {
+	output, err := exec.Command("chronyc", "-n", "tracking").Output()
+	if err != nil {
+		return fmt.Sprintf("chronyc tracking: %v\n", err)
+	}
+	var result string
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Reference ID", "Stratum", "System time", "Leap status":
+			result += fmt.Sprintf("chrony %s: %s\n", strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+	}
+	return result
+}
+
+// kernelSynchronized asks the kernel, via adjtimex, whether a time daemon
+// has marked the system clock as synchronized.
+func kernelSynchronized() (bool, error) {
+	var buf unix.Timex
+	state, err := unix.Adjtimex(&buf)
+	if err != nil {
+		return false, err
+	}
+	return state != unix.TIME_ERROR && buf.Status&unix.STA_UNSYNC == 0, nil
+}
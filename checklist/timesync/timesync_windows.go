@@ -0,0 +1,5 @@
+package timesync
+
+func GetTimeSync() (string, error) {
+	return "", nil
+}
@@ -1,13 +1,13 @@
 #!/bin/bash
 
 # Check if ldap-utils package is installed
-if dpkg -s ldap-utils 2>/dev/null | grep -q "Status: install oke installed" ; then
+if dpkg -s ldap-utils 2>/dev/null | grep -q "Status: install ok installed" ; then
   echo "ldap-utils package is installed."
   exit 1
 fi
 
 # Check if libnss-ldap package is installed
-if dpkg -s libnss-ldap 2>/dev/null | grep -q "Status: install oke installed"; then
+if dpkg -s libnss-ldap 2>/dev/null | grep -q "Status: install ok installed"; then
   echo "libnss-ldap package is installed."
   exit 1
 fi